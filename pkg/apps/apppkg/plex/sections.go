@@ -5,6 +5,8 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net/url"
+	"strings"
 )
 
 // LibrarySection is data about a library's section, ie movies, tv, music.
@@ -179,6 +181,20 @@ type GUID struct {
 	ID string `json:"id"`
 }
 
+// ExternalID returns the ID from a list of GUIDs for the provided agent, ie. tmdb, tvdb or imdb.
+// Plex formats these like tmdb://12345. Returns an empty string if there is no GUID for the agent.
+func ExternalID(guids []*GUID, agent string) string {
+	prefix := agent + "://"
+
+	for _, guid := range guids {
+		if guid != nil && strings.HasPrefix(guid.ID, prefix) {
+			return strings.TrimPrefix(guid.ID, prefix)
+		}
+	}
+
+	return ""
+}
+
 // GetPlexSectionKey gets a section key from Plex based on a key path.
 func (s *Server) GetPlexSectionKey(keyPath string) (*MediaSection, error) {
 	return s.GetPlexSectionKeyWithContext(context.Background(), keyPath)
@@ -228,3 +244,48 @@ func (s *Server) GetDirectoryWithContext(ctx context.Context) (*SectionDirectory
 
 	return v.MediaContainer, nil
 }
+
+// SectionForPath returns the library section with a location that contains the provided path.
+// If more than one section matches, the section with the longest (most specific) location wins.
+// Returns nil if no library section contains the path.
+func (d *SectionDirectory) SectionForPath(folder string) *LibrarySection {
+	var (
+		found   *LibrarySection
+		longest int
+	)
+
+	folder = strings.TrimRight(folder, `/\`)
+
+	for _, section := range d.Directory {
+		for _, loc := range section.Location {
+			root := strings.TrimRight(loc.Path, `/\`)
+			if len(root) <= longest {
+				continue
+			}
+
+			if folder == root || strings.HasPrefix(folder, root+"/") || strings.HasPrefix(folder, root+`\`) {
+				found, longest = section, len(root)
+			}
+		}
+	}
+
+	return found
+}
+
+// ScanPath requests a partial scan of a single folder in a library section.
+func (s *Server) ScanPath(sectionKey, folder string) error {
+	return s.ScanPathWithContext(context.Background(), sectionKey, folder)
+}
+
+// ScanPathWithContext requests a partial scan of a single folder in a library section.
+func (s *Server) ScanPathWithContext(ctx context.Context, sectionKey, folder string) error {
+	params := url.Values{}
+	params.Set("path", folder)
+
+	body, err := s.getPlexURL(ctx, s.config.URL+"/library/sections/"+sectionKey+"/refresh", params)
+	if err != nil {
+		return fmt.Errorf("%w: %s", err, string(body))
+	}
+
+	return nil
+}
@@ -0,0 +1,897 @@
+package apps
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"sync"
+
+	"github.com/Notifiarr/notifiarr/pkg/mnd"
+	"golift.io/starr"
+	"golift.io/starr/lidarr"
+	"golift.io/starr/radarr"
+	"golift.io/starr/readarr"
+	"golift.io/starr/sonarr"
+)
+
+/* The bulk handlers run many single item requests concurrently, and return a result for each item. */
+
+const (
+	// bulkParallel is the default number of items worked on at once.
+	bulkParallel = 5
+	// bulkParallelMax is the max number of items worked on at once; set with the parallel query parameter.
+	bulkParallelMax = 20
+	// bulkItemsMax is the max number of items in a bulk request.
+	bulkItemsMax = 1000
+)
+
+// ErrBulkSize is returned when a bulk request has no items, or too many items.
+var ErrBulkSize = fmt.Errorf("bulk requests must have between 1 and %d items", bulkItemsMax)
+
+// BulkResult is the result for a single item in a bulk request.
+type BulkResult struct {
+	Index   int         `json:"index"` // position in the request.
+	ID      int64       `json:"id"`    // the item ID from the request; TMDB or TVDB ID, or new album or book ID for adds.
+	Code    int         `json:"code"`  // the status code the single item handler would return.
+	Message interface{} `json:"message"`
+}
+
+// BulkResponse is returned by every bulk handler.
+type BulkResponse struct {
+	Succeeded int           `json:"succeeded"`
+	Failed    int           `json:"failed"`
+	Results   []*BulkResult `json:"results"` // in request order.
+}
+
+// BulkIDs is the input for bulk tag and delete requests.
+type BulkIDs struct {
+	IDs []int64 `json:"ids"`
+	// Tags are the tag IDs to add or remove.
+	Tags   []int `json:"tags,omitempty"`
+	Remove bool  `json:"remove,omitempty"` // remove the tags instead of adding them.
+	// DeleteFiles and AddImportExclusion are used when deleting.
+	DeleteFiles        bool `json:"deleteFiles,omitempty"`
+	AddImportExclusion bool `json:"addImportExclusion,omitempty"`
+}
+
+// bulk runs a function for every item in a request with bounded parallelism.
+// The function returns the item ID, a status code and a message or error.
+func bulk(req *http.Request, count int, run func(ctx context.Context, idx int) (int64, int, interface{})) (int, interface{}) {
+	if count < 1 {
+		return http.StatusUnprocessableEntity, fmt.Errorf("%d: %w", count, ErrBulkSize)
+	} else if count > bulkItemsMax {
+		return http.StatusRequestEntityTooLarge, fmt.Errorf("%d: %w", count, ErrBulkSize)
+	}
+
+	parallel, _ := strconv.Atoi(req.URL.Query().Get("parallel"))
+	if parallel < 1 {
+		parallel = bulkParallel
+	} else if parallel > bulkParallelMax {
+		parallel = bulkParallelMax
+	}
+
+	var (
+		ctx    = req.Context()
+		output = &BulkResponse{Results: make([]*BulkResult, count)}
+		limit  = make(chan struct{}, parallel)
+		wg     sync.WaitGroup
+	)
+
+	for idx := 0; idx < count; idx++ {
+		if !bulkAcquire(ctx, limit) {
+			// The request ended; the items that did not start are not worked on.
+			output.Results[idx] = &BulkResult{
+				Index:   idx,
+				Code:    http.StatusRequestTimeout,
+				Message: "canceled: " + ctx.Err().Error(),
+			}
+			continue
+		}
+
+		wg.Add(1)
+
+		go func(idx int) {
+			defer func() {
+				<-limit
+				wg.Done()
+			}()
+
+			result := &BulkResult{Index: idx}
+			result.ID, result.Code, result.Message = run(ctx, idx)
+
+			if err, ok := result.Message.(error); ok {
+				result.Message = err.Error() // errors do not encode to json.
+			}
+
+			output.Results[idx] = result
+		}(idx)
+	}
+
+	wg.Wait()
+
+	for _, result := range output.Results {
+		if result.Code >= http.StatusOK && result.Code < http.StatusMultipleChoices {
+			output.Succeeded++
+		} else {
+			output.Failed++
+		}
+	}
+
+	return http.StatusOK, output
+}
+
+// bulkAcquire waits for a free slot to work on an item. Returns false if the request ends first.
+func bulkAcquire(ctx context.Context, limit chan struct{}) bool {
+	select {
+	case <-ctx.Done():
+		return false
+	case limit <- struct{}{}:
+	}
+
+	if ctx.Err() != nil { // both were ready.
+		<-limit
+		return false
+	}
+
+	return true
+}
+
+// bulkTags adds or removes tag IDs from a list of tag IDs.
+func bulkTags(have []int, input *BulkIDs) []int {
+	tags := []int{}
+	remove := make(map[int]bool)
+
+	if input.Remove {
+		for _, tag := range input.Tags {
+			remove[tag] = true
+		}
+	}
+
+	for _, tag := range have {
+		if !remove[tag] {
+			tags = append(tags, tag)
+			remove[tag] = true // do not add it twice.
+		}
+	}
+
+	if !input.Remove {
+		for _, tag := range input.Tags {
+			if !remove[tag] {
+				tags = append(tags, tag)
+				remove[tag] = true
+			}
+		}
+	}
+
+	return tags
+}
+
+func decodeBulkIDs(req *http.Request) (*BulkIDs, error) {
+	var input BulkIDs
+	if err := json.NewDecoder(req.Body).Decode(&input); err != nil {
+		return nil, fmt.Errorf("decoding payload: %w", err)
+	}
+
+	return &input, nil
+}
+
+// @Description  Adds many Movies to Radarr at once. Each movie is checked and added like the add endpoint.
+// @Summary      Bulk Add Radarr Movies
+// @Tags         Radarr
+// @Produce      json
+// @Accept       json
+// @Param        instance  path   int64  true  "instance ID"
+// @Param        parallel  query  int    false "movies to add at once, default 5, max 20"
+// @Param        POST body []radarr.AddMovieInput true "new movies"
+// @Success      200  {object} apps.Respond.apiResponse{message=apps.BulkResponse} "results for each movie"
+// @Failure      400  {object} apps.Respond.apiResponse{message=string} "bad json payload"
+// @Failure      413  {object} apps.Respond.apiResponse{message=string} "too many movies"
+// @Failure      422  {object} apps.Respond.apiResponse{message=string} "no movies provided"
+// @Failure      404  {object} string "bad token or api key"
+// @Router       /api/radarr/{instance}/bulk/add [post]
+// @Security     ApiKeyAuth
+func radarrBulkAdd(req *http.Request) (int, interface{}) {
+	var payload []*radarr.AddMovieInput
+	if err := json.NewDecoder(req.Body).Decode(&payload); err != nil {
+		return http.StatusBadRequest, fmt.Errorf("decoding payload: %w", err)
+	}
+
+	return bulk(req, len(payload), func(ctx context.Context, idx int) (int64, int, interface{}) {
+		if payload[idx] == nil {
+			return 0, http.StatusUnprocessableEntity, fmt.Errorf("0: %w", ErrNoTMDB)
+		}
+
+		code, msg := radarrAdd(ctx, getRadarr(req), payload[idx])
+
+		return payload[idx].TmdbID, code, msg
+	})
+}
+
+// @Description  Updates many Movies in Radarr at once.
+// @Summary      Bulk Update Radarr Movies
+// @Tags         Radarr
+// @Produce      json
+// @Accept       json
+// @Param        instance  path   int64  true  "instance ID"
+// @Param        moveFiles query  bool   false "move files? true/false"
+// @Param        parallel  query  int    false "movies to update at once, default 5, max 20"
+// @Param        PUT body []radarr.Movie true "movies content"
+// @Success      200  {object} apps.Respond.apiResponse{message=apps.BulkResponse} "results for each movie"
+// @Failure      400  {object} apps.Respond.apiResponse{message=string} "bad json payload"
+// @Failure      413  {object} apps.Respond.apiResponse{message=string} "too many movies"
+// @Failure      422  {object} apps.Respond.apiResponse{message=string} "no movies provided"
+// @Failure      404  {object} string "bad token or api key"
+// @Router       /api/radarr/{instance}/bulk/update [put]
+// @Security     ApiKeyAuth
+func radarrBulkUpdate(req *http.Request) (int, interface{}) {
+	var payload []*radarr.Movie
+	if err := json.NewDecoder(req.Body).Decode(&payload); err != nil {
+		return http.StatusBadRequest, fmt.Errorf("decoding payload: %w", err)
+	}
+
+	moveFiles := req.URL.Query().Get("moveFiles") == fmt.Sprint(true)
+
+	return bulk(req, len(payload), func(ctx context.Context, idx int) (int64, int, interface{}) {
+		if payload[idx] == nil {
+			return 0, http.StatusUnprocessableEntity, "no movie provided"
+		}
+
+		_, err := getRadarr(req).UpdateMovieContext(ctx, payload[idx].ID, payload[idx], moveFiles)
+		if err != nil {
+			return payload[idx].ID, http.StatusServiceUnavailable, fmt.Errorf("updating movie: %w", err)
+		}
+
+		return payload[idx].ID, http.StatusOK, "radarr seems to have worked"
+	})
+}
+
+// @Description  Adds or removes tags on many Movies in Radarr at once. Only the tags are changed.
+// @Summary      Bulk Tag Radarr Movies
+// @Tags         Radarr
+// @Produce      json
+// @Accept       json
+// @Param        instance  path   int64  true  "instance ID"
+// @Param        parallel  query  int    false "movies to tag at once, default 5, max 20"
+// @Param        PUT body apps.BulkIDs true "movie IDs, tag IDs, and remove true/false"
+// @Success      200  {object} apps.Respond.apiResponse{message=apps.BulkResponse} "results for each movie"
+// @Failure      400  {object} apps.Respond.apiResponse{message=string} "bad json payload"
+// @Failure      413  {object} apps.Respond.apiResponse{message=string} "too many movies"
+// @Failure      422  {object} apps.Respond.apiResponse{message=string} "no movies provided"
+// @Failure      404  {object} string "bad token or api key"
+// @Router       /api/radarr/{instance}/bulk/tag [put]
+// @Security     ApiKeyAuth
+func radarrBulkTag(req *http.Request) (int, interface{}) {
+	input, err := decodeBulkIDs(req)
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+
+	return bulk(req, len(input.IDs), func(ctx context.Context, idx int) (int64, int, interface{}) {
+		tags, err := bulkTag(ctx, getRadarr(req).APIer, path.Join(radarr.APIver, "movie"), input.IDs[idx], input)
+		if err != nil {
+			return input.IDs[idx], http.StatusServiceUnavailable, fmt.Errorf("tagging movie: %w", err)
+		}
+
+		return input.IDs[idx], http.StatusOK, tags
+	})
+}
+
+// @Description  Triggers a search for many Movies in Radarr at once. One search command is sent for each movie.
+// @Summary      Bulk Search Radarr Movies
+// @Tags         Radarr
+// @Produce      json
+// @Accept       json
+// @Param        instance  path   int64  true  "instance ID"
+// @Param        parallel  query  int    false "searches to send at once, default 5, max 20"
+// @Param        POST body []int64 true "movie IDs"
+// @Success      200  {object} apps.Respond.apiResponse{message=apps.BulkResponse} "results for each movie"
+// @Failure      400  {object} apps.Respond.apiResponse{message=string} "bad json payload"
+// @Failure      413  {object} apps.Respond.apiResponse{message=string} "too many movies"
+// @Failure      422  {object} apps.Respond.apiResponse{message=string} "no movies provided"
+// @Failure      404  {object} string "bad token or api key"
+// @Router       /api/radarr/{instance}/bulk/search [post]
+// @Security     ApiKeyAuth
+func radarrBulkSearch(req *http.Request) (int, interface{}) {
+	var ids []int64
+	if err := json.NewDecoder(req.Body).Decode(&ids); err != nil {
+		return http.StatusBadRequest, fmt.Errorf("decoding payload: %w", err)
+	}
+
+	return bulk(req, len(ids), func(ctx context.Context, idx int) (int64, int, interface{}) {
+		output, err := getRadarr(req).SendCommandContext(ctx, &radarr.CommandRequest{
+			Name:     "MoviesSearch",
+			MovieIDs: []int64{ids[idx]},
+		})
+		if err != nil {
+			return ids[idx], http.StatusServiceUnavailable, fmt.Errorf("triggering movie search: %w", err)
+		}
+
+		return ids[idx], http.StatusOK, output.Status
+	})
+}
+
+// @Description  Deletes many Movies from Radarr at once.
+// @Summary      Bulk Delete Radarr Movies
+// @Tags         Radarr
+// @Produce      json
+// @Accept       json
+// @Param        instance  path   int64  true  "instance ID"
+// @Param        parallel  query  int    false "movies to delete at once, default 5, max 20"
+// @Param        DELETE body apps.BulkIDs true "movie IDs, deleteFiles and addImportExclusion"
+// @Success      200  {object} apps.Respond.apiResponse{message=apps.BulkResponse} "results for each movie"
+// @Failure      400  {object} apps.Respond.apiResponse{message=string} "bad json payload"
+// @Failure      413  {object} apps.Respond.apiResponse{message=string} "too many movies"
+// @Failure      422  {object} apps.Respond.apiResponse{message=string} "no movies provided"
+// @Failure      404  {object} string "bad token or api key"
+// @Router       /api/radarr/{instance}/bulk/delete [delete]
+// @Security     ApiKeyAuth
+func radarrBulkDelete(req *http.Request) (int, interface{}) {
+	input, err := decodeBulkIDs(req)
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+
+	return bulk(req, len(input.IDs), func(ctx context.Context, idx int) (int64, int, interface{}) {
+		err := getRadarr(req).DeleteMovieContext(ctx, input.IDs[idx], input.DeleteFiles, input.AddImportExclusion)
+		if err != nil {
+			return input.IDs[idx], http.StatusServiceUnavailable, fmt.Errorf("deleting movie: %w", err)
+		}
+
+		return input.IDs[idx], http.StatusOK, "deleted"
+	})
+}
+
+// @Description  Adds many Series to Sonarr at once. Each series is checked and added like the add endpoint.
+// @Summary      Bulk Add Sonarr Series
+// @Tags         Sonarr
+// @Produce      json
+// @Accept       json
+// @Param        instance  path   int64  true  "instance ID"
+// @Param        parallel  query  int    false "series to add at once, default 5, max 20"
+// @Param        POST body []sonarr.AddSeriesInput true "new series"
+// @Success      200  {object} apps.Respond.apiResponse{message=apps.BulkResponse} "results for each series"
+// @Failure      400  {object} apps.Respond.apiResponse{message=string} "bad json payload"
+// @Failure      413  {object} apps.Respond.apiResponse{message=string} "too many series"
+// @Failure      422  {object} apps.Respond.apiResponse{message=string} "no series provided"
+// @Failure      404  {object} string "bad token or api key"
+// @Router       /api/sonarr/{instance}/bulk/add [post]
+// @Security     ApiKeyAuth
+func sonarrBulkAdd(req *http.Request) (int, interface{}) {
+	var payload []*sonarr.AddSeriesInput
+	if err := json.NewDecoder(req.Body).Decode(&payload); err != nil {
+		return http.StatusBadRequest, fmt.Errorf("decoding payload: %w", err)
+	}
+
+	return bulk(req, len(payload), func(ctx context.Context, idx int) (int64, int, interface{}) {
+		if payload[idx] == nil {
+			return 0, http.StatusUnprocessableEntity, fmt.Errorf("0: %w", ErrNoTVDB)
+		}
+
+		code, msg := sonarrAdd(ctx, getSonarr(req), payload[idx])
+
+		return payload[idx].TvdbID, code, msg
+	})
+}
+
+// @Description  Updates many Series in Sonarr at once.
+// @Summary      Bulk Update Sonarr Series
+// @Tags         Sonarr
+// @Produce      json
+// @Accept       json
+// @Param        instance  path   int64  true  "instance ID"
+// @Param        moveFiles query  bool   false "move files? true/false"
+// @Param        parallel  query  int    false "series to update at once, default 5, max 20"
+// @Param        PUT body []sonarr.AddSeriesInput true "series content"
+// @Success      200  {object} apps.Respond.apiResponse{message=apps.BulkResponse} "results for each series"
+// @Failure      400  {object} apps.Respond.apiResponse{message=string} "bad json payload"
+// @Failure      413  {object} apps.Respond.apiResponse{message=string} "too many series"
+// @Failure      422  {object} apps.Respond.apiResponse{message=string} "no series provided"
+// @Failure      404  {object} string "bad token or api key"
+// @Router       /api/sonarr/{instance}/bulk/update [put]
+// @Security     ApiKeyAuth
+func sonarrBulkUpdate(req *http.Request) (int, interface{}) {
+	var payload []*sonarr.AddSeriesInput
+	if err := json.NewDecoder(req.Body).Decode(&payload); err != nil {
+		return http.StatusBadRequest, fmt.Errorf("decoding payload: %w", err)
+	}
+
+	moveFiles := req.URL.Query().Get("moveFiles") == fmt.Sprint(true)
+
+	return bulk(req, len(payload), func(ctx context.Context, idx int) (int64, int, interface{}) {
+		if payload[idx] == nil {
+			return 0, http.StatusUnprocessableEntity, "no series provided"
+		}
+
+		_, err := getSonarr(req).UpdateSeriesContext(ctx, payload[idx], moveFiles)
+		if err != nil {
+			return payload[idx].ID, http.StatusServiceUnavailable, fmt.Errorf("updating series: %w", err)
+		}
+
+		return payload[idx].ID, http.StatusOK, "sonarr seems to have worked"
+	})
+}
+
+// @Description  Adds or removes tags on many Series in Sonarr at once. Only the tags are changed.
+// @Summary      Bulk Tag Sonarr Series
+// @Tags         Sonarr
+// @Produce      json
+// @Accept       json
+// @Param        instance  path   int64  true  "instance ID"
+// @Param        parallel  query  int    false "series to tag at once, default 5, max 20"
+// @Param        PUT body apps.BulkIDs true "series IDs, tag IDs, and remove true/false"
+// @Success      200  {object} apps.Respond.apiResponse{message=apps.BulkResponse} "results for each series"
+// @Failure      400  {object} apps.Respond.apiResponse{message=string} "bad json payload"
+// @Failure      413  {object} apps.Respond.apiResponse{message=string} "too many series"
+// @Failure      422  {object} apps.Respond.apiResponse{message=string} "no series provided"
+// @Failure      404  {object} string "bad token or api key"
+// @Router       /api/sonarr/{instance}/bulk/tag [put]
+// @Security     ApiKeyAuth
+func sonarrBulkTag(req *http.Request) (int, interface{}) {
+	input, err := decodeBulkIDs(req)
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+
+	return bulk(req, len(input.IDs), func(ctx context.Context, idx int) (int64, int, interface{}) {
+		tags, err := bulkTag(ctx, getSonarr(req).APIer, path.Join(sonarr.APIver, "series"), input.IDs[idx], input)
+		if err != nil {
+			return input.IDs[idx], http.StatusServiceUnavailable, fmt.Errorf("tagging series: %w", err)
+		}
+
+		return input.IDs[idx], http.StatusOK, tags
+	})
+}
+
+// @Description  Triggers a search for many Series in Sonarr at once. One search command is sent for each series.
+// @Summary      Bulk Search Sonarr Series
+// @Tags         Sonarr
+// @Produce      json
+// @Accept       json
+// @Param        instance  path   int64  true  "instance ID"
+// @Param        parallel  query  int    false "searches to send at once, default 5, max 20"
+// @Param        POST body []int64 true "series IDs"
+// @Success      200  {object} apps.Respond.apiResponse{message=apps.BulkResponse} "results for each series"
+// @Failure      400  {object} apps.Respond.apiResponse{message=string} "bad json payload"
+// @Failure      413  {object} apps.Respond.apiResponse{message=string} "too many series"
+// @Failure      422  {object} apps.Respond.apiResponse{message=string} "no series provided"
+// @Failure      404  {object} string "bad token or api key"
+// @Router       /api/sonarr/{instance}/bulk/search [post]
+// @Security     ApiKeyAuth
+func sonarrBulkSearch(req *http.Request) (int, interface{}) {
+	var ids []int64
+	if err := json.NewDecoder(req.Body).Decode(&ids); err != nil {
+		return http.StatusBadRequest, fmt.Errorf("decoding payload: %w", err)
+	}
+
+	return bulk(req, len(ids), func(ctx context.Context, idx int) (int64, int, interface{}) {
+		output, err := getSonarr(req).SendCommandContext(ctx, &sonarr.CommandRequest{
+			Name:     "SeriesSearch",
+			SeriesID: ids[idx],
+		})
+		if err != nil {
+			return ids[idx], http.StatusServiceUnavailable, fmt.Errorf("triggering series search: %w", err)
+		}
+
+		return ids[idx], http.StatusOK, output.Status
+	})
+}
+
+// @Description  Deletes many Series from Sonarr at once.
+// @Summary      Bulk Delete Sonarr Series
+// @Tags         Sonarr
+// @Produce      json
+// @Accept       json
+// @Param        instance  path   int64  true  "instance ID"
+// @Param        parallel  query  int    false "series to delete at once, default 5, max 20"
+// @Param        DELETE body apps.BulkIDs true "series IDs, deleteFiles and addImportExclusion"
+// @Success      200  {object} apps.Respond.apiResponse{message=apps.BulkResponse} "results for each series"
+// @Failure      400  {object} apps.Respond.apiResponse{message=string} "bad json payload"
+// @Failure      413  {object} apps.Respond.apiResponse{message=string} "too many series"
+// @Failure      422  {object} apps.Respond.apiResponse{message=string} "no series provided"
+// @Failure      404  {object} string "bad token or api key"
+// @Router       /api/sonarr/{instance}/bulk/delete [delete]
+// @Security     ApiKeyAuth
+func sonarrBulkDelete(req *http.Request) (int, interface{}) {
+	input, err := decodeBulkIDs(req)
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+
+	return bulk(req, len(input.IDs), func(ctx context.Context, idx int) (int64, int, interface{}) {
+		err := getSonarr(req).DeleteSeriesContext(ctx, int(input.IDs[idx]), input.DeleteFiles, input.AddImportExclusion)
+		if err != nil {
+			return input.IDs[idx], http.StatusServiceUnavailable, fmt.Errorf("deleting series: %w", err)
+		}
+
+		return input.IDs[idx], http.StatusOK, "deleted"
+	})
+}
+
+// @Description  Adds many Albums to Lidarr at once. Each album is checked and added like the add endpoint.
+// @Description  Lidarr uses string IDs, so the result ID is the new album's ID.
+// @Summary      Bulk Add Lidarr Albums
+// @Tags         Lidarr
+// @Produce      json
+// @Accept       json
+// @Param        instance  path   int64  true  "instance ID"
+// @Param        parallel  query  int    false "albums to add at once, default 5, max 20"
+// @Param        POST body []lidarr.AddAlbumInput true "new albums"
+// @Success      200  {object} apps.Respond.apiResponse{message=apps.BulkResponse} "results for each album"
+// @Failure      400  {object} apps.Respond.apiResponse{message=string} "bad json payload"
+// @Failure      413  {object} apps.Respond.apiResponse{message=string} "too many albums"
+// @Failure      422  {object} apps.Respond.apiResponse{message=string} "no albums provided"
+// @Failure      404  {object} string "bad token or api key"
+// @Router       /api/lidarr/{instance}/bulk/add [post]
+// @Security     ApiKeyAuth
+func lidarrBulkAdd(req *http.Request) (int, interface{}) {
+	var payload []*lidarr.AddAlbumInput
+	if err := json.NewDecoder(req.Body).Decode(&payload); err != nil {
+		return http.StatusBadRequest, fmt.Errorf("decoding payload: %w", err)
+	}
+
+	return bulk(req, len(payload), func(ctx context.Context, idx int) (int64, int, interface{}) {
+		if payload[idx] == nil {
+			return 0, http.StatusUnprocessableEntity, fmt.Errorf("0: %w", ErrNoMBID)
+		}
+
+		code, msg := lidarrAdd(ctx, getLidarr(req), payload[idx])
+		if album, ok := msg.(*lidarr.Album); ok {
+			return album.ID, code, msg
+		}
+
+		return 0, code, msg
+	})
+}
+
+// @Description  Updates many Artists in Lidarr at once.
+// @Summary      Bulk Update Lidarr Artists
+// @Tags         Lidarr
+// @Produce      json
+// @Accept       json
+// @Param        instance  path   int64  true  "instance ID"
+// @Param        parallel  query  int    false "artists to update at once, default 5, max 20"
+// @Param        PUT body []lidarr.Artist true "artists content"
+// @Success      200  {object} apps.Respond.apiResponse{message=apps.BulkResponse} "results for each artist"
+// @Failure      400  {object} apps.Respond.apiResponse{message=string} "bad json payload"
+// @Failure      413  {object} apps.Respond.apiResponse{message=string} "too many artists"
+// @Failure      422  {object} apps.Respond.apiResponse{message=string} "no artists provided"
+// @Failure      404  {object} string "bad token or api key"
+// @Router       /api/lidarr/{instance}/bulk/update [put]
+// @Security     ApiKeyAuth
+func lidarrBulkUpdate(req *http.Request) (int, interface{}) {
+	var payload []*lidarr.Artist
+	if err := json.NewDecoder(req.Body).Decode(&payload); err != nil {
+		return http.StatusBadRequest, fmt.Errorf("decoding payload: %w", err)
+	}
+
+	return bulk(req, len(payload), func(ctx context.Context, idx int) (int64, int, interface{}) {
+		if payload[idx] == nil {
+			return 0, http.StatusUnprocessableEntity, "no artist provided"
+		}
+
+		_, err := getLidarr(req).UpdateArtistContext(ctx, payload[idx])
+		if err != nil {
+			return payload[idx].ID, http.StatusServiceUnavailable, fmt.Errorf("updating artist: %w", err)
+		}
+
+		return payload[idx].ID, http.StatusOK, mnd.Success
+	})
+}
+
+// @Description  Adds or removes tags on many Artists in Lidarr at once. Only the tags are changed.
+// @Summary      Bulk Tag Lidarr Artists
+// @Tags         Lidarr
+// @Produce      json
+// @Accept       json
+// @Param        instance  path   int64  true  "instance ID"
+// @Param        parallel  query  int    false "artists to tag at once, default 5, max 20"
+// @Param        PUT body apps.BulkIDs true "artist IDs, tag IDs, and remove true/false"
+// @Success      200  {object} apps.Respond.apiResponse{message=apps.BulkResponse} "results for each artist"
+// @Failure      400  {object} apps.Respond.apiResponse{message=string} "bad json payload"
+// @Failure      413  {object} apps.Respond.apiResponse{message=string} "too many artists"
+// @Failure      422  {object} apps.Respond.apiResponse{message=string} "no artists provided"
+// @Failure      404  {object} string "bad token or api key"
+// @Router       /api/lidarr/{instance}/bulk/tag [put]
+// @Security     ApiKeyAuth
+func lidarrBulkTag(req *http.Request) (int, interface{}) {
+	input, err := decodeBulkIDs(req)
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+
+	return bulk(req, len(input.IDs), func(ctx context.Context, idx int) (int64, int, interface{}) {
+		tags, err := bulkTag(ctx, getLidarr(req).APIer, path.Join(lidarr.APIver, "artist"), input.IDs[idx], input)
+		if err != nil {
+			return input.IDs[idx], http.StatusServiceUnavailable, fmt.Errorf("tagging artist: %w", err)
+		}
+
+		return input.IDs[idx], http.StatusOK, tags
+	})
+}
+
+// @Description  Triggers a search for many Albums in Lidarr at once. One search command is sent for each album.
+// @Summary      Bulk Search Lidarr Albums
+// @Tags         Lidarr
+// @Produce      json
+// @Accept       json
+// @Param        instance  path   int64  true  "instance ID"
+// @Param        parallel  query  int    false "searches to send at once, default 5, max 20"
+// @Param        POST body []int64 true "album IDs"
+// @Success      200  {object} apps.Respond.apiResponse{message=apps.BulkResponse} "results for each album"
+// @Failure      400  {object} apps.Respond.apiResponse{message=string} "bad json payload"
+// @Failure      413  {object} apps.Respond.apiResponse{message=string} "too many albums"
+// @Failure      422  {object} apps.Respond.apiResponse{message=string} "no albums provided"
+// @Failure      404  {object} string "bad token or api key"
+// @Router       /api/lidarr/{instance}/bulk/search [post]
+// @Security     ApiKeyAuth
+func lidarrBulkSearch(req *http.Request) (int, interface{}) {
+	var ids []int64
+	if err := json.NewDecoder(req.Body).Decode(&ids); err != nil {
+		return http.StatusBadRequest, fmt.Errorf("decoding payload: %w", err)
+	}
+
+	return bulk(req, len(ids), func(ctx context.Context, idx int) (int64, int, interface{}) {
+		output, err := getLidarr(req).SendCommandContext(ctx, &lidarr.CommandRequest{
+			Name:     "AlbumSearch",
+			AlbumIDs: []int64{ids[idx]},
+		})
+		if err != nil {
+			return ids[idx], http.StatusServiceUnavailable, fmt.Errorf("triggering album search: %w", err)
+		}
+
+		return ids[idx], http.StatusOK, output.Status
+	})
+}
+
+// @Description  Deletes many Artists from Lidarr at once.
+// @Summary      Bulk Delete Lidarr Artists
+// @Tags         Lidarr
+// @Produce      json
+// @Accept       json
+// @Param        instance  path   int64  true  "instance ID"
+// @Param        parallel  query  int    false "artists to delete at once, default 5, max 20"
+// @Param        DELETE body apps.BulkIDs true "artist IDs, deleteFiles and addImportExclusion"
+// @Success      200  {object} apps.Respond.apiResponse{message=apps.BulkResponse} "results for each artist"
+// @Failure      400  {object} apps.Respond.apiResponse{message=string} "bad json payload"
+// @Failure      413  {object} apps.Respond.apiResponse{message=string} "too many artists"
+// @Failure      422  {object} apps.Respond.apiResponse{message=string} "no artists provided"
+// @Failure      404  {object} string "bad token or api key"
+// @Router       /api/lidarr/{instance}/bulk/delete [delete]
+// @Security     ApiKeyAuth
+func lidarrBulkDelete(req *http.Request) (int, interface{}) {
+	input, err := decodeBulkIDs(req)
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+
+	return bulk(req, len(input.IDs), func(ctx context.Context, idx int) (int64, int, interface{}) {
+		err := bulkDelete(ctx, getLidarr(req).APIer, path.Join(lidarr.APIver, "artist"), input.IDs[idx], input)
+		if err != nil {
+			return input.IDs[idx], http.StatusServiceUnavailable, fmt.Errorf("deleting artist: %w", err)
+		}
+
+		return input.IDs[idx], http.StatusOK, "deleted"
+	})
+}
+
+// @Description  Adds many Books to Readarr at once. Each book is checked and added like the add endpoint.
+// @Description  Readarr uses string IDs, so the result ID is the new book's ID.
+// @Summary      Bulk Add Readarr Books
+// @Tags         Readarr
+// @Produce      json
+// @Accept       json
+// @Param        instance  path   int64  true  "instance ID"
+// @Param        parallel  query  int    false "books to add at once, default 5, max 20"
+// @Param        POST body []readarr.AddBookInput true "new books"
+// @Success      200  {object} apps.Respond.apiResponse{message=apps.BulkResponse} "results for each book"
+// @Failure      400  {object} apps.Respond.apiResponse{message=string} "bad json payload"
+// @Failure      413  {object} apps.Respond.apiResponse{message=string} "too many books"
+// @Failure      422  {object} apps.Respond.apiResponse{message=string} "no books provided"
+// @Failure      404  {object} string "bad token or api key"
+// @Router       /api/readarr/{instance}/bulk/add [post]
+// @Security     ApiKeyAuth
+func readarrBulkAdd(req *http.Request) (int, interface{}) {
+	var payload []*readarr.AddBookInput
+	if err := json.NewDecoder(req.Body).Decode(&payload); err != nil {
+		return http.StatusBadRequest, fmt.Errorf("decoding payload: %w", err)
+	}
+
+	return bulk(req, len(payload), func(ctx context.Context, idx int) (int64, int, interface{}) {
+		if payload[idx] == nil {
+			return 0, http.StatusUnprocessableEntity, fmt.Errorf("0: %w", ErrNoGRID)
+		}
+
+		code, msg := readarrAdd(ctx, getReadarr(req), payload[idx])
+		if book, ok := msg.(*readarr.Book); ok {
+			return book.ID, code, msg
+		}
+
+		return 0, code, msg
+	})
+}
+
+// @Description  Updates many Authors in Readarr at once.
+// @Summary      Bulk Update Readarr Authors
+// @Tags         Readarr
+// @Produce      json
+// @Accept       json
+// @Param        instance  path   int64  true  "instance ID"
+// @Param        parallel  query  int    false "authors to update at once, default 5, max 20"
+// @Param        PUT body []readarr.Author true "authors content"
+// @Success      200  {object} apps.Respond.apiResponse{message=apps.BulkResponse} "results for each author"
+// @Failure      400  {object} apps.Respond.apiResponse{message=string} "bad json payload"
+// @Failure      413  {object} apps.Respond.apiResponse{message=string} "too many authors"
+// @Failure      422  {object} apps.Respond.apiResponse{message=string} "no authors provided"
+// @Failure      404  {object} string "bad token or api key"
+// @Router       /api/readarr/{instance}/bulk/update [put]
+// @Security     ApiKeyAuth
+func readarrBulkUpdate(req *http.Request) (int, interface{}) {
+	var payload []*readarr.Author
+	if err := json.NewDecoder(req.Body).Decode(&payload); err != nil {
+		return http.StatusBadRequest, fmt.Errorf("decoding payload: %w", err)
+	}
+
+	return bulk(req, len(payload), func(ctx context.Context, idx int) (int64, int, interface{}) {
+		if payload[idx] == nil {
+			return 0, http.StatusUnprocessableEntity, "no author provided"
+		}
+
+		err := getReadarr(req).UpdateAuthorContext(ctx, payload[idx].ID, payload[idx])
+		if err != nil {
+			return payload[idx].ID, http.StatusServiceUnavailable, fmt.Errorf("updating author: %w", err)
+		}
+
+		return payload[idx].ID, http.StatusOK, "readarr seems to have worked"
+	})
+}
+
+// @Description  Adds or removes tags on many Authors in Readarr at once. Only the tags are changed.
+// @Summary      Bulk Tag Readarr Authors
+// @Tags         Readarr
+// @Produce      json
+// @Accept       json
+// @Param        instance  path   int64  true  "instance ID"
+// @Param        parallel  query  int    false "authors to tag at once, default 5, max 20"
+// @Param        PUT body apps.BulkIDs true "author IDs, tag IDs, and remove true/false"
+// @Success      200  {object} apps.Respond.apiResponse{message=apps.BulkResponse} "results for each author"
+// @Failure      400  {object} apps.Respond.apiResponse{message=string} "bad json payload"
+// @Failure      413  {object} apps.Respond.apiResponse{message=string} "too many authors"
+// @Failure      422  {object} apps.Respond.apiResponse{message=string} "no authors provided"
+// @Failure      404  {object} string "bad token or api key"
+// @Router       /api/readarr/{instance}/bulk/tag [put]
+// @Security     ApiKeyAuth
+func readarrBulkTag(req *http.Request) (int, interface{}) {
+	input, err := decodeBulkIDs(req)
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+
+	return bulk(req, len(input.IDs), func(ctx context.Context, idx int) (int64, int, interface{}) {
+		tags, err := bulkTag(ctx, getReadarr(req).APIer, path.Join(readarr.APIver, "author"), input.IDs[idx], input)
+		if err != nil {
+			return input.IDs[idx], http.StatusServiceUnavailable, fmt.Errorf("tagging author: %w", err)
+		}
+
+		return input.IDs[idx], http.StatusOK, tags
+	})
+}
+
+// @Description  Triggers a search for many Books in Readarr at once. One search command is sent for each book.
+// @Summary      Bulk Search Readarr Books
+// @Tags         Readarr
+// @Produce      json
+// @Accept       json
+// @Param        instance  path   int64  true  "instance ID"
+// @Param        parallel  query  int    false "searches to send at once, default 5, max 20"
+// @Param        POST body []int64 true "book IDs"
+// @Success      200  {object} apps.Respond.apiResponse{message=apps.BulkResponse} "results for each book"
+// @Failure      400  {object} apps.Respond.apiResponse{message=string} "bad json payload"
+// @Failure      413  {object} apps.Respond.apiResponse{message=string} "too many books"
+// @Failure      422  {object} apps.Respond.apiResponse{message=string} "no books provided"
+// @Failure      404  {object} string "bad token or api key"
+// @Router       /api/readarr/{instance}/bulk/search [post]
+// @Security     ApiKeyAuth
+func readarrBulkSearch(req *http.Request) (int, interface{}) {
+	var ids []int64
+	if err := json.NewDecoder(req.Body).Decode(&ids); err != nil {
+		return http.StatusBadRequest, fmt.Errorf("decoding payload: %w", err)
+	}
+
+	return bulk(req, len(ids), func(ctx context.Context, idx int) (int64, int, interface{}) {
+		output, err := getReadarr(req).SendCommandContext(ctx, &readarr.CommandRequest{
+			Name:    "BookSearch",
+			BookIDs: []int64{ids[idx]},
+		})
+		if err != nil {
+			return ids[idx], http.StatusServiceUnavailable, fmt.Errorf("triggering book search: %w", err)
+		}
+
+		return ids[idx], http.StatusOK, output.Status
+	})
+}
+
+// @Description  Deletes many Authors from Readarr at once.
+// @Summary      Bulk Delete Readarr Authors
+// @Tags         Readarr
+// @Produce      json
+// @Accept       json
+// @Param        instance  path   int64  true  "instance ID"
+// @Param        parallel  query  int    false "authors to delete at once, default 5, max 20"
+// @Param        DELETE body apps.BulkIDs true "author IDs, deleteFiles and addImportExclusion"
+// @Success      200  {object} apps.Respond.apiResponse{message=apps.BulkResponse} "results for each author"
+// @Failure      400  {object} apps.Respond.apiResponse{message=string} "bad json payload"
+// @Failure      413  {object} apps.Respond.apiResponse{message=string} "too many authors"
+// @Failure      422  {object} apps.Respond.apiResponse{message=string} "no authors provided"
+// @Failure      404  {object} string "bad token or api key"
+// @Router       /api/readarr/{instance}/bulk/delete [delete]
+// @Security     ApiKeyAuth
+func readarrBulkDelete(req *http.Request) (int, interface{}) {
+	input, err := decodeBulkIDs(req)
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+
+	return bulk(req, len(input.IDs), func(ctx context.Context, idx int) (int64, int, interface{}) {
+		err := bulkDelete(ctx, getReadarr(req).APIer, path.Join(readarr.APIver, "author"), input.IDs[idx], input)
+		if err != nil {
+			return input.IDs[idx], http.StatusServiceUnavailable, fmt.Errorf("deleting author: %w", err)
+		}
+
+		return input.IDs[idx], http.StatusOK, "deleted"
+	})
+}
+
+// bulkTag adds or removes tags on a movie, series, artist or author, and returns its new tags.
+// The item is sent back exactly as the app returned it, with only the tags changed, so fields
+// the starr library does not have (like monitorNewItems in Sonarr v4) are not reset.
+func bulkTag(ctx context.Context, api starr.APIer, uri string, id int64, input *BulkIDs) ([]int, error) {
+	var item map[string]interface{}
+
+	req := starr.Request{URI: path.Join(uri, strconv.FormatInt(id, mnd.Base10))}
+	if err := api.GetInto(ctx, req, &item); err != nil {
+		return nil, fmt.Errorf("api.Get(%s): %w", uri, err)
+	}
+
+	have := []int{}
+	list, _ := item["tags"].([]interface{})
+
+	for _, tag := range list {
+		if tag, ok := tag.(float64); ok {
+			have = append(have, int(tag))
+		}
+	}
+
+	tags := bulkTags(have, input)
+	item["tags"] = tags
+	body, _ := json.Marshal(item)
+	req.Body = bytes.NewReader(body)
+	req.Query = url.Values{"moveFiles": {"false"}}
+
+	var output interface{}
+	if err := api.PutInto(ctx, req, &output); err != nil {
+		return nil, fmt.Errorf("api.Put(%s): %w", uri, err)
+	}
+
+	return tags, nil
+}
+
+// bulkDelete deletes an artist or author. The starr library does not provide these yet.
+func bulkDelete(ctx context.Context, api starr.APIer, uri string, id int64, input *BulkIDs) error {
+	err := api.DeleteAny(ctx, starr.Request{
+		URI: path.Join(uri, strconv.FormatInt(id, mnd.Base10)),
+		Query: url.Values{
+			"deleteFiles":            {strconv.FormatBool(input.DeleteFiles)},
+			"addImportListExclusion": {strconv.FormatBool(input.AddImportExclusion)},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("api.Delete(%s): %w", uri, err)
+	}
+
+	return nil
+}
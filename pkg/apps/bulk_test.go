@@ -0,0 +1,137 @@
+package apps
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golift.io/starr"
+	"golift.io/starr/sonarr"
+)
+
+func TestBulkTags(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, []int{1, 2, 3}, bulkTags([]int{1, 2, 2}, &BulkIDs{Tags: []int{2, 3}}), "no duplicates")
+	assert.Equal(t, []int{1}, bulkTags([]int{1, 2, 3}, &BulkIDs{Tags: []int{2, 3, 4}, Remove: true}))
+	assert.Equal(t, []int{}, bulkTags(nil, &BulkIDs{Tags: []int{1}, Remove: true}))
+}
+
+func TestBulkParallel(t *testing.T) {
+	t.Parallel()
+
+	var running, most int32
+
+	req := httptest.NewRequest(http.MethodPost, "/?parallel=3", nil)
+	code, msg := bulk(req, 20, func(_ context.Context, idx int) (int64, int, interface{}) {
+		now := atomic.AddInt32(&running, 1)
+		defer atomic.AddInt32(&running, -1)
+
+		for old := atomic.LoadInt32(&most); now > old; old = atomic.LoadInt32(&most) {
+			if atomic.CompareAndSwapInt32(&most, old, now) {
+				break
+			}
+		}
+
+		if idx%4 == 0 {
+			return int64(idx), http.StatusConflict, ErrBulkSize // an error message is sent as a string.
+		}
+
+		return int64(idx), http.StatusOK, "ok"
+	})
+
+	require.Equal(t, http.StatusOK, code)
+
+	output, _ := msg.(*BulkResponse)
+	require.NotNil(t, output)
+	assert.Equal(t, 15, output.Succeeded)
+	assert.Equal(t, 5, output.Failed)
+	assert.LessOrEqual(t, most, int32(3))
+	assert.Equal(t, ErrBulkSize.Error(), output.Results[4].Message)
+
+	for idx, result := range output.Results {
+		assert.Equal(t, idx, result.Index, "results are in request order")
+	}
+}
+
+func TestBulkSize(t *testing.T) {
+	t.Parallel()
+
+	run := func(context.Context, int) (int64, int, interface{}) { return 0, http.StatusOK, nil }
+	req := httptest.NewRequest(http.MethodPost, "/", nil)
+
+	code, _ := bulk(req, 0, run)
+	assert.Equal(t, http.StatusUnprocessableEntity, code)
+
+	code, _ = bulk(req, bulkItemsMax+1, run)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, code)
+}
+
+func TestBulkCanceled(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	req := httptest.NewRequest(http.MethodPost, "/?parallel=1", nil).WithContext(ctx)
+	started := 0
+
+	code, msg := bulk(req, 5, func(context.Context, int) (int64, int, interface{}) {
+		if started++; started == 2 {
+			cancel() // the client went away while the second item was running.
+		}
+
+		return 1, http.StatusOK, "ok"
+	})
+
+	require.Equal(t, http.StatusOK, code)
+
+	output, _ := msg.(*BulkResponse)
+	require.NotNil(t, output)
+	assert.Equal(t, 2, started, "items are not started after the request ends")
+	assert.Equal(t, 2, output.Succeeded)
+	assert.Equal(t, 3, output.Failed)
+	assert.Equal(t, http.StatusRequestTimeout, output.Results[4].Code)
+	assert.Contains(t, output.Results[4].Message, "canceled")
+}
+
+// TestBulkTagKeepsFields checks that tagging a series does not reset fields the starr library does not know.
+func TestBulkTagKeepsFields(t *testing.T) {
+	t.Parallel()
+
+	var put map[string]interface{}
+
+	server := httptest.NewServer(http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, "/api/v3/series/7", req.URL.Path)
+
+		if req.Method == http.MethodPut {
+			assert.Equal(t, "false", req.URL.Query().Get("moveFiles"))
+			assert.NoError(t, json.NewDecoder(req.Body).Decode(&put))
+			_ = json.NewEncoder(resp).Encode(put)
+
+			return
+		}
+
+		_, _ = resp.Write([]byte(`{"id":7,"title":"Show","seriesType":"anime","monitorNewItems":"none",` +
+			`"seasonFolder":false,"tags":[1,2]}`))
+	}))
+	defer server.Close()
+
+	api := starr.New("key", server.URL, 0)
+	tags, err := bulkTag(context.Background(), api, path.Join(sonarr.APIver, "series"), 7, &BulkIDs{Tags: []int{2, 5}})
+
+	require.NoError(t, err)
+	assert.Equal(t, []int{1, 2, 5}, tags)
+	assert.Equal(t, map[string]interface{}{
+		"id":              float64(7),
+		"title":           "Show",
+		"seriesType":      "anime",
+		"monitorNewItems": "none",
+		"seasonFolder":    false,
+		"tags":            []interface{}{float64(1), float64(2), float64(5)},
+	}, put)
+}
@@ -0,0 +1,482 @@
+package apps
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"golift.io/starr"
+	"golift.io/starr/lidarr"
+	"golift.io/starr/radarr"
+	"golift.io/starr/readarr"
+	"golift.io/starr/sonarr"
+)
+
+/* The config backup handlers export and import Starr app settings as one JSON document.
+   Items are matched by name (or label) in the target instance, and the IDs they reference
+   (tags, custom formats and profiles) are changed to the IDs in the target instance. */
+
+// Config import actions.
+const (
+	ConfigCreate    = "create"
+	ConfigUpdate    = "update"
+	ConfigUnchanged = "unchanged"
+	ConfigInput     = "input" // a new item has secret fields that need a value; it was not created.
+	ConfigError     = "error"
+)
+
+// This is the value Starr apps return for secret fields, like passwords and API keys.
+const maskedValue = "********"
+
+// ErrConfigBundle is returned when a config bundle is not for the app it's imported into.
+var ErrConfigBundle = fmt.Errorf("config bundle app does not match")
+
+// ConfigBundle is a Starr app configuration export. Every section is a list of items
+// exactly as the Starr app returns them. Naming is a list with one item.
+type ConfigBundle struct {
+	App      starr.App                           `json:"app"`
+	Name     string                              `json:"name"` // instance name.
+	Exported time.Time                           `json:"exported"`
+	Sections map[string][]map[string]interface{} `json:"sections"`
+	Errors   map[string]string                   `json:"errors,omitempty"` // sections that could not be exported.
+}
+
+// ConfigChange is a change made (or that would be made) to a config item during an import.
+type ConfigChange struct {
+	Section string   `json:"section"`
+	Name    string   `json:"name"`
+	Action  string   `json:"action"`
+	Fields  []string `json:"fields,omitempty"` // changed fields for updates, masked fields for input.
+	Error   string   `json:"error,omitempty"`
+}
+
+// ConfigImport is the result of importing a config bundle.
+type ConfigImport struct {
+	App       starr.App       `json:"app"`
+	DryRun    bool            `json:"dryRun"`
+	Created   int             `json:"created"`
+	Updated   int             `json:"updated"`
+	Unchanged int             `json:"unchanged"`
+	Input     int             `json:"input"`
+	Failed    int             `json:"failed"`
+	Changes   []*ConfigChange `json:"changes"`
+}
+
+// configSection is an API path exported and imported in a config bundle.
+type configSection struct {
+	name   string
+	uri    string // path after the API version.
+	key    func(item map[string]interface{}) string
+	single bool // naming is one object, not a list.
+}
+
+// configSections are in the order they are imported; sections that reference IDs come after the IDs they reference.
+func configSections(app starr.App) []*configSection {
+	tag := &configSection{name: "tag", uri: "tag", key: keyField("label")}
+	definition := &configSection{name: "qualitydefinition", uri: "qualitydefinition", key: keyQuality}
+	format := &configSection{name: "customformat", uri: "customformat", key: keyField("name")}
+	profile := &configSection{name: "qualityprofile", uri: "qualityprofile", key: keyField("name")}
+	delay := &configSection{name: "delayprofile", uri: "delayprofile", key: keyField("order")}
+	release := &configSection{name: "releaseprofile", uri: "releaseprofile", key: keyRelease}
+	naming := &configSection{name: "naming", uri: "config/naming", key: keyField("id"), single: true}
+	notification := &configSection{name: "notification", uri: "notification", key: keyField("name")}
+	importList := &configSection{name: "importlist", uri: "importlist", key: keyField("name")}
+
+	switch app {
+	case starr.Radarr:
+		return []*configSection{tag, definition, format, profile, delay, naming, notification, importList}
+	case starr.Sonarr:
+		language := &configSection{name: "languageprofile", uri: "languageprofile", key: keyField("name")}
+		return []*configSection{tag, definition, format, language, profile, delay, release, naming, notification, importList}
+	case starr.Lidarr, starr.Readarr:
+		metadata := &configSection{name: "metadataprofile", uri: "metadataprofile", key: keyField("name")}
+		return []*configSection{tag, definition, profile, metadata, delay, release, naming, notification, importList}
+	default:
+		return nil
+	}
+}
+
+func keyField(field string) func(map[string]interface{}) string {
+	return func(item map[string]interface{}) string {
+		return fmt.Sprint(item[field])
+	}
+}
+
+// keyQuality identifies a quality definition by its built-in quality ID.
+func keyQuality(item map[string]interface{}) string {
+	quality, _ := item["quality"].(map[string]interface{})
+	return fmt.Sprint(quality["id"])
+}
+
+// keyRelease identifies a release profile by name, or by its terms if it has no name.
+func keyRelease(item map[string]interface{}) string {
+	if name, _ := item["name"].(string); name != "" {
+		return name
+	}
+
+	return fmt.Sprint(item["required"], item["ignored"])
+}
+
+// configAPI returns the app, API interface, API version and instance name for a request.
+func configAPI(req *http.Request) (starr.App, starr.APIer, string, string) {
+	ctx := req.Context()
+
+	switch {
+	case ctx.Value(starr.Lidarr) != nil:
+		return starr.Lidarr, getLidarr(req).APIer, lidarr.APIver, ctx.Value(starr.Lidarr).(*LidarrConfig).Name
+	case ctx.Value(starr.Radarr) != nil:
+		return starr.Radarr, getRadarr(req).APIer, radarr.APIver, ctx.Value(starr.Radarr).(*RadarrConfig).Name
+	case ctx.Value(starr.Readarr) != nil:
+		return starr.Readarr, getReadarr(req).APIer, readarr.APIver, ctx.Value(starr.Readarr).(*ReadarrConfig).Name
+	default:
+		return starr.Sonarr, getSonarr(req).APIer, sonarr.APIver, ctx.Value(starr.Sonarr).(*SonarrConfig).Name
+	}
+}
+
+// onlySections returns the sections in a comma separated list, or all sections if the list is empty.
+func onlySections(sections []*configSection, list string) []*configSection {
+	if list == "" {
+		return sections
+	}
+
+	output := []*configSection{}
+
+	for _, section := range sections {
+		for _, name := range strings.Split(list, ",") {
+			if strings.EqualFold(strings.TrimSpace(name), section.name) {
+				output = append(output, section)
+			}
+		}
+	}
+
+	return output
+}
+
+// @Description  Exports the configuration of a Starr app instance as one JSON document.
+// @Description  Includes tags, quality definitions, custom formats, quality, language, metadata, delay and release
+// @Description  profiles, naming, notifications and import lists; the sections depend on the app.
+// @Summary      Export Starr Configuration
+// @Tags         Radarr,Sonarr,Lidarr,Readarr
+// @Produce      json
+// @Param        instance  path   int64   true  "instance ID"
+// @Param        sections  query  string  false "only export these sections, comma separated"
+// @Success      200  {object} apps.Respond.apiResponse{message=apps.ConfigBundle} "configuration bundle"
+// @Failure      404  {object} string "bad token or api key"
+// @Router       /api/radarr/{instance}/config/export [get]
+// @Router       /api/sonarr/{instance}/config/export [get]
+// @Router       /api/lidarr/{instance}/config/export [get]
+// @Router       /api/readarr/{instance}/config/export [get]
+// @Security     ApiKeyAuth
+func configExport(req *http.Request) (int, interface{}) {
+	app, api, apiVer, name := configAPI(req)
+	bundle := &ConfigBundle{
+		App:      app,
+		Name:     name,
+		Exported: time.Now(),
+		Sections: make(map[string][]map[string]interface{}),
+		Errors:   make(map[string]string),
+	}
+
+	for _, section := range onlySections(configSections(app), req.URL.Query().Get("sections")) {
+		items, err := getConfigSection(req.Context(), api, apiVer, section)
+		if err != nil {
+			// Not every version of every app has every section, like custom formats in Sonarr v3.
+			bundle.Errors[section.name] = err.Error()
+			continue
+		}
+
+		bundle.Sections[section.name] = items
+	}
+
+	return http.StatusOK, bundle
+}
+
+func getConfigSection(
+	ctx context.Context,
+	api starr.APIer,
+	apiVer string,
+	section *configSection,
+) ([]map[string]interface{}, error) {
+	req := starr.Request{URI: path.Join(apiVer, section.uri)}
+
+	if section.single {
+		var item map[string]interface{}
+		if err := api.GetInto(ctx, req, &item); err != nil {
+			return nil, fmt.Errorf("api.Get(%s): %w", section.uri, err)
+		}
+
+		return []map[string]interface{}{item}, nil
+	}
+
+	var items []map[string]interface{}
+	if err := api.GetInto(ctx, req, &items); err != nil {
+		return nil, fmt.Errorf("api.Get(%s): %w", section.uri, err)
+	}
+
+	return items, nil
+}
+
+// @Description  Imports a configuration bundle into a Starr app instance; the bundle may come from another instance.
+// @Description  Items are matched by name, and updated or created. Items not in the bundle are not changed.
+// @Description  IDs for tags, custom formats and profiles are changed to match the target instance.
+// @Description  Secret fields that were exported as ******** keep the value in the target instance when updated.
+// @Description  New items with secret fields are not created, because they need a value; their action is input.
+// @Description  Use dryRun=true to preview the changes without making them.
+// @Summary      Import Starr Configuration
+// @Tags         Radarr,Sonarr,Lidarr,Readarr
+// @Produce      json
+// @Accept       json
+// @Param        instance  path   int64   true  "instance ID"
+// @Param        dryRun    query  bool    false "only report the changes that would be made"
+// @Param        sections  query  string  false "only import these sections, comma separated"
+// @Param        POST body apps.ConfigBundle true "configuration bundle from the export endpoint"
+// @Success      200  {object} apps.Respond.apiResponse{message=apps.ConfigImport} "changes"
+// @Failure      400  {object} apps.Respond.apiResponse{message=string} "bad json payload"
+// @Failure      422  {object} apps.Respond.apiResponse{message=string} "bundle is for a different app"
+// @Failure      404  {object} string "bad token or api key"
+// @Router       /api/radarr/{instance}/config/import [post]
+// @Router       /api/sonarr/{instance}/config/import [post]
+// @Router       /api/lidarr/{instance}/config/import [post]
+// @Router       /api/readarr/{instance}/config/import [post]
+// @Security     ApiKeyAuth
+func configImport(req *http.Request) (int, interface{}) {
+	var bundle ConfigBundle
+	if err := json.NewDecoder(req.Body).Decode(&bundle); err != nil {
+		return http.StatusBadRequest, fmt.Errorf("decoding payload: %w", err)
+	}
+
+	app, api, apiVer, _ := configAPI(req)
+	if !strings.EqualFold(string(bundle.App), string(app)) {
+		return http.StatusUnprocessableEntity, fmt.Errorf("%w: %s bundle, %s instance", ErrConfigBundle, bundle.App, app)
+	}
+
+	imp := &configImporter{
+		api:    api,
+		apiVer: apiVer,
+		ids:    make(map[string]map[string]interface{}),
+		output: &ConfigImport{App: app, DryRun: req.URL.Query().Get("dryRun") == fmt.Sprint(true), Changes: []*ConfigChange{}},
+	}
+
+	for _, section := range onlySections(configSections(app), req.URL.Query().Get("sections")) {
+		if items, ok := bundle.Sections[section.name]; ok {
+			imp.section(req.Context(), section, items)
+		}
+	}
+
+	return http.StatusOK, imp.output
+}
+
+// configImporter holds the data for one config import.
+type configImporter struct {
+	api    starr.APIer
+	apiVer string
+	ids    map[string]map[string]interface{} // section -> bundle ID -> target ID.
+	output *ConfigImport
+}
+
+// section imports the items in one config section.
+func (c *configImporter) section(ctx context.Context, section *configSection, items []map[string]interface{}) {
+	current, err := getConfigSection(ctx, c.api, c.apiVer, section)
+	if err != nil {
+		c.add(&ConfigChange{Section: section.name, Action: ConfigError, Error: err.Error()})
+		return
+	}
+
+	existing := make(map[string]map[string]interface{})
+	for _, item := range current {
+		existing[section.key(item)] = item
+	}
+
+	c.ids[section.name] = make(map[string]interface{})
+
+	for _, item := range items {
+		if item == nil {
+			continue
+		}
+
+		bundleID := fmt.Sprint(item["id"])
+		item = c.remap(item)
+		change := &ConfigChange{Section: section.name, Name: configName(section, item)}
+
+		if section.single && len(current) > 0 {
+			existing[section.key(item)] = current[0] // there's only one, the key does not matter.
+			item["id"] = current[0]["id"]
+		}
+
+		// The target masks its secrets too. They compare equal, and Starr keeps the stored secret
+		// when an update has the mask, so only new items need a value from the user.
+		switch have, masked := existing[section.key(item)], maskedFields(item); {
+		case have != nil:
+			c.ids[section.name][bundleID] = have["id"]
+			item["id"] = have["id"]
+			change.Fields = diffFields(item, have)
+			c.update(ctx, section, item, change)
+		case len(masked) > 0:
+			// Posting the mask would save it as the secret.
+			change.Action, change.Fields = ConfigInput, masked
+		default:
+			delete(item, "id")
+			c.ids[section.name][bundleID] = c.create(ctx, section, item, change)
+		}
+
+		c.add(change)
+	}
+}
+
+func (c *configImporter) update(ctx context.Context, section *configSection, item map[string]interface{}, change *ConfigChange) {
+	if change.Action = ConfigUpdate; len(change.Fields) == 0 {
+		change.Action = ConfigUnchanged
+		return
+	}
+
+	if c.output.DryRun {
+		return
+	}
+
+	uri := path.Join(c.apiVer, section.uri, fmt.Sprint(item["id"]))
+	if section.single {
+		uri = path.Join(c.apiVer, section.uri)
+	}
+
+	body, _ := json.Marshal(item)
+
+	var output interface{}
+	if err := c.api.PutInto(ctx, starr.Request{URI: uri, Body: bytes.NewReader(body)}, &output); err != nil {
+		change.Action, change.Error = ConfigError, fmt.Sprintf("api.Put(%s): %v", section.uri, err)
+	}
+}
+
+// create adds a new item and returns its ID.
+func (c *configImporter) create(
+	ctx context.Context,
+	section *configSection,
+	item map[string]interface{},
+	change *ConfigChange,
+) interface{} {
+	if change.Action = ConfigCreate; c.output.DryRun {
+		return nil
+	}
+
+	body, _ := json.Marshal(item)
+
+	var output map[string]interface{}
+
+	err := c.api.PostInto(ctx, starr.Request{URI: path.Join(c.apiVer, section.uri), Body: bytes.NewReader(body)}, &output)
+	if err != nil {
+		change.Action, change.Error = ConfigError, fmt.Sprintf("api.Post(%s): %v", section.uri, err)
+		return nil
+	}
+
+	return output["id"]
+}
+
+func (c *configImporter) add(change *ConfigChange) {
+	switch change.Action {
+	case ConfigCreate:
+		c.output.Created++
+	case ConfigUpdate:
+		c.output.Updated++
+	case ConfigUnchanged:
+		c.output.Unchanged++
+	case ConfigInput:
+		c.output.Input++
+	case ConfigError:
+		c.output.Failed++
+	}
+
+	c.output.Changes = append(c.output.Changes, change)
+}
+
+// remap returns a copy of an item with the IDs it references changed to the IDs in the target instance.
+// IDs that are not in the target instance (yet) are not changed.
+func (c *configImporter) remap(item map[string]interface{}) map[string]interface{} {
+	var output map[string]interface{}
+
+	body, _ := json.Marshal(item)
+	_ = json.Unmarshal(body, &output)
+
+	if tags, ok := output["tags"].([]interface{}); ok {
+		for idx, tag := range tags {
+			tags[idx] = c.targetID("tag", tag)
+		}
+	}
+
+	if formats, ok := output["formatItems"].([]interface{}); ok {
+		for _, format := range formats {
+			if format, ok := format.(map[string]interface{}); ok {
+				format["format"] = c.targetID("customformat", format["format"])
+			}
+		}
+	}
+
+	for field, section := range map[string]string{
+		"qualityProfileId":  "qualityprofile",
+		"languageProfileId": "languageprofile",
+		"metadataProfileId": "metadataprofile",
+	} {
+		if id, ok := output[field]; ok {
+			output[field] = c.targetID(section, id)
+		}
+	}
+
+	return output
+}
+
+func (c *configImporter) targetID(section string, bundleID interface{}) interface{} {
+	if id, ok := c.ids[section][fmt.Sprint(bundleID)]; ok && id != nil {
+		return id
+	}
+
+	return bundleID
+}
+
+func configName(section *configSection, item map[string]interface{}) string {
+	switch {
+	case section.single:
+		return section.name
+	case item["name"] != nil && item["name"] != "":
+		return fmt.Sprint(item["name"])
+	case item["label"] != nil:
+		return fmt.Sprint(item["label"])
+	case item["title"] != nil:
+		return fmt.Sprint(item["title"])
+	default:
+		return section.key(item)
+	}
+}
+
+// maskedFields returns the names of secret fields that still have the ******** value.
+func maskedFields(item map[string]interface{}) []string {
+	names := []string{}
+	fields, _ := item["fields"].([]interface{})
+
+	for _, field := range fields {
+		if field, _ := field.(map[string]interface{}); field != nil && field["value"] == maskedValue {
+			names = append(names, fmt.Sprint(field["name"]))
+		}
+	}
+
+	return names
+}
+
+// diffFields returns the top level fields that are different in two items.
+func diffFields(item, have map[string]interface{}) []string {
+	fields := []string{}
+
+	for key, value := range item {
+		if !reflect.DeepEqual(value, have[key]) {
+			fields = append(fields, key)
+		}
+	}
+
+	sort.Strings(fields)
+
+	return fields
+}
@@ -0,0 +1,192 @@
+package apps
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golift.io/starr"
+	"golift.io/starr/radarr"
+)
+
+// fakeStarr is a Starr app with one config section; it records the items updated and created.
+type fakeStarr struct {
+	items   []map[string]interface{}
+	puts    map[string]map[string]interface{} // path -> body.
+	posts   []map[string]interface{}
+	nextID  float64
+	section string
+}
+
+func (f *fakeStarr) ServeHTTP(resp http.ResponseWriter, req *http.Request) {
+	var body map[string]interface{}
+	if req.Body != nil {
+		_ = json.NewDecoder(req.Body).Decode(&body)
+	}
+
+	switch req.Method {
+	case http.MethodGet:
+		_ = json.NewEncoder(resp).Encode(f.items)
+	case http.MethodPut:
+		f.puts[strings.TrimPrefix(req.URL.Path, "/api/v3/"+f.section+"/")] = body
+		_ = json.NewEncoder(resp).Encode(body)
+	case http.MethodPost:
+		f.nextID++
+		body["id"] = f.nextID
+		f.posts = append(f.posts, body)
+		_ = json.NewEncoder(resp).Encode(body)
+	}
+}
+
+func notification(id float64, name, url, apiKey string) map[string]interface{} {
+	return map[string]interface{}{
+		"id":   id,
+		"name": name,
+		"fields": []interface{}{
+			map[string]interface{}{"name": "url", "value": url},
+			map[string]interface{}{"name": "apiKey", "value": apiKey},
+		},
+	}
+}
+
+func testImport(t *testing.T, fake *fakeStarr, dryRun bool, items ...map[string]interface{}) *ConfigImport {
+	t.Helper()
+
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	imp := &configImporter{
+		api:    starr.New("key", server.URL, 0),
+		apiVer: radarr.APIver,
+		ids:    make(map[string]map[string]interface{}),
+		output: &ConfigImport{App: starr.Radarr, DryRun: dryRun, Changes: []*ConfigChange{}},
+	}
+	imp.section(context.Background(), &configSection{name: fake.section, uri: fake.section, key: keyField("name")}, items)
+
+	return imp.output
+}
+
+func TestConfigImport(t *testing.T) {
+	t.Parallel()
+
+	fake := &fakeStarr{
+		section: "notification",
+		puts:    make(map[string]map[string]interface{}),
+		nextID:  10,
+		items: []map[string]interface{}{
+			notification(1, "same", "http://a", "key"),
+			notification(2, "moved", "http://old", "key"),
+		},
+	}
+
+	output := testImport(t, fake, false,
+		notification(7, "same", "http://a", "key"),
+		notification(8, "moved", "http://new", "key"),
+		notification(9, "new", "http://b", "key"),
+	)
+
+	assert.Equal(t, 1, output.Unchanged)
+	assert.Equal(t, 1, output.Updated)
+	assert.Equal(t, 1, output.Created)
+	assert.Zero(t, output.Failed)
+
+	require.Len(t, output.Changes, 3)
+	assert.Equal(t, []string{"fields"}, output.Changes[1].Fields)
+
+	// Items are matched by name, and updated with the target's ID.
+	require.Contains(t, fake.puts, "2")
+	assert.Equal(t, notification(2, "moved", "http://new", "key"), fake.puts["2"])
+
+	require.Len(t, fake.posts, 1)
+	assert.Equal(t, "new", fake.posts[0]["name"])
+	assert.Equal(t, float64(11), fake.posts[0]["id"], "the id comes from the target")
+}
+
+func TestConfigImportMasked(t *testing.T) {
+	t.Parallel()
+
+	// The target returns its own secret masked, just like the bundle.
+	fake := &fakeStarr{
+		section: "notification",
+		puts:    make(map[string]map[string]interface{}),
+		nextID:  10,
+		items: []map[string]interface{}{
+			notification(1, "unchanged", "http://a", maskedValue),
+			notification(2, "moved", "http://old", maskedValue),
+		},
+	}
+
+	output := testImport(t, fake, false,
+		notification(7, "unchanged", "http://a", maskedValue),
+		notification(8, "moved", "http://new", maskedValue),
+		notification(9, "new secret", "http://b", maskedValue),
+		notification(4, "new plain", "http://c", "plain"),
+	)
+
+	assert.Equal(t, 1, output.Unchanged, "masked secrets on both sides are equal")
+	assert.Equal(t, 1, output.Updated)
+	assert.Equal(t, 1, output.Input, "only a new item needs the secret")
+	assert.Equal(t, 1, output.Created)
+	assert.Zero(t, output.Failed)
+
+	require.Len(t, output.Changes, 4)
+	assert.Equal(t, []string{"fields"}, output.Changes[1].Fields)
+	assert.Equal(t, []string{"apiKey"}, output.Changes[2].Fields)
+
+	// The update keeps the mask, so Starr keeps the stored secret, and uses the target's ID.
+	require.Contains(t, fake.puts, "2")
+	assert.Equal(t, notification(2, "moved", "http://new", maskedValue), fake.puts["2"])
+
+	// The new item without a secret is created without the bundle's ID; the masked one is not created.
+	require.Len(t, fake.posts, 1)
+	assert.Equal(t, "new plain", fake.posts[0]["name"])
+	assert.Equal(t, float64(11), fake.posts[0]["id"], "the id comes from the target")
+}
+
+func TestConfigImportDryRun(t *testing.T) {
+	t.Parallel()
+
+	fake := &fakeStarr{
+		section: "notification",
+		puts:    make(map[string]map[string]interface{}),
+		items:   []map[string]interface{}{notification(1, "moved", "http://old", "")},
+	}
+
+	output := testImport(t, fake, true,
+		notification(5, "moved", "http://new", ""),
+		notification(6, "new", "http://new", ""),
+	)
+
+	assert.Equal(t, 1, output.Updated)
+	assert.Equal(t, 1, output.Created)
+	assert.Empty(t, fake.puts, "a dry run changes nothing")
+	assert.Empty(t, fake.posts, "a dry run creates nothing")
+}
+
+func TestConfigImportRemap(t *testing.T) {
+	t.Parallel()
+
+	imp := &configImporter{ids: map[string]map[string]interface{}{
+		"tag":            {"1": float64(11), "2": nil},
+		"customformat":   {"3": float64(33)},
+		"qualityprofile": {"4": float64(44)},
+	}}
+	bundle := map[string]interface{}{
+		"tags":             []interface{}{float64(1), float64(2), float64(9)},
+		"formatItems":      []interface{}{map[string]interface{}{"format": float64(3), "score": float64(10)}},
+		"qualityProfileId": float64(4),
+	}
+
+	output := imp.remap(bundle)
+
+	assert.Equal(t, []interface{}{float64(11), float64(2), float64(9)}, output["tags"],
+		"unknown and uncreated (dry run) IDs are not changed")
+	assert.Equal(t, float64(33), output["formatItems"].([]interface{})[0].(map[string]interface{})["format"])
+	assert.Equal(t, float64(44), output["qualityProfileId"])
+	assert.Equal(t, []interface{}{float64(1), float64(2), float64(9)}, bundle["tags"], "the bundle item is not changed")
+}
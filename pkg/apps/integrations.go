@@ -9,6 +9,7 @@ import (
 	"github.com/Notifiarr/notifiarr/pkg/apps/apppkg/tautulli"
 	"github.com/Notifiarr/notifiarr/pkg/mnd"
 	"github.com/mrobinsn/go-rtorrent/xmlrpc"
+	"golift.io/cnfg"
 	"golift.io/deluge"
 	"golift.io/nzbget"
 	"golift.io/qbit"
@@ -20,6 +21,27 @@ type PlexConfig struct {
 	*plex.Config
 	*plex.Server
 	extraConfig
+	Events   []*PlexEvent   `toml:"event" xml:"event" json:"events,omitempty"`
+	PathMaps []*PlexPathMap `toml:"path_map" xml:"path_map" json:"pathMaps,omitempty"`
+	// HistorySize is the number of finished sessions kept in the local session history. 0 disables it.
+	HistorySize int `toml:"history_size" xml:"history_size" json:"historySize"`
+}
+
+// PlexPathMap translates a path from a Starr app into the same path as Plex sees it.
+// Useful when Plex and the Starr apps run in containers with different volume mounts.
+type PlexPathMap struct {
+	Starr string `toml:"starr" xml:"starr" json:"starr"`
+	Plex  string `toml:"plex" xml:"plex" json:"plex"`
+}
+
+// PlexEvent allows customizing how an incoming Plex webhook event is handled.
+// Events without a config entry are handled with the built-in defaults.
+type PlexEvent struct {
+	Event     string        `toml:"event" xml:"event" json:"event"`
+	Disabled  bool          `toml:"disabled" xml:"disabled" json:"disabled"`
+	Libraries []string      `toml:"libraries" xml:"library" json:"libraries"`
+	Cooldown  cnfg.Duration `toml:"cooldown" xml:"cooldown" json:"cooldown"`
+	Enrich    bool          `toml:"enrich" xml:"enrich" json:"enrich"`
 }
 
 func (c *PlexConfig) Setup(maxBody int, logger mnd.Logger) {
@@ -43,6 +65,61 @@ func (c *PlexConfig) Enabled() bool {
 	return c != nil && c.Config != nil && c.Config.URL != "" && c.Config.Token != "" && c.Timeout.Duration >= 0
 }
 
+// GetEvent returns the custom configuration for a webhook event, or nil if there is none.
+func (c *PlexConfig) GetEvent(event string) *PlexEvent {
+	if c == nil {
+		return nil
+	}
+
+	for _, e := range c.Events {
+		if e != nil && strings.EqualFold(e.Event, event) {
+			return e
+		}
+	}
+
+	return nil
+}
+
+// MapPath translates a Starr app path into a Plex path using the first matching path map.
+// The path is returned unchanged if no path maps match.
+func (c *PlexConfig) MapPath(starrPath string) string {
+	if c == nil {
+		return starrPath
+	}
+
+	for _, pathMap := range c.PathMaps {
+		if pathMap == nil || pathMap.Starr == "" {
+			continue
+		}
+
+		from := strings.TrimRight(pathMap.Starr, `/\`)
+		if starrPath == from || strings.HasPrefix(starrPath, from+"/") || strings.HasPrefix(starrPath, from+`\`) {
+			return strings.TrimRight(pathMap.Plex, `/\`) + strings.TrimPrefix(starrPath, from)
+		}
+	}
+
+	return starrPath
+}
+
+// AllowLibrary returns true if the event is allowed for the provided library section.
+// Libraries may be configured by title or section ID. An empty list allows all libraries,
+// and events that do not belong to a library (like device.new) are always allowed.
+func (e *PlexEvent) AllowLibrary(title string, sectionID interface{}) bool {
+	if e == nil || len(e.Libraries) == 0 || (title == "" && sectionID == nil) {
+		return true
+	}
+
+	id := fmt.Sprint(sectionID)
+
+	for _, lib := range e.Libraries {
+		if strings.EqualFold(lib, title) || (sectionID != nil && lib == id) {
+			return true
+		}
+	}
+
+	return false
+}
+
 type TautulliConfig struct {
 	extraConfig
 	*tautulli.Config
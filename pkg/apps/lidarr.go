@@ -2,6 +2,7 @@
 package apps
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -43,6 +44,13 @@ func (a *Apps) lidarrHandlers() {
 	a.HandleAPIpath(starr.Lidarr, "/notification", lidarrGetNotifications, "GET")
 	a.HandleAPIpath(starr.Lidarr, "/notification", lidarrUpdateNotification, "PUT")
 	a.HandleAPIpath(starr.Lidarr, "/notification", lidarrAddNotification, "POST")
+	a.HandleAPIpath(starr.Lidarr, "/config/export", configExport, "GET")
+	a.HandleAPIpath(starr.Lidarr, "/config/import", configImport, "POST")
+	a.HandleAPIpath(starr.Lidarr, "/bulk/add", lidarrBulkAdd, "POST")
+	a.HandleAPIpath(starr.Lidarr, "/bulk/update", lidarrBulkUpdate, "PUT")
+	a.HandleAPIpath(starr.Lidarr, "/bulk/tag", lidarrBulkTag, "PUT")
+	a.HandleAPIpath(starr.Lidarr, "/bulk/search", lidarrBulkSearch, "POST")
+	a.HandleAPIpath(starr.Lidarr, "/bulk/delete", lidarrBulkDelete, "DELETE")
 }
 
 // LidarrConfig represents the input data for a Lidarr server.
@@ -112,19 +120,26 @@ func lidarrAddAlbum(req *http.Request) (int, interface{}) {
 	err := json.NewDecoder(req.Body).Decode(&payload)
 	if err != nil {
 		return http.StatusBadRequest, fmt.Errorf("decoding payload: %w", err)
-	} else if payload.ForeignAlbumID == "" {
+	}
+
+	return lidarrAdd(req.Context(), getLidarr(req), &payload)
+}
+
+// lidarrAdd adds an album to Lidarr if it does not exist. Used by the add and bulk add handlers.
+func lidarrAdd(ctx context.Context, app *lidarr.Lidarr, payload *lidarr.AddAlbumInput) (int, interface{}) {
+	if payload.ForeignAlbumID == "" {
 		return http.StatusUnprocessableEntity, fmt.Errorf("0: %w", ErrNoMBID)
 	}
 
 	// Check for existing album.
-	m, err := getLidarr(req).GetAlbumContext(req.Context(), payload.ForeignAlbumID)
+	m, err := app.GetAlbumContext(ctx, payload.ForeignAlbumID)
 	if err != nil {
 		return http.StatusServiceUnavailable, fmt.Errorf("checking album: %w", err)
 	} else if len(m) > 0 {
 		return http.StatusConflict, lidarrData(m[0])
 	}
 
-	album, err := getLidarr(req).AddAlbumContext(req.Context(), &payload)
+	album, err := app.AddAlbumContext(ctx, payload)
 	if err != nil {
 		return http.StatusInternalServerError, fmt.Errorf("adding album: %w", err)
 	}
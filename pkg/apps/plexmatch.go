@@ -0,0 +1,147 @@
+package apps
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/Notifiarr/notifiarr/pkg/apps/apppkg/plex"
+	"github.com/Notifiarr/notifiarr/pkg/mnd"
+	"golift.io/starr"
+)
+
+// StarrMatch is a Radarr movie or Sonarr series that matches an item in a Plex webhook.
+type StarrMatch struct {
+	App              starr.App `json:"app"`
+	Instance         int       `json:"instance"`
+	Name             string    `json:"name"`
+	ID               int64     `json:"id"`
+	TmdbID           int64     `json:"tmdbId,omitempty"`
+	TvdbID           int64     `json:"tvdbId,omitempty"`
+	Title            string    `json:"title"`
+	Path             string    `json:"path"`
+	Monitored        bool      `json:"monitored"`
+	HasFile          bool      `json:"hasFile"`
+	QualityProfileID int64     `json:"qualityProfileId"`
+	Tags             []int     `json:"tags"`
+}
+
+// EnrichPlexWebhook returns the Radarr movies and Sonarr series that match the item in a Plex webhook,
+// if the webhook's [[plex.event]] has enrich enabled. Returns nil otherwise.
+func (a *Apps) EnrichPlexWebhook(ctx context.Context, hook *plex.IncomingWebhook) ([]*StarrMatch, error) {
+	if event := a.Plex.GetEvent(hook.Event); event == nil || !event.Enrich {
+		return nil, nil
+	}
+
+	return a.MatchPlexItem(ctx, hook)
+}
+
+// MatchPlexItem finds the Radarr movies and Sonarr series that match the external IDs (GUIDs)
+// of the item in a Plex webhook. Movies are matched by TMDB ID, and everything that belongs to
+// a show is matched by the show's TVDB ID. The show's GUIDs are fetched from Plex when needed.
+func (a *Apps) MatchPlexItem(ctx context.Context, hook *plex.IncomingWebhook) ([]*StarrMatch, error) {
+	switch hook.Metadata.Type {
+	case "movie":
+		return a.matchRadarr(ctx, plex.ExternalID(hook.Metadata.GuID, "tmdb"))
+	case "show":
+		return a.matchSonarr(ctx, plex.ExternalID(hook.Metadata.GuID, "tvdb"))
+	case "season":
+		return a.matchPlexShow(ctx, hook.Metadata.ParentRatingKey)
+	case "episode":
+		return a.matchPlexShow(ctx, hook.Metadata.GrandparentRatingKey)
+	default:
+		return nil, nil
+	}
+}
+
+// matchPlexShow fetches a show's metadata from Plex to find its TVDB ID, then matches Sonarr series.
+func (a *Apps) matchPlexShow(ctx context.Context, ratingKey string) ([]*StarrMatch, error) {
+	if ratingKey == "" || !a.Plex.Enabled() {
+		return nil, nil
+	}
+
+	show, err := a.Plex.GetPlexSectionKeyWithContext(ctx, "/library/metadata/"+ratingKey)
+	if err != nil {
+		return nil, fmt.Errorf("getting show metadata from plex: %w", err)
+	} else if len(show.Metadata) == 0 {
+		return nil, nil
+	}
+
+	return a.matchSonarr(ctx, plex.ExternalID(show.Metadata[0].GuID, "tvdb"))
+}
+
+func (a *Apps) matchRadarr(ctx context.Context, tmdb string) ([]*StarrMatch, error) {
+	tmdbID, _ := strconv.ParseInt(tmdb, mnd.Base10, mnd.Bits64)
+	if tmdbID == 0 {
+		return nil, nil
+	}
+
+	matches := []*StarrMatch{}
+
+	for idx, app := range a.Radarr {
+		if !app.Enabled() {
+			continue
+		}
+
+		movies, err := app.GetMovieContext(ctx, tmdbID)
+		if err != nil {
+			return matches, fmt.Errorf("checking radarr %d movie: %w", idx+1, err)
+		}
+
+		for _, movie := range movies {
+			matches = append(matches, &StarrMatch{
+				App:              starr.Radarr,
+				Instance:         idx + 1,
+				Name:             app.Name,
+				ID:               movie.ID,
+				TmdbID:           movie.TmdbID,
+				Title:            movie.Title,
+				Path:             movie.Path,
+				Monitored:        movie.Monitored,
+				HasFile:          movie.HasFile,
+				QualityProfileID: movie.QualityProfileID,
+				Tags:             movie.Tags,
+			})
+		}
+	}
+
+	return matches, nil
+}
+
+func (a *Apps) matchSonarr(ctx context.Context, tvdb string) ([]*StarrMatch, error) {
+	tvdbID, _ := strconv.ParseInt(tvdb, mnd.Base10, mnd.Bits64)
+	if tvdbID == 0 {
+		return nil, nil
+	}
+
+	matches := []*StarrMatch{}
+
+	for idx, app := range a.Sonarr {
+		if !app.Enabled() {
+			continue
+		}
+
+		series, err := app.GetSeriesContext(ctx, tvdbID)
+		if err != nil {
+			return matches, fmt.Errorf("checking sonarr %d series: %w", idx+1, err)
+		}
+
+		for _, show := range series {
+			matches = append(matches, &StarrMatch{
+				App:              starr.Sonarr,
+				Instance:         idx + 1,
+				Name:             app.Name,
+				ID:               show.ID,
+				TvdbID:           show.TvdbID,
+				Title:            show.Title,
+				Path:             show.Path,
+				Monitored:        show.Monitored,
+				HasFile:          show.Statistics != nil && show.Statistics.EpisodeFileCount > 0,
+				QualityProfileID: show.QualityProfileID,
+				Tags:             show.Tags,
+			})
+		}
+	}
+
+	return matches, nil
+}
@@ -1,6 +1,7 @@
 package apps
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -18,6 +19,11 @@ import (
 // radarrHandlers is called once on startup to register the web API paths.
 func (a *Apps) radarrHandlers() {
 	a.HandleAPIpath(starr.Radarr, "/add", radarrAddMovie, "POST")
+	a.HandleAPIpath(starr.Radarr, "/bulk/add", radarrBulkAdd, "POST")
+	a.HandleAPIpath(starr.Radarr, "/bulk/update", radarrBulkUpdate, "PUT")
+	a.HandleAPIpath(starr.Radarr, "/bulk/tag", radarrBulkTag, "PUT")
+	a.HandleAPIpath(starr.Radarr, "/bulk/search", radarrBulkSearch, "POST")
+	a.HandleAPIpath(starr.Radarr, "/bulk/delete", radarrBulkDelete, "DELETE")
 	a.HandleAPIpath(starr.Radarr, "/check/{tmdbid:[0-9]+}", radarrCheckMovie, "GET")
 	a.HandleAPIpath(starr.Radarr, "/get/{movieid:[0-9]+}", radarrGetMovie, "GET")
 	a.HandleAPIpath(starr.Radarr, "/get", radarrGetAllMovies, "GET")
@@ -53,6 +59,8 @@ func (a *Apps) radarrHandlers() {
 	a.HandleAPIpath(starr.Radarr, "/notification", radarrGetNotifications, "GET")
 	a.HandleAPIpath(starr.Radarr, "/notification", radarrUpdateNotification, "PUT")
 	a.HandleAPIpath(starr.Radarr, "/notification", radarrAddNotification, "POST")
+	a.HandleAPIpath(starr.Radarr, "/config/export", configExport, "GET")
+	a.HandleAPIpath(starr.Radarr, "/config/import", configImport, "POST")
 }
 
 // RadarrConfig represents the input data for a Radarr server.
@@ -122,12 +130,19 @@ func radarrAddMovie(req *http.Request) (int, interface{}) {
 	err := json.NewDecoder(req.Body).Decode(&payload)
 	if err != nil {
 		return http.StatusBadRequest, fmt.Errorf("decoding payload: %w", err)
-	} else if payload.TmdbID == 0 {
+	}
+
+	return radarrAdd(req.Context(), getRadarr(req), &payload)
+}
+
+// radarrAdd adds a movie to Radarr if it does not exist. Used by the add and bulk add handlers.
+func radarrAdd(ctx context.Context, app *radarr.Radarr, payload *radarr.AddMovieInput) (int, interface{}) {
+	if payload.TmdbID == 0 {
 		return http.StatusUnprocessableEntity, fmt.Errorf("0: %w", ErrNoTMDB)
 	}
 
 	// Check for existing movie.
-	m, err := getRadarr(req).GetMovieContext(req.Context(), payload.TmdbID)
+	m, err := app.GetMovieContext(ctx, payload.TmdbID)
 	if err != nil {
 		return http.StatusServiceUnavailable, fmt.Errorf("checking movie: %w", err)
 	} else if len(m) > 0 {
@@ -144,7 +159,7 @@ func radarrAddMovie(req *http.Request) (int, interface{}) {
 	}
 
 	// Add movie using fixed payload.
-	movie, err := getRadarr(req).AddMovieContext(req.Context(), &payload)
+	movie, err := app.AddMovieContext(ctx, payload)
 	if err != nil {
 		return http.StatusInternalServerError, fmt.Errorf("adding movie: %w", err)
 	}
@@ -1,6 +1,7 @@
 package apps
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -37,6 +38,13 @@ func (a *Apps) readarrHandlers() {
 	a.HandleAPIpath(starr.Readarr, "/notification", readarrGetNotifications, "GET")
 	a.HandleAPIpath(starr.Readarr, "/notification", readarrUpdateNotification, "PUT")
 	a.HandleAPIpath(starr.Readarr, "/notification", readarrAddNotification, "POST")
+	a.HandleAPIpath(starr.Readarr, "/config/export", configExport, "GET")
+	a.HandleAPIpath(starr.Readarr, "/config/import", configImport, "POST")
+	a.HandleAPIpath(starr.Readarr, "/bulk/add", readarrBulkAdd, "POST")
+	a.HandleAPIpath(starr.Readarr, "/bulk/update", readarrBulkUpdate, "PUT")
+	a.HandleAPIpath(starr.Readarr, "/bulk/tag", readarrBulkTag, "PUT")
+	a.HandleAPIpath(starr.Readarr, "/bulk/search", readarrBulkSearch, "POST")
+	a.HandleAPIpath(starr.Readarr, "/bulk/delete", readarrBulkDelete, "DELETE")
 }
 
 // ReadarrConfig represents the input data for a Readarr server.
@@ -102,10 +110,17 @@ func (a *Apps) setupReadarr() error {
 // @Security     ApiKeyAuth
 func readarrAddBook(req *http.Request) (int, interface{}) {
 	payload := &readarr.AddBookInput{}
-	// Extract payload and check for GRID ID.
-	switch err := json.NewDecoder(req.Body).Decode(payload); {
-	case err != nil:
+	if err := json.NewDecoder(req.Body).Decode(payload); err != nil {
 		return http.StatusBadRequest, fmt.Errorf("decoding payload: %w", err)
+	}
+
+	return readarrAdd(req.Context(), getReadarr(req), payload)
+}
+
+// readarrAdd adds a book to Readarr if it does not exist. Used by the add and bulk add handlers.
+func readarrAdd(ctx context.Context, app *readarr.Readarr, payload *readarr.AddBookInput) (int, interface{}) {
+	// Check for GRID ID.
+	switch {
 	case len(payload.Editions) != 1:
 		return http.StatusUnprocessableEntity,
 			fmt.Errorf("invalid editions count; only 1 allowed: %d, %w", len(payload.Editions), ErrNoGRID)
@@ -114,7 +129,7 @@ func readarrAddBook(req *http.Request) (int, interface{}) {
 	}
 
 	// Check for existing book.
-	m, err := getReadarr(req).GetBookContext(req.Context(), payload.Editions[0].ForeignEditionID)
+	m, err := app.GetBookContext(ctx, payload.Editions[0].ForeignEditionID)
 	if err != nil {
 		return http.StatusServiceUnavailable, fmt.Errorf("checking book: %w", err)
 	} else if len(m) > 0 {
@@ -122,7 +137,7 @@ func readarrAddBook(req *http.Request) (int, interface{}) {
 	}
 
 	// Add book using payload.
-	book, err := getReadarr(req).AddBookContext(req.Context(), payload)
+	book, err := app.AddBookContext(ctx, payload)
 	if err != nil {
 		return http.StatusInternalServerError, fmt.Errorf("adding book: %w", err)
 	}
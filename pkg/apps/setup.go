@@ -64,6 +64,9 @@ var (
 	// ErrWrongCount is returned when an app returns the wrong item count.
 	ErrWrongCount = fmt.Errorf("wrong item count returned")
 	ErrInvalidApp = fmt.Errorf("invalid application configuration provided")
+	ErrBadStatus  = fmt.Errorf("unexpected response status")
+	// ErrNoDeleteData is returned when a download client cannot delete the data for a torrent.
+	ErrNoDeleteData = fmt.Errorf("this download client cannot delete torrent data")
 )
 
 // Setup creates request interfaces and sets the timeout for each server.
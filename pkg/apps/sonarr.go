@@ -2,6 +2,7 @@
 package apps
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -19,6 +20,11 @@ import (
 // sonarrHandlers is called once on startup to register the web API paths.
 func (a *Apps) sonarrHandlers() {
 	a.HandleAPIpath(starr.Sonarr, "/add", sonarrAddSeries, "POST")
+	a.HandleAPIpath(starr.Sonarr, "/bulk/add", sonarrBulkAdd, "POST")
+	a.HandleAPIpath(starr.Sonarr, "/bulk/update", sonarrBulkUpdate, "PUT")
+	a.HandleAPIpath(starr.Sonarr, "/bulk/tag", sonarrBulkTag, "PUT")
+	a.HandleAPIpath(starr.Sonarr, "/bulk/search", sonarrBulkSearch, "POST")
+	a.HandleAPIpath(starr.Sonarr, "/bulk/delete", sonarrBulkDelete, "DELETE")
 	a.HandleAPIpath(starr.Sonarr, "/check/{tvdbid:[0-9]+}", sonarrCheckSeries, "GET")
 	a.HandleAPIpath(starr.Sonarr, "/get/{seriesid:[0-9]+}", sonarrGetSeries, "GET")
 	a.HandleAPIpath(starr.Sonarr, "/getEpisodes/{seriesid:[0-9]+}", sonarrGetEpisodes, "GET")
@@ -57,6 +63,8 @@ func (a *Apps) sonarrHandlers() {
 	a.HandleAPIpath(starr.Sonarr, "/notification", sonarrGetNotifications, "GET")
 	a.HandleAPIpath(starr.Sonarr, "/notification", sonarrUpdateNotification, "PUT")
 	a.HandleAPIpath(starr.Sonarr, "/notification", sonarrAddNotification, "POST")
+	a.HandleAPIpath(starr.Sonarr, "/config/export", configExport, "GET")
+	a.HandleAPIpath(starr.Sonarr, "/config/import", configImport, "POST")
 }
 
 // SonarrConfig represents the input data for a Sonarr server.
@@ -126,19 +134,26 @@ func sonarrAddSeries(req *http.Request) (int, interface{}) {
 	err := json.NewDecoder(req.Body).Decode(&payload)
 	if err != nil {
 		return http.StatusBadRequest, fmt.Errorf("decoding payload: %w", err)
-	} else if payload.TvdbID == 0 {
+	}
+
+	return sonarrAdd(req.Context(), getSonarr(req), &payload)
+}
+
+// sonarrAdd adds a series to Sonarr if it does not exist. Used by the add and bulk add handlers.
+func sonarrAdd(ctx context.Context, app *sonarr.Sonarr, payload *sonarr.AddSeriesInput) (int, interface{}) {
+	if payload.TvdbID == 0 {
 		return http.StatusUnprocessableEntity, fmt.Errorf("0: %w", ErrNoTVDB)
 	}
 
 	// Check for existing series.
-	m, err := getSonarr(req).GetSeriesContext(req.Context(), payload.TvdbID)
+	m, err := app.GetSeriesContext(ctx, payload.TvdbID)
 	if err != nil {
 		return http.StatusServiceUnavailable, fmt.Errorf("checking series: %w", err)
 	} else if len(m) > 0 {
 		return http.StatusConflict, sonarrData(m[0])
 	}
 
-	series, err := getSonarr(req).AddSeriesContext(req.Context(), &payload)
+	series, err := app.AddSeriesContext(ctx, payload)
 	if err != nil {
 		return http.StatusInternalServerError, fmt.Errorf("adding series: %w", err)
 	}
@@ -0,0 +1,157 @@
+package apps
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+/* This file contains the torrent controls the download client libraries do not provide. */
+
+// PauseTorrents pauses one or more torrents in qBittorrent.
+func (c *QbitConfig) PauseTorrents(ctx context.Context, hashes ...string) error {
+	return c.postTorrents(ctx, "api/v2/torrents/pause", url.Values{"hashes": {strings.Join(hashes, "|")}})
+}
+
+// DeleteTorrents removes one or more torrents from qBittorrent, optionally deleting their data.
+func (c *QbitConfig) DeleteTorrents(ctx context.Context, deleteData bool, hashes ...string) error {
+	return c.postTorrents(ctx, "api/v2/torrents/delete", url.Values{
+		"hashes":      {strings.Join(hashes, "|")},
+		"deleteFiles": {fmt.Sprint(deleteData)},
+	})
+}
+
+// FreeSpace returns the free space (bytes) on the qBittorrent download disk.
+func (c *QbitConfig) FreeSpace(ctx context.Context) (int64, error) {
+	var mainData struct {
+		ServerState struct {
+			FreeSpaceOnDisk int64 `json:"free_space_on_disk"`
+		} `json:"server_state"`
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.qbitURL("api/v2/sync/maindata"), nil)
+	if err != nil {
+		return 0, fmt.Errorf("creating request: %w", err)
+	}
+
+	if err := c.doTorrents(req, &mainData); err != nil {
+		return 0, err
+	}
+
+	return mainData.ServerState.FreeSpaceOnDisk, nil
+}
+
+// postTorrents uses the qbit library's http client, so it must be logged in already.
+// Getting the transfers first takes care of that.
+func (c *QbitConfig) postTorrents(ctx context.Context, path string, params url.Values) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.qbitURL(path), strings.NewReader(params.Encode()))
+	if err != nil {
+		return fmt.Errorf("creating request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	return c.doTorrents(req, nil)
+}
+
+func (c *QbitConfig) doTorrents(req *http.Request, into interface{}) error {
+	if c.HTTPUser != "" || c.HTTPPass != "" {
+		req.SetBasicAuth(c.HTTPUser, c.HTTPPass)
+	}
+
+	resp, err := c.Config.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("making request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("%w: %s: %s: %s", ErrBadStatus, req.URL.Path, resp.Status, body)
+	}
+
+	if into == nil {
+		return nil
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(into); err != nil {
+		return fmt.Errorf("decoding response: %w", err)
+	}
+
+	return nil
+}
+
+func (c *QbitConfig) qbitURL(path string) string {
+	return strings.TrimSuffix(c.Config.URL, "/") + "/" + path
+}
+
+// PauseTorrents pauses one or more torrents in Deluge.
+func (c *DelugeConfig) PauseTorrents(ctx context.Context, hashes ...string) error {
+	// Deluge 2 uses pause_torrents for a list, Deluge 1 uses pause_torrent.
+	if _, err := c.Get(ctx, "core.pause_torrents", []interface{}{hashes}); err == nil {
+		return nil
+	}
+
+	if _, err := c.Get(ctx, "core.pause_torrent", []interface{}{hashes}); err != nil {
+		return fmt.Errorf("pausing torrents: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteTorrents removes one or more torrents from Deluge, optionally deleting their data.
+func (c *DelugeConfig) DeleteTorrents(ctx context.Context, deleteData bool, hashes ...string) error {
+	for _, hash := range hashes {
+		if _, err := c.Get(ctx, "core.remove_torrent", []interface{}{hash, deleteData}); err != nil {
+			return fmt.Errorf("removing torrent %s: %w", hash, err)
+		}
+	}
+
+	return nil
+}
+
+// FreeSpace returns the free space (bytes) in the Deluge download location.
+func (c *DelugeConfig) FreeSpace(ctx context.Context) (int64, error) {
+	resp, err := c.Get(ctx, "core.get_free_space", []string{})
+	if err != nil {
+		return 0, fmt.Errorf("getting free space: %w", err)
+	}
+
+	var free int64
+	if err := json.Unmarshal(resp.Result, &free); err != nil {
+		return 0, fmt.Errorf("decoding free space: %w", err)
+	}
+
+	return free, nil
+}
+
+// PauseTorrents pauses (stops) one or more torrents in rTorrent.
+func (c *RtorrentConfig) PauseTorrents(_ context.Context, hashes ...string) error {
+	for _, hash := range hashes {
+		if _, err := c.Call("d.stop", hash); err != nil {
+			return fmt.Errorf("%w: d.stop XMLRPC call failed: %s", err, hash)
+		}
+	}
+
+	return nil
+}
+
+// DeleteTorrents removes one or more torrents from rTorrent.
+// rTorrent cannot delete torrent data, so deleteData must be false.
+func (c *RtorrentConfig) DeleteTorrents(_ context.Context, deleteData bool, hashes ...string) error {
+	if deleteData {
+		return ErrNoDeleteData
+	}
+
+	for _, hash := range hashes {
+		if _, err := c.Call("d.erase", hash); err != nil {
+			return fmt.Errorf("%w: d.erase XMLRPC call failed: %s", err, hash)
+		}
+	}
+
+	return nil
+}
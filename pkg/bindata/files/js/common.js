@@ -38,6 +38,7 @@ $(document).ready(function()
     setTimeout(function() {
         loadDataTable($('.filetable'));
         loadMonitorTable($('.monitortable'));
+        loadQueueTable($('.queuetable'));
     }, 200);
 
     $(window).resize(function() {
@@ -174,6 +175,35 @@ function loadMonitorTable(table) {
         }
     });
 }
+
+function loadQueueTable(table) {
+    table.DataTable({
+        'order': [[3,'asc'], [0, 'asc']], // oldest first.
+        'paging': true,
+        'pageLength': 100,
+        "autoWidth": true,
+        'scrollY': '60vh',
+        'scrollCollapse': true,
+        "oLanguage": {"sSearch": "Filter Queue:"},
+        "responsive": true,
+        "scrollX": true,
+        "columns": [
+            null,
+            null,
+            null,
+            // do not search age or size columns.
+            { "searchable": false },
+            { "searchable": false },
+            null,
+            null
+        ],
+        "lengthMenu": [20, 50, 100, 200, 500, 1000],
+        "fnDrawCallback":function() {
+            // fix the header column on window resize.
+            this.api().columns.adjust();
+        }
+    });
+}
 // ---------------------------------------------------------------------------------------------
 
 function jsLoader()
@@ -87,6 +87,7 @@ function getCmdArgs(from, hash)
     });
 }
 
+// runCommand opens a websocket to watch the command's output, then starts the command.
 function runCommand(from, hash)
 {
     let fields = '';
@@ -94,6 +95,52 @@ function runCommand(from, hash)
         fields += '&' + $(this).serialize();
     });
 
+    from.parents('.ui-dialog').find('.ui-dialog-content').dialog('close');
+    $('#commandArgs'+hash).html('');
+
+    if (!('WebSocket' in window)) {
+        postCommand(hash, fields); // no live output, just run it.
+        return;
+    }
+
+    const box = $('<pre style="max-height:500px;overflow:auto;white-space:pre-wrap;"></pre>');
+    const socket = new WebSocket(location.origin.replace(/^http/, 'ws') + URLBase +'ws?source=command&hash='+ hash);
+
+    $('<div></div>').append(box).dialog({
+        title: 'Command Output',
+        modal: true,
+        width: '60%',
+        height: 'auto',
+        dialogClass: 'modal-body',
+        buttons: {
+            'Cancel Command': function() { cancelCommand(hash); },
+            'Close': function() { $(this).dialog('close'); },
+        },
+        close: function(event, ui) {
+            socket.close();
+            $(this).dialog('destroy').remove();
+        }
+    });
+
+    socket.onopen = function() {
+        postCommand(hash, fields); // start the command once we're watching.
+    };
+
+    socket.onmessage = function(incoming) {
+        box.append($('<span/>').text(incoming.data));
+        box.scrollTop(box.prop('scrollHeight'));
+    };
+
+    socket.onerror = function(data) {
+        toast('Websocket Error', 'Error connecting to the client websocket, details in console.', 'error');
+        console.log('Websocket connection error');
+        console.log(data);
+    };
+}
+
+// postCommand starts a command.
+function postCommand(hash, fields)
+{
     $.ajax({
         type: 'POST',
         url: URLBase+'runCommand/'+hash,
@@ -110,6 +157,24 @@ function runCommand(from, hash)
             }
         }
     });
-    from.parents('.ui-dialog').find('.ui-dialog-content').dialog('close');
-    $('#commandArgs'+hash).html('');
+}
+
+// cancelCommand stops a running command.
+function cancelCommand(hash)
+{
+    $.ajax({
+        type: 'POST',
+        url: URLBase+'runCommand/'+hash+'/cancel',
+        success: function (data){
+            toast('Command Canceled', data, 'success');
+        },
+        error: function (response, status, error) {
+            if (response.responseText === undefined) {
+                toast('Web Server Error',
+                    'Notifiarr client appears to be down! Hard refresh recommended.', 'error', 30000);
+            } else {
+                toast('Cancel Error', error+': '+response.responseText, 'error', 15000);
+            }
+        }
+    });
 }
@@ -6,11 +6,13 @@ import (
 	"fmt"
 	"net/http"
 	"path"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/Notifiarr/notifiarr/pkg/bindata"
 	"github.com/Notifiarr/notifiarr/pkg/mnd"
+	"github.com/gorilla/mux"
 	"golift.io/starr"
 )
 
@@ -76,6 +78,8 @@ func (c *Client) httpGuiHandlers(base string) {
 	gui.HandleFunc("/browse", c.handleFileBrowser).Queries("dir", "{dir}").Methods("GET")
 	gui.HandleFunc("/ajax/{path:cmdstats|cmdargs}/{hash}", c.handleCommandStats).Methods("GET")
 	gui.HandleFunc("/runCommand/{hash}", c.handleRunCommand).Methods("POST")
+	gui.HandleFunc("/runCommand/{hash}/cancel", c.handleCancelCommand).Methods("POST")
+	gui.HandleFunc("/ws", c.handleCommandSocket).Queries("source", "command", "hash", "{hash}").Methods("GET")
 	gui.HandleFunc("/ws", c.handleWebSockets).Queries("source", "{source}", "fileId", "{fileId}").Methods("GET")
 	gui.HandleFunc("/docs/json/{instance}", c.handlerSwaggerDoc).Methods("GET")
 	gui.HandleFunc("/ui.json", c.handlerSwaggerDoc).Methods("GET")
@@ -94,16 +98,29 @@ func (c *Client) httpAPIHandlers() {
 
 	// Aggregate handlers. Non-app specific.
 	c.Config.HandleAPIpath("", "/trash/{app}", c.triggers.CFSync.Handler, "POST")
+	c.Config.HandleAPIpath("", "/trash/local", c.triggers.CFSync.HandleLocal, "GET")
+	c.Config.HandleAPIpath("", "seeding/report", c.triggers.Seeding.HandleReport, "GET")
+	c.Config.HandleAPIpath("", "calendar", c.triggers.Calendar.HandleDigest, "GET")
+	c.Config.HandleAPIpath("", "backlog/log", c.triggers.Backlog.HandleLog, "GET")
+	c.Config.HandleAPIpath("", "compare", c.triggers.Compare.HandleReport, "GET")
+	c.Config.HandleAPIpath("", "starrsync/report", c.triggers.StarrSync.HandleReport, "GET")
+	c.Config.HandleAPIpath("", "queue", c.triggers.StarrQueue.HandleQueue, "GET")
+	c.Config.HandleAPIpath("", "stuckqueue/log", c.triggers.StarrQueue.HandleRemedyLog, "GET")
+	c.Config.HandleAPIpath("", "commands/history", c.triggers.Commands.HandleHistory, "GET")
+	c.Config.HandleAPIpath("", "commands/history/{hash}", c.triggers.Commands.HandleCommandHistory, "GET")
 
 	if c.Config.Plex.Enabled() {
 		c.Config.HandleAPIpath(starr.Plex, "sessions", c.Config.Plex.HandleSessions, "GET")
 		c.Config.HandleAPIpath(starr.Plex, "directory", c.Config.Plex.HandleDirectory, "GET")
 		c.Config.HandleAPIpath(starr.Plex, "emptytrash/{key}", c.Config.Plex.HandleEmptyTrash, "GET")
 		c.Config.HandleAPIpath(starr.Plex, "markwatched/{key}", c.Config.Plex.HandleMarkWatched, "GET")
+		c.Config.HandleAPIpath(starr.Plex, "history", c.triggers.PlexCron.HandleHistory, "GET")
+		c.Config.HandleAPIpath(starr.Plex, "history/report", c.triggers.PlexCron.HandleHistoryReport, "GET")
 		c.Config.HandleAPIpath(starr.Plex, "kill", c.Config.Plex.HandleKillSession, "GET").
 			Queries("reason", "{reason:.*}", "sessionId", "{sessionId:[0-9a-z-]+}")
 
 		tokens := fmt.Sprintf("{token:%s|%s}", c.Config.Plex.Token, c.Config.Apps.APIKey)
+		c.Config.Router.HandleFunc("/plex/scan", c.StarrImportHandler).Methods("POST").Queries("token", tokens)
 		c.Config.Router.HandleFunc("/plex", c.PlexHandler).Methods("POST").Queries("token", tokens)
 		c.Config.Router.HandleFunc("/", c.PlexHandler).Methods("POST").Queries("token", tokens)
 
@@ -111,6 +128,8 @@ func (c *Client) httpAPIHandlers() {
 			// Allow plex to use the base url too.
 			c.Config.Router.HandleFunc(path.Join(c.Config.URLBase, "plex"), c.PlexHandler).
 				Methods("POST").Queries("token", tokens)
+			c.Config.Router.HandleFunc(path.Join(c.Config.URLBase, "plex", "scan"), c.StarrImportHandler).
+				Methods("POST").Queries("token", tokens)
 		}
 	}
 }
@@ -184,6 +203,28 @@ func (c *Client) addUsernameHeader(next http.Handler) http.Handler {
 	})
 }
 
+// syncStarrChanges runs the Starr sync rules after a movie or series is added or updated with the API.
+// This must be used after countRequest, so the response status code is available.
+func (c *Client) syncStarrChanges(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(response http.ResponseWriter, req *http.Request) {
+		next.ServeHTTP(response, req)
+
+		wrap, ok := response.(*responseWrapper)
+		if route := mux.CurrentRoute(req); !ok || route == nil || c.triggers == nil ||
+			wrap.statusCode < http.StatusOK || wrap.statusCode >= http.StatusMultipleChoices {
+			return
+		} else if tmpl, _ := route.GetPathTemplate(); strings.HasSuffix(tmpl, "/add") || strings.HasSuffix(tmpl, "/update") {
+			instance, _ := strconv.Atoi(mux.Vars(req)["id"])
+
+			for _, app := range []starr.App{starr.Radarr, starr.Sonarr} {
+				if strings.HasPrefix(tmpl, path.Join("/", c.Config.URLBase, "api", app.Lower())+"/") {
+					c.triggers.StarrSync.Changed(app, instance)
+				}
+			}
+		}
+	})
+}
+
 func (c *Client) countRequest(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(response http.ResponseWriter, req *http.Request) {
 		mnd.HTTPRequests.Add("Total Requests", 1)
@@ -478,6 +478,24 @@ func (c *Client) handleRunCommand(response http.ResponseWriter, request *http.Re
 	http.Error(response, "Check command output after a few seconds.", http.StatusOK)
 }
 
+// handleCancelCommand stops a running command.
+func (c *Client) handleCancelCommand(response http.ResponseWriter, request *http.Request) {
+	cmd := c.triggers.Commands.GetByHash(mux.Vars(request)["hash"])
+	if cmd == nil {
+		http.Error(response, "Invalid command Hash provided", http.StatusBadRequest)
+		return
+	}
+
+	if !cmd.Cancel() {
+		http.Error(response, "Command is not running.", http.StatusNotAcceptable)
+		return
+	}
+
+	user, _ := c.getUserName(request)
+	c.Printf("[gui '%s' requested] Canceled Custom Command '%s'", user, cmd.Name)
+	http.Error(response, "Command canceled.", http.StatusOK)
+}
+
 // handleProcessList just returns the running process list for a human to view.
 func (c *Client) handleProcessList(response http.ResponseWriter, request *http.Request) {
 	if ps, err := getProcessList(request.Context()); err != nil {
@@ -667,6 +685,11 @@ func (c *Client) mergeAndValidateNewConfig(config *configfile.Config, request *h
 		config.Apps.Tautulli = nil
 	}
 
+	// Some Plex settings are not configurable in the GUI, so keep them.
+	oldPlex := config.Plex
+	oldWatch := config.WatchFiles
+	oldCommands := config.Commands
+
 	config.Plex = nil
 	config.WatchFiles = nil
 	config.Commands = nil
@@ -677,6 +700,30 @@ func (c *Client) mergeAndValidateNewConfig(config *configfile.Config, request *h
 		return fmt.Errorf("decoding POST data into Go data structure failed: %w", err)
 	}
 
+	if config.Plex != nil && oldPlex != nil {
+		config.Plex.Events = oldPlex.Events
+		config.Plex.PathMaps = oldPlex.PathMaps
+		config.Plex.HistorySize = oldPlex.HistorySize
+	}
+
+	// Same for some file watcher settings. They're kept for the watcher with the same path.
+	for _, watch := range config.WatchFiles {
+		for _, old := range oldWatch {
+			if watch != nil && old != nil && watch.Path == old.Path {
+				watch.KeepSettings(old)
+			}
+		}
+	}
+
+	// And the command history settings. They're kept for the command with the same name.
+	for _, cmd := range config.Commands {
+		for _, old := range oldCommands {
+			if cmd != nil && old != nil && cmd.Name == old.Name {
+				cmd.KeepSettings(old)
+			}
+		}
+	}
+
 	if err := c.validateNewCommandConfig(config); err != nil {
 		return err
 	}
@@ -689,6 +736,14 @@ func (c *Client) validateNewCommandConfig(config *configfile.Config) error {
 		if err := cmd.SetupRegexpArgs(); err != nil {
 			return fmt.Errorf("command %d '%s' failed setup: %w", idx+1, cmd.Name, err)
 		}
+
+		if err := cmd.SetupSchedule(); err != nil {
+			return fmt.Errorf("command %d failed setup: %w", idx+1, err)
+		}
+
+		if err := cmd.SetupOptions(); err != nil {
+			return fmt.Errorf("command %d failed setup: %w", idx+1, err)
+		}
 	}
 
 	return nil
@@ -2,9 +2,11 @@
 package client
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"path"
 	"strings"
 	"time"
 
@@ -56,32 +58,30 @@ func (c *Client) PlexHandler(w http.ResponseWriter, r *http.Request) { //nolint:
 		mnd.Apps.Add("Plex&&Webhook Errors", 1)
 		http.Error(w, "payload error", http.StatusBadRequest)
 		c.Errorf("Unmarshalling Plex payload: %v", err)
-	case strings.EqualFold(v.Event, "admin.database.backup"):
-		fallthrough
-	case strings.EqualFold(v.Event, "device.new"):
-		fallthrough
-	case strings.EqualFold(v.Event, "media.rate"):
-		fallthrough
-	case strings.EqualFold(v.Event, "library.new"):
-		fallthrough
-	case strings.EqualFold(v.Event, "admin.database.corrupt"):
+	case !c.plexEventAllowed(&v):
+		c.Printf("Plex Incoming Webhook Ignored (disabled or filtered): %s, %s '%s' ~> %s",
+			v.Server.Title, v.Account.Title, v.Event, v.Metadata.Title)
+		http.Error(w, "ignored, disabled", http.StatusAlreadyReported)
+	case isPlexRelayEvent(v.Event):
+		if c.plexEventCooldown(&v) {
+			c.Printf("Plex Incoming Webhook Ignored (cooldown): %s, %s '%s' ~> %s",
+				v.Server.Title, v.Account.Title, v.Event, v.Metadata.Title)
+			http.Error(w, "ignored, cooldown", http.StatusAlreadyReported)
+
+			return
+		}
+
 		c.Printf("Plex Incoming Webhook: %s, %s '%s' ~> %s (relaying to Notifiarr)",
 			v.Server.Title, v.Account.Title, v.Event, v.Metadata.Title)
-		c.website.SendData(&website.Request{
-			Route:      website.PlexRoute,
-			Event:      website.EventHook,
-			LogPayload: true,
-			LogMsg:     fmt.Sprintf("Plex Webhook: %s '%s' ~> %s", v.Account.Title, v.Event, v.Metadata.Title),
-			Payload:    &website.Payload{Load: &v, Plex: &plex.Sessions{Name: c.Config.Plex.Server.Name()}},
-		})
+		go c.relayPlexWebhook(&v)
 		r.Header.Set("X-Request-Time", fmt.Sprintf("%dms", time.Since(start).Milliseconds()))
 		http.Error(w, "process", http.StatusAccepted)
-	case strings.EqualFold(v.Event, "media.resume") && c.plexTimer.Active(v.Metadata.Key+"resume", c.plexCooldown()):
+	case strings.EqualFold(v.Event, "media.resume") && c.plexTimer.Active(v.Metadata.Key+"resume", c.plexCooldown(&v)):
 		c.Printf("Plex Incoming Webhook Ignored (cooldown): %s, %s '%s' ~> %s",
 			v.Server.Title, v.Account.Title, v.Event, v.Metadata.Title)
 		http.Error(w, "ignored, cooldown", http.StatusAlreadyReported)
 	case strings.EqualFold(v.Event, "media.play"), strings.EqualFold(v.Event, "playback.started"):
-		if c.plexTimer.Active(v.Metadata.Key+"play", c.plexCooldown()) {
+		if c.plexTimer.Active(v.Metadata.Key+"play", c.plexCooldown(&v)) {
 			c.Printf("Plex Incoming Webhook Ignored (cooldown): %s, %s '%s' ~> %s",
 				v.Server.Title, v.Account.Title, v.Event, v.Metadata.Title)
 			http.Error(w, "ignored, cooldown", http.StatusAlreadyReported)
@@ -103,10 +103,145 @@ func (c *Client) PlexHandler(w http.ResponseWriter, r *http.Request) { //nolint:
 	}
 }
 
-func (c *Client) plexCooldown() time.Duration {
+// plexRelayEvents are relayed directly to the website. The value is true
+// for events that are only relayed when they have an [[plex.event]] configured.
+//
+//nolint:gochecknoglobals
+var plexRelayEvents = map[string]bool{
+	"admin.database.backup":  false,
+	"admin.database.corrupt": false,
+	"device.new":             false,
+	"library.new":            false,
+	"media.rate":             false,
+	"library.on.deck":        true,
+	"media.scrobble":         true,
+}
+
+func isPlexRelayEvent(event string) bool {
+	_, ok := plexRelayEvents[strings.ToLower(event)]
+	return ok
+}
+
+// plexEventAllowed checks the event's config to see if it's disabled or filtered by library.
+// Opt-in events are only allowed when they are configured.
+func (c *Client) plexEventAllowed(v *plex.IncomingWebhook) bool {
+	event := c.Config.Plex.GetEvent(v.Event)
+	if event == nil {
+		return !plexRelayEvents[strings.ToLower(v.Event)]
+	}
+
+	return !event.Disabled && event.AllowLibrary(v.Metadata.LibrarySectionTitle, v.Metadata.LibrarySectionID)
+}
+
+// plexEventCooldown returns true if a relayed event has a cooldown configured and it's active.
+func (c *Client) plexEventCooldown(v *plex.IncomingWebhook) bool {
+	event := c.Config.Plex.GetEvent(v.Event)
+	if event == nil || event.Cooldown.Duration <= 0 {
+		return false
+	}
+
+	// Device events have no metadata, and rate events are per-user.
+	key := v.Event + v.Metadata.Key + v.Player.UUID + v.Account.Title
+
+	return c.plexTimer.Active(key, event.Cooldown.Duration)
+}
+
+// relayPlexWebhook sends a webhook to the website. Enrichment makes requests to the Starr apps,
+// so this runs in a go routine after the webhook is accepted.
+func (c *Client) relayPlexWebhook(v *plex.IncomingWebhook) {
+	defer c.CapturePanic()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	matches, err := c.Config.Apps.EnrichPlexWebhook(ctx, v)
+	if err != nil {
+		c.Errorf("Matching Plex Webhook item to Starr apps: %v", err)
+	}
+
+	c.website.SendData(&website.Request{
+		Route:      website.PlexRoute,
+		Event:      website.EventHook,
+		LogPayload: true,
+		LogMsg:     fmt.Sprintf("Plex Webhook: %s '%s' ~> %s", v.Account.Title, v.Event, v.Metadata.Title),
+		Payload: &website.Payload{
+			Load:  v,
+			Plex:  &plex.Sessions{Name: c.Config.Plex.Server.Name()},
+			Starr: matches,
+		},
+	})
+}
+
+func (c *Client) plexCooldown(v *plex.IncomingWebhook) time.Duration {
+	if event := c.Config.Plex.GetEvent(v.Event); event != nil && event.Cooldown.Duration > 0 {
+		return event.Cooldown.Duration
+	}
+
 	if ci := clientinfo.Get(); ci != nil {
 		return ci.Actions.Plex.Cooldown.Duration
 	}
 
 	return time.Minute
 }
+
+// starrImport is the part of a Radarr or Sonarr webhook used to find the folder with an imported file.
+type starrImport struct {
+	EventType string `json:"eventType"`
+	Movie     *struct {
+		FolderPath string `json:"folderPath"`
+	} `json:"movie"`
+	Series *struct {
+		Path string `json:"path"`
+	} `json:"series"`
+	EpisodeFile *struct {
+		RelativePath string `json:"relativePath"`
+	} `json:"episodeFile"`
+}
+
+// folder returns the folder that contains the imported media.
+func (s *starrImport) folder() string {
+	switch {
+	case s.Movie != nil:
+		return s.Movie.FolderPath
+	case s.Series != nil && s.EpisodeFile != nil:
+		// Sonarr uses forward slashes in relative paths, even on Windows. Scan the season folder.
+		return strings.TrimSuffix(s.Series.Path+"/"+path.Dir(s.EpisodeFile.RelativePath), "/.")
+	case s.Series != nil:
+		return s.Series.Path
+	default:
+		return ""
+	}
+}
+
+// StarrImportHandler handles an incoming import webhook from Radarr or Sonarr.
+// @Summary      Accept Starr Import Webhook
+// @Description  Accepts a Radarr or Sonarr webhook. On import (Download) events a partial scan of the
+// @Description  imported folder is requested from Plex. Add this URL as a Webhook connection in Radarr or Sonarr.
+// @Description  Does not require X-API-Key header.
+// @Tags         Plex
+// @Accept       json
+// @Produce      text/plain
+// @Param        token query   string true "Plex Token or Client API Key"
+// @Success      202  {string} string "accepted"
+// @Success      208  {string} string "ignored"
+// @Failure      400  {string} string "bad input"
+// @Failure      404  {string} string "bad token or api key"
+// @Router       /plex/scan [post]
+func (c *Client) StarrImportHandler(w http.ResponseWriter, r *http.Request) { //nolint:varnamelen
+	mnd.Apps.Add("Plex&&Incoming Import Webhooks", 1)
+
+	var v starrImport
+
+	switch err := json.NewDecoder(r.Body).Decode(&v); {
+	case err != nil:
+		mnd.Apps.Add("Plex&&Webhook Errors", 1)
+		http.Error(w, "payload error", http.StatusBadRequest)
+		c.Errorf("Unmarshalling Starr import payload: %v", err)
+	case v.EventType != "Download" || v.folder() == "":
+		http.Error(w, "ignored, unsupported", http.StatusAlreadyReported)
+		c.Debugf("Starr Incoming Import Webhook Ignored (unsupported): %s", v.EventType)
+	default:
+		c.triggers.PlexScan.Scan(website.EventHook, v.folder())
+		http.Error(w, "processing", http.StatusAccepted)
+	}
+}
@@ -145,6 +145,68 @@ func (c *Client) webSocketWriter(socket *websocket.Conn, fileTail *tail.Tail) {
 	}
 }
 
+// handleCommandSocket streams a custom command's output on a websocket while it runs.
+// This only watches; the command is started with a POST to /runCommand/{hash}.
+// The socket stays open until the viewer leaves, and output from every run of the command is streamed.
+func (c *Client) handleCommandSocket(response http.ResponseWriter, request *http.Request) {
+	defer c.CapturePanic()
+
+	cmd := c.triggers.Commands.GetByHash(mux.Vars(request)["hash"])
+	if cmd == nil {
+		http.Error(response, "Invalid command Hash provided", http.StatusBadRequest)
+		c.socketLog(http.StatusBadRequest, request)
+
+		return
+	}
+
+	socket, err := upgrader.Upgrade(response, request, nil)
+	if err != nil {
+		c.Errorf("[gui requested] Creating Websocket: %v", err)
+		c.socketLog(http.StatusInternalServerError, request)
+
+		return
+	}
+
+	output, stop := cmd.Watch()
+
+	go c.commandSocketWriter(socket, output, stop)
+	c.socketLog(http.StatusOK, request)
+	c.webSocketReader(socket)
+}
+
+func (c *Client) commandSocketWriter(socket *websocket.Conn, output <-chan string, stop func()) {
+	var (
+		pingTicker = time.NewTicker(29 * time.Second) //nolint:gomnd
+		writeWait  = 10 * time.Second
+	)
+
+	defer func() {
+		c.CapturePanic()
+		stop()
+		pingTicker.Stop()
+		socket.Close()
+	}()
+
+	for {
+		select {
+		case text := <-output:
+			_ = socket.SetWriteDeadline(time.Now().Add(writeWait))
+
+			if err := socket.WriteMessage(websocket.TextMessage, []byte(text)); err != nil {
+				c.Debugf("websocket closed, write error: %v", err)
+				return // dead sock
+			}
+		case <-pingTicker.C:
+			_ = socket.SetWriteDeadline(time.Now().Add(writeWait))
+
+			if err := socket.WriteMessage(websocket.PingMessage, []byte{}); err != nil {
+				c.Debugf("websocket closed, ping error: %v", err)
+				return
+			}
+		}
+	}
+}
+
 func (c *Client) webSocketReader(socket *websocket.Conn) {
 	defer func() {
 		c.CapturePanic()
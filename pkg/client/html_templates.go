@@ -22,6 +22,7 @@ import (
 	"github.com/Notifiarr/notifiarr/pkg/mnd"
 	"github.com/Notifiarr/notifiarr/pkg/snapshot"
 	"github.com/Notifiarr/notifiarr/pkg/triggers/data"
+	"github.com/Notifiarr/notifiarr/pkg/triggers/starrqueue"
 	"github.com/Notifiarr/notifiarr/pkg/website/clientinfo"
 	"github.com/fsnotify/fsnotify"
 	"github.com/hako/durafmt"
@@ -178,6 +179,8 @@ func (c *Client) getFuncMap() template.FuncMap { //nolint:funlen
 			return i + j
 		},
 		"intervaloptions": intervaloptions,
+		// returns the cached Starr app queue items.
+		"starrqueue": func() []*starrqueue.QueueItem { return c.triggers.StarrQueue.QueueItems() },
 	}
 }
 
@@ -319,6 +322,8 @@ func megabyte(size interface{}) string {
 		val = int64(valtype)
 	case int:
 		val = int64(valtype)
+	case float64:
+		val = int64(valtype)
 	}
 
 	switch {
@@ -28,6 +28,7 @@ func (c *Client) StartWebServer() {
 	c.Config.Router.Use(c.fixForwardedFor)
 	c.Config.Router.Use(c.countRequest)
 	c.Config.Router.Use(c.addUsernameHeader)
+	c.Config.Router.Use(c.syncStarrChanges)
 	c.webauth = c.Config.UIPassword.Webauth() // this needs to be locked since password can be changed without reloading.
 
 	// Make a multiplexer because websockets can't use apache log.
@@ -23,8 +23,16 @@ import (
 	"github.com/Notifiarr/notifiarr/pkg/services"
 	"github.com/Notifiarr/notifiarr/pkg/snapshot"
 	"github.com/Notifiarr/notifiarr/pkg/triggers"
+	"github.com/Notifiarr/notifiarr/pkg/triggers/backlog"
+	"github.com/Notifiarr/notifiarr/pkg/triggers/calendar"
+	"github.com/Notifiarr/notifiarr/pkg/triggers/cfsync"
 	"github.com/Notifiarr/notifiarr/pkg/triggers/commands"
+	"github.com/Notifiarr/notifiarr/pkg/triggers/compare"
+	"github.com/Notifiarr/notifiarr/pkg/triggers/dashboard"
 	"github.com/Notifiarr/notifiarr/pkg/triggers/filewatch"
+	"github.com/Notifiarr/notifiarr/pkg/triggers/seeding"
+	"github.com/Notifiarr/notifiarr/pkg/triggers/starrqueue"
+	"github.com/Notifiarr/notifiarr/pkg/triggers/starrsync"
 	"github.com/Notifiarr/notifiarr/pkg/ui"
 	"github.com/Notifiarr/notifiarr/pkg/website"
 	"github.com/Notifiarr/notifiarr/pkg/website/clientinfo"
@@ -46,21 +54,30 @@ const (
 
 // Config represents the data in our config file.
 type Config struct {
-	HostID     string                 `json:"hostId" toml:"host_id" xml:"host_id" yaml:"hostId"`
-	UIPassword CryptPass              `json:"uiPassword" toml:"ui_password" xml:"ui_password" yaml:"uiPassword"`
-	BindAddr   string                 `json:"bindAddr" toml:"bind_addr" xml:"bind_addr" yaml:"bindAddr"`
-	SSLCrtFile string                 `json:"sslCertFile" toml:"ssl_cert_file" xml:"ssl_cert_file" yaml:"sslCertFile"`
-	SSLKeyFile string                 `json:"sslKeyFile" toml:"ssl_key_file" xml:"ssl_key_file" yaml:"sslKeyFile"`
-	AutoUpdate string                 `json:"autoUpdate" toml:"auto_update" xml:"auto_update" yaml:"autoUpdate"`
-	Upstreams  []string               `json:"upstreams" toml:"upstreams" xml:"upstreams" yaml:"upstreams"`
-	Timeout    cnfg.Duration          `json:"timeout" toml:"timeout" xml:"timeout" yaml:"timeout"`
-	Retries    int                    `json:"retries" toml:"retries" xml:"retries" yaml:"retries"`
-	Snapshot   *snapshot.Config       `json:"snapshot" toml:"snapshot" xml:"snapshot" yaml:"snapshot"`
-	Services   *services.Config       `json:"services" toml:"services" xml:"services" yaml:"services"`
-	Service    []*services.Service    `json:"service" toml:"service" xml:"service" yaml:"service"`
-	EnableApt  bool                   `json:"apt" toml:"apt" xml:"apt" yaml:"apt"`
-	WatchFiles []*filewatch.WatchFile `json:"watchFiles" toml:"watch_file" xml:"watch_file" yaml:"watchFiles"`
-	Commands   []*commands.Command    `json:"commands" toml:"command" xml:"command" yaml:"commands"`
+	HostID     string                  `json:"hostId" toml:"host_id" xml:"host_id" yaml:"hostId"`
+	UIPassword CryptPass               `json:"uiPassword" toml:"ui_password" xml:"ui_password" yaml:"uiPassword"`
+	BindAddr   string                  `json:"bindAddr" toml:"bind_addr" xml:"bind_addr" yaml:"bindAddr"`
+	SSLCrtFile string                  `json:"sslCertFile" toml:"ssl_cert_file" xml:"ssl_cert_file" yaml:"sslCertFile"`
+	SSLKeyFile string                  `json:"sslKeyFile" toml:"ssl_key_file" xml:"ssl_key_file" yaml:"sslKeyFile"`
+	AutoUpdate string                  `json:"autoUpdate" toml:"auto_update" xml:"auto_update" yaml:"autoUpdate"`
+	Upstreams  []string                `json:"upstreams" toml:"upstreams" xml:"upstreams" yaml:"upstreams"`
+	Timeout    cnfg.Duration           `json:"timeout" toml:"timeout" xml:"timeout" yaml:"timeout"`
+	Retries    int                     `json:"retries" toml:"retries" xml:"retries" yaml:"retries"`
+	Snapshot   *snapshot.Config        `json:"snapshot" toml:"snapshot" xml:"snapshot" yaml:"snapshot"`
+	Services   *services.Config        `json:"services" toml:"services" xml:"services" yaml:"services"`
+	Service    []*services.Service     `json:"service" toml:"service" xml:"service" yaml:"service"`
+	EnableApt  bool                    `json:"apt" toml:"apt" xml:"apt" yaml:"apt"`
+	WatchFiles []*filewatch.WatchFile  `json:"watchFiles" toml:"watch_file" xml:"watch_file" yaml:"watchFiles"`
+	Commands   []*commands.Command     `json:"commands" toml:"command" xml:"command" yaml:"commands"`
+	Series     dashboard.SeriesConfig  `json:"downloadStats" toml:"download_stats" xml:"download_stats" yaml:"downloadStats"`
+	Seeding    seeding.Config          `json:"seeding" toml:"seeding" xml:"seeding" yaml:"seeding"`
+	StarrQueue starrqueue.QueueConfig  `json:"starrQueue" toml:"starr_queue" xml:"starr_queue" yaml:"starrQueue"`
+	StuckQueue starrqueue.RemedyConfig `json:"stuckQueue" toml:"stuck_queue" xml:"stuck_queue" yaml:"stuckQueue"`
+	Calendar   calendar.Config         `json:"calendar" toml:"calendar" xml:"calendar" yaml:"calendar"`
+	Backlog    backlog.Config          `json:"backlog" toml:"backlog" xml:"backlog" yaml:"backlog"`
+	Compare    compare.Config          `json:"compare" toml:"compare" xml:"compare" yaml:"compare"`
+	StarrSync  starrsync.Config        `json:"starrSync" toml:"starr_sync" xml:"starr_sync" yaml:"starrSync"`
+	TrashLocal cfsync.LocalConfig      `json:"trashLocal" toml:"trash_local" xml:"trash_local" yaml:"trashLocal"`
 	*logs.LogConfig
 	*apps.Apps
 	Allow AllowedIPs `json:"-" toml:"-" xml:"-" yaml:"-"`
@@ -147,6 +164,38 @@ func (c *Config) Get(flag *Flags, logger *logs.Logger) (*website.Server, *trigge
 		return nil, nil, fmt.Errorf("service checks: %w", err)
 	}
 
+	if err := c.Seeding.Validate(len(c.Rtorrent) > 0); err != nil {
+		return nil, nil, err
+	}
+
+	if err := c.StarrQueue.Validate(); err != nil {
+		return nil, nil, err
+	}
+
+	if err := c.StuckQueue.Validate(); err != nil {
+		return nil, nil, err
+	}
+
+	if err := c.Calendar.Validate(); err != nil {
+		return nil, nil, err
+	}
+
+	if err := c.Backlog.Validate(); err != nil {
+		return nil, nil, err
+	}
+
+	if err := c.Compare.Validate(); err != nil {
+		return nil, nil, err
+	}
+
+	if err := c.StarrSync.Validate(); err != nil {
+		return nil, nil, err
+	}
+
+	if err := c.TrashLocal.Validate(); err != nil {
+		return nil, nil, err
+	}
+
 	// Make sure each app has a sane timeout.
 	if err := c.Apps.Setup(); err != nil {
 		return nil, nil, fmt.Errorf("setting up app: %w", err)
@@ -165,7 +214,7 @@ func (c *Config) Get(flag *Flags, logger *logs.Logger) (*website.Server, *trigge
 		HostID:  c.HostID,
 	})
 
-	return c.Services.Website, c.setup(), err
+	return c.Services.Website, c.setup(flag), err
 }
 
 func (c *Config) fixConfig() {
@@ -179,7 +228,7 @@ func (c *Config) fixConfig() {
 	c.Services.Plugins = c.Snapshot.Plugins
 }
 
-func (c *Config) setup() *triggers.Actions {
+func (c *Config) setup(flag *Flags) *triggers.Actions {
 	c.URLBase = strings.TrimSuffix(path.Join("/", c.URLBase), "/") + "/"
 	c.Allow = MakeIPs(c.Upstreams)
 
@@ -207,12 +256,32 @@ func (c *Config) setup() *triggers.Actions {
 		Commands:   c.Commands,
 		Services:   c.Services,
 		CIC:        cic,
+		DataDir:    dataDir(flag.ConfigFile),
+		Series:     c.Series,
+		Seeding:    &c.Seeding,
+		StarrQueue: &c.StarrQueue,
+		StuckQueue: &c.StuckQueue,
+		Calendar:   &c.Calendar,
+		Backlog:    &c.Backlog,
+		Compare:    &c.Compare,
+		StarrSync:  &c.StarrSync,
+		TrashLocal: &c.TrashLocal,
 	})
 	cic.CmdList = triggers.Commands.List()
 
 	return triggers
 }
 
+// dataDir returns the folder where triggers may store data files; next to the config file.
+// Returns an empty string when running without a config file; data files are not written then.
+func dataDir(configFile string) string {
+	if configFile == "" {
+		return ""
+	}
+
+	return filepath.Dir(configFile)
+}
+
 // FindAndReturn return a config file. Write one if requested.
 func (c *Config) FindAndReturn(ctx context.Context, configFile string, write bool) (string, string, string) {
 	var confFile string
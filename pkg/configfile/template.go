@@ -34,7 +34,11 @@ func Funcs() template.FuncMap {
 }
 
 //nolint:lll
-const tmpl = `###############################################
+const tmpl = `{{/* Text shared by the config sections below. */ -}}
+{{define "apps"}}## apps may be lidarr, radarr, readarr or sonarr, or include an instance like sonarr2; empty includes all.{{end -}}
+{{define "example"}}## Example {{.}} (remove the leading # hashes to use it):{{end -}}
+{{define "audit"}}## Use dry_run to see what would happen first. Actions are logged and saved to an audit log.{{end -}}
+###############################################
 # Notifiarr Client Example Configuration File #
 # Created by Notifiarr {{version}} #
 ###############################################
@@ -372,11 +376,53 @@ retries = {{.Retries}}
   {{- if .Plex.ValidSSL}}
   valid_ssl = true
   {{- end}}
+  {{- if .Plex.HistorySize}}
+  history_size = {{.Plex.HistorySize}} # Finished sessions kept in the local session history.
+  {{- end}}
+  {{- range .Plex.Events}}
+
+  [[plex.event]]
+    event     = "{{.Event}}"
+    {{- if .Disabled}}
+    disabled  = true
+    {{- end}}
+    libraries = [{{range $s := .Libraries}}"{{$s}}",{{end}}]
+    cooldown  = "{{.Cooldown}}"
+    enrich    = {{.Enrich}}
+  {{- end}}
+  {{- range .Plex.PathMaps}}
+
+  [[plex.path_map]]
+    starr = "{{.Starr}}"
+    plex  = "{{.Plex}}"
+  {{- end}}
 {{- else}}#[plex]
 #url     = "http://localhost:32400/" # Your plex URL
 #token   = "" # your plex token; get this from a web inspector
 {{- end }}
 
+## Customize how incoming Plex webhook events are handled. Add one [[plex.event]] per event.
+## Supported: library.new, library.on.deck, media.rate, media.scrobble, device.new, playback.started,
+## media.play and media.resume. library.on.deck and media.scrobble are only relayed when configured.
+## libraries filters by library title or section ID; an empty list allows all libraries.
+## enrich = true attaches matching Radarr movies and Sonarr series to the notification.
+#[[plex.event]]
+#  event     = "library.new"
+#  disabled  = false
+#  libraries = ["Movies", "TV Shows"]
+#  cooldown  = "1m"
+#  enrich    = true
+
+## Radarr and Sonarr can request a partial Plex library scan when they import a file. Add a Webhook
+## connection (On Import) in each app with the URL http://notifiarr:5454/plex/scan?token=<your api key>
+## If Plex sees your media at a different path than the Starr apps, add a path map for each mount.
+#[[plex.path_map]]
+#  starr = "/movies"
+#  plex  = "/data/movies"
+
+## Set history_size in the [plex] section to keep a local history of finished sessions.
+## Watch time reports by user, library and day are available in the GUI and API. ie. history_size = 1000
+
 #####################
 # Tautulli Settings #
 #####################
@@ -492,6 +538,14 @@ bus_ids  = [{{range $s := .Snapshot.Nvidia.BusIDs}}"{{$s}}",{{end}}]
 ######################
 
 ## Tail a log file, regex match lines, and send notifications.
+## path may also be a glob pattern with a * or ?, like '/var/log/sonarr/*.txt', or a directory to watch every file in it.
+## New files that match are read from the beginning, and removed files are no longer watched.
+## path may also be a journal or docker log source. The regex matches the log message, and the other values
+## in each entry are fields (see json below), like PRIORITY and _SYSTEMD_UNIT, or stream and container.
+##   journal:sonarr.service, journal:identifier=sshd  - runs journalctl; separate more than one with commas.
+##   journal:/path/to/journal.log                     - reads journal entries from a file in json or export format.
+##   docker:sonarr                                    - tails a container's json-file log by name or ID.
+##                                                      /var/lib/docker/containers must be readable.
 ## Example:
 
 #[[watch_file]]
@@ -502,6 +556,38 @@ bus_ids  = [{{range $s := .Snapshot.Nvidia.BusIDs}}"{{$s}}",{{end}}]
 #  pipe  = false
 #  must_exist = false
 #  log_match  = true
+##
+## Multi-line mode sends a stack trace or other multi-line log entry as one event, and the regex matches the whole event.
+## start is a regex for the first line of an event, continue is a regex for the other lines,
+## and indent = true makes indented lines continue an event. With only start, every line until the next start is included.
+## An event is sent when the next one starts, after max_lines (default 100), or after no new lines for timeout (default 2s).
+#  start     = '''^\d{4}-\d\d-\d\d'''
+#  indent    = true
+#  max_lines = 50
+#  timeout   = "2s"
+##
+## Named capture groups in the regex, like (?P<level>ERROR|WARN), are sent as fields with the match.
+## json = true turns lines that are json objects into fields; nested keys are joined with a dot, like "error.code".
+## where is a list of field=regex conditions for json lines; when provided, every condition must match instead of the regex.
+## format is a template for the notification message using the fields, like '{{"{{.level}}: {{.msg}}"}}'.
+## Use '{{"{{index . \"error.code\"}}"}}' for field names with a dot.
+#  json   = true
+#  where  = ['level=(?i)error|fatal', 'logger=^Sonarr']
+#  format = '''{{"{{.logger}}: {{.message}}"}}'''
+##
+## command is the name of a custom command to run when a line matches, like a restart script.
+## The command gets every capture group in the regex as arguments, or the fields named in args.
+## The command's argument regexes must match them. cooldown is the minimum time between runs, default 1m.
+#  command  = 'restart-sonarr'
+#  args     = ['instance']
+#  cooldown = "10m"
+##
+## Each watcher sends 6 matches, then 1 every 1.5s. Change this with burst and rate.
+## Matches over the limit are dropped, unless summary is set. Then they're counted, and
+## one summary is sent for them after the summary period, like "42 more matches in the last 5m".
+#  burst   = 10
+#  rate    = "10s"
+#  summary = "5m"
 {{if .WatchFiles}}
 ## Configured Watch Files:
 {{- range $item := .WatchFiles}}{{if $item}}
@@ -513,7 +599,21 @@ bus_ids  = [{{range $s := .Snapshot.Nvidia.BusIDs}}"{{$s}}",{{end}}]
   poll  = true{{end}}{{if $item.Pipe}}
   pipe  = true{{end}}{{if $item.MustExist}}
   must_exist = true{{end}}{{if $item.LogMatch}}
-  log_match = true{{end}}{{end}}
+  log_match = true{{end}}{{if $item.Start}}
+  start = '''{{$item.Start}}'''{{end}}{{if $item.Continue}}
+  continue = '''{{$item.Continue}}'''{{end}}{{if $item.Indent}}
+  indent = true{{end}}{{if $item.MaxLines}}
+  max_lines = {{$item.MaxLines}}{{end}}{{if $item.Timeout.Duration}}
+  timeout = "{{$item.Timeout}}"{{end}}{{if $item.JSON}}
+  json = true{{end}}{{if $item.Where}}
+  where = [{{range $s := $item.Where}}'''{{$s}}''',{{end}}]{{end}}{{if $item.Format}}
+  format = '''{{$item.Format}}'''{{end}}{{if $item.Command}}
+  command = '{{$item.Command}}'{{end}}{{if $item.Args}}
+  args = [{{range $s := $item.Args}}'{{$s}}',{{end}}]{{end}}{{if $item.Cooldown.Duration}}
+  cooldown = "{{$item.Cooldown}}"{{end}}{{if $item.Burst}}
+  burst = {{$item.Burst}}{{end}}{{if $item.Rate.Duration}}
+  rate = "{{$item.Rate}}"{{end}}{{if $item.Summary.Duration}}
+  summary = "{{$item.Summary}}"{{end}}{{end}}
 {{end}}{{end}}
 
 
@@ -536,6 +636,27 @@ bus_ids  = [{{range $s := .Snapshot.Nvidia.BusIDs}}"{{$s}}",{{end}}]
 #  log     = true
 #  notify  = true
 #  timeout = "10s"
+##
+## Each command keeps a history of its last runs with the exit code, duration, what triggered it and the end of the output.
+## history is how many runs to keep, default 10. Set save_history to keep the history between restarts.
+#  history      = 10
+#  save_history = true
+##
+## schedule runs the command without being triggered. Use an interval like "6h", or a cron expression
+## like "0 3 * * *" (minute hour day-of-month month day-of-week), or @hourly, @daily, @weekly or @monthly.
+## A scheduled run is skipped if the command is still running, and it only notifies if it fails.
+#  schedule = "0 3 * * *"
+##
+## env adds KEY=value variables to the command's environment. working_dir is the folder it runs in, and
+## stdin is written to its input. env values and stdin are templates that may use the trigger input:
+## {{"{{.Name}}"}} is the command name, {{"{{.Source}}"}} is what triggered it, and {{"{{index .Args 0}}"}} is the first arg.
+## run_as is a user or user:group to run the command as. This only works on Linux when the app runs as root.
+## max_output is how many bytes of output to keep; the rest is dropped. Default is 1MB.
+#  env          = ['BACKUP_DIR=/backups', 'TARGET={{"{{index .Args 0}}"}}']
+#  working_dir  = '/opt/scripts'
+#  stdin        = ''
+#  run_as       = 'media:media'
+#  max_output   = 65536
 {{if .Commands}}
 ## Configured Commands:
 {{- range $item := .Commands}}{{if $item}}
@@ -546,6 +667,285 @@ bus_ids  = [{{range $s := .Snapshot.Nvidia.BusIDs}}"{{$s}}",{{end}}]
   shell   = {{$item.Shell}}
   log     = {{$item.Log}}
   notify  = {{$item.Notify}}
-  timeout = "{{$item.Timeout}}"{{end}}
+  timeout = "{{$item.Timeout}}"{{if $item.History}}
+  history = {{$item.History}}{{end}}{{if $item.SaveHistory}}
+  save_history = true{{end}}{{if $item.Schedule}}
+  schedule = "{{$item.Schedule}}"{{end}}{{if $item.Env}}
+  env = [{{range $s := $item.Env}}'''{{$s}}''',{{end}}]{{end}}{{if $item.WorkingDir}}
+  working_dir = '{{$item.WorkingDir}}'{{end}}{{if $item.Stdin}}
+  stdin = '''{{toml $item.Stdin}}'''{{end}}{{if $item.RunAs}}
+  run_as = '{{$item.RunAs}}'{{end}}{{if $item.MaxOutput}}
+  max_output = {{$item.MaxOutput}}{{end}}{{end}}
 {{end}}{{end}}
+
+
+##############################
+# Download Client Statistics #
+##############################
+
+## Sample download client speeds, active transfers, ratio and free space on an interval.
+## Works with qBittorrent, Deluge, rTorrent, SABnzbd and NZBGet. New samples are sent with
+## the dashboard state, saved next to this config file, and graphed on the Integrations page.
+## interval is how often to sample; set it to 0 to disable. Something like "1m" works well.
+## retain is how long to keep samples. Defaults to 24h.
+[download_stats]
+  interval = "{{.Series.Interval}}"
+  retain   = "{{.Series.Retain}}"
+
+
+#################
+# Seeding Rules #
+#################
+
+## Pause or remove finished torrents in qBittorrent, Deluge and rTorrent.
+## Rules are checked in order and the first matching rule wins. clients, trackers and categories
+## narrow which torrents a rule applies to; leave them empty to match everything. clients may be
+## qbit, deluge or rtorrent, or include an instance number like qbit2. categories match the qBittorrent
+## category or tags, and the Deluge or rTorrent label. trackers match part of the tracker URL.
+## A torrent is acted on when it reaches the ratio OR the seed_time. When free_space (GB) is set,
+## the rule only applies while the client has less free space than that; torrents that seeded the
+## longest go first. action may be pause, remove (keeps data) or delete (removes data). Only a delete
+## rule may set free_space without a ratio or seed_time, because only deleting data frees space. rTorrent cannot
+## delete data, so when rTorrent is configured a delete rule must list its clients.
+## Torrents still in a Starr app queue are never touched, unless ignore_starr_queue is true.
+{{template "audit"}}
+## Set interval to 0 to only apply the rules with the seeding trigger.
+##
+{{template "example" "rule"}}
+#[[seeding.rule]]
+#  name       = "private trackers"
+#  action     = "remove"
+#  clients    = ["qbit", "deluge"]
+#  trackers   = ["tracker.example.com"]
+#  categories = ["radarr", "sonarr"]
+#  ratio      = 2.0
+#  seed_time  = "336h"
+#  free_space = 0
+[seeding]
+  interval = "{{.Seeding.Interval}}"
+  dry_run  = {{.Seeding.DryRun}}
+  ignore_starr_queue = {{.Seeding.IgnoreStarr}}
+{{- range $rule := .Seeding.Rules}}{{if $rule}}
+
+[[seeding.rule]]
+  name       = '{{$rule.Name}}'
+  action     = "{{$rule.Action}}"
+  clients    = [{{range $s := $rule.Clients}}'{{$s}}',{{end}}]
+  trackers   = [{{range $s := $rule.Trackers}}'{{$s}}',{{end}}]
+  categories = [{{range $s := $rule.Categories}}'{{$s}}',{{end}}]
+  ratio      = {{$rule.Ratio}}
+  seed_time  = "{{$rule.SeedTime}}"
+  free_space = {{$rule.FreeSpace}}{{end}}{{end}}
+
+
+###################
+# Upcoming Digest #
+###################
+
+## Sends a calendar of upcoming releases from every Starr app to the website: airing episodes,
+## cinema, digital and physical movie releases, albums and books. interval = "0s" disables the timer;
+## the digest is always available at /api/calendar and with the calendar trigger.
+## horizon is how far ahead to look, default 168h (7 days), max 2160h (90 days). group is day or week.
+{{template "apps"}}
+## tags and quality_profiles only include items with one of these tags or quality profiles (by name).
+## unmonitored = true includes unmonitored items.
+[calendar]
+  interval         = "{{.Calendar.Interval}}"
+  horizon          = "{{.Calendar.Horizon}}"
+  group            = "{{.Calendar.Group}}"
+  unmonitored      = {{.Calendar.Unmonitored}}
+  apps             = [{{range $s := .Calendar.Apps}}'{{$s}}',{{end}}]
+  tags             = [{{range $s := .Calendar.Tags}}'{{$s}}',{{end}}]
+  quality_profiles = [{{range $s := .Calendar.Profiles}}'{{$s}}',{{end}}]
+
+##################
+# Backlog Search #
+##################
+
+## Searches the wanted missing and cutoff unmet lists in every Starr app, a few items at a time.
+## Enable one or both lists with missing and cutoff. interval = "0s" disables the timer;
+## the backlog trigger still works. Searched items are remembered, and not searched again until retry_after passes.
+## batch is the number of items searched in each instance per run, default 5. With both lists enabled,
+## the batch is split between them, and the list that gets the extra item takes turns.
+## max_per_day limits the searches in each instance per day, default 100; -1 is unlimited.
+## delay is the time to wait between searches in different instances, default 5s. Go easy on your indexers.
+{{template "apps"}}
+## dry_run = true logs what would be searched without searching. The log is at /api/backlog/log.
+[backlog]
+  interval    = "{{.Backlog.Interval}}"
+  missing     = {{.Backlog.Missing}}
+  cutoff      = {{.Backlog.Cutoff}}
+  batch       = {{.Backlog.Batch}}
+  max_per_day = {{.Backlog.MaxPerDay}}
+  delay       = "{{.Backlog.Delay}}"
+  retry_after = "{{.Backlog.RetryAfter}}"
+  apps        = [{{range $s := .Backlog.Apps}}'{{$s}}',{{end}}]
+  dry_run     = {{.Backlog.DryRun}}
+
+###################
+# Library Compare #
+###################
+
+## Compares the libraries in every Radarr instance by TMDB ID, and every Sonarr instance by TVDB ID.
+## Useful for 1080p and 4K pairs. Sends the items that differ to the website as a notification.
+## interval = "0s" disables the timer; the report is always available at /api/compare and with the compare trigger.
+## apps may be radarr or sonarr, or include an instance like radarr2; empty includes all.
+## checks may include missing (not in every instance), monitored (different monitored state),
+## and profile (different quality profile name); empty includes missing and monitored.
+[compare]
+  interval = "{{.Compare.Interval}}"
+  apps     = [{{range $s := .Compare.Apps}}'{{$s}}',{{end}}]
+  checks   = [{{range $s := .Compare.Checks}}'{{$s}}',{{end}}]
+
+##############
+# Starr Sync #
+##############
+
+## Mirrors a Radarr or Sonarr instance (source) to another instance of the same app (target).
+## add = true adds new items to the target; an item removed from the target is reported as a conflict, not added again.
+## delete = true deletes items from the target after they are deleted from the source; delete_files also deletes the files.
+## Deletes are skipped and reported as a conflict when the source library is empty, or more than 25 items
+## (or more than 10% of the library) are missing from it at once. This protects the target from a bad source.
+## monitored = true copies monitored state changes; differences that did not change in the source are conflicts.
+## search = true searches for items added to the target. Rules also run after the /add and /update APIs change a source.
+## root_folders, quality_profiles and tags map source values to target values, like '/movies=/movies4k' or 'HD-1080p=UHD'.
+## An entry without an equal sign is the default root folder or profile, or a tag always added to new items.
+## Unmapped root folders and profiles use the same name in the target; unmapped tags are not copied.
+## Write root folders without a trailing slash. Changes and conflicts are at /api/starrsync/report.
+##
+{{template "example" "rule"}}
+#[[starr_sync.rule]]
+#  name             = "4K movies"
+#  app              = "radarr"
+#  source           = 1
+#  target           = 2
+#  add              = true
+#  delete           = true
+#  monitored        = true
+#  search           = true
+#  root_folders     = ['/movies4k']
+#  quality_profiles = ['UHD']
+#  tags             = ['4k', 'kids=kids']
+[starr_sync]
+  interval = "{{.StarrSync.Interval}}"
+  dry_run  = {{.StarrSync.DryRun}}
+{{- range $rule := .StarrSync.Rules}}{{if $rule}}
+
+[[starr_sync.rule]]
+  name             = '{{$rule.Name}}'
+  app              = "{{$rule.App}}"
+  source           = {{$rule.Source}}
+  target           = {{$rule.Target}}
+  add              = {{$rule.Add}}
+  delete           = {{$rule.Delete}}
+  delete_files     = {{$rule.DeleteFiles}}
+  monitored        = {{$rule.Monitored}}
+  search           = {{$rule.Search}}
+  root_folders     = [{{range $s := $rule.RootFolders}}'{{$s}}',{{end}}]
+  quality_profiles = [{{range $s := $rule.Profiles}}'{{$s}}',{{end}}]
+  tags             = [{{range $s := $rule.Tags}}'{{$s}}',{{end}}]{{end}}{{end}}
+
+####################
+# Local TRaSH Sync #
+####################
+
+## Applies custom formats, scores and Sonarr v3 release profiles from a local copy of the TRaSH guides,
+## instead of the data from the website. path is a TRaSH guides git checkout or a folder of guide json files.
+## pull = true runs git pull in the path before every sync that is not a dry run. mapping is a json, toml or yaml
+## file that selects the custom formats (by trash_id or name) and release profiles, and the quality profiles the
+## formats are scored in.
+## Example mapping.toml:
+##   [[radarr]]
+##     instances        = [1]
+##     quality_profiles = ['HD Bluray + WEB']
+##     score_set        = 'default'
+##     custom_format    = [{trash_id = 'ed38b889b31be83fda192888e2286d83'}, {name = 'BR-DISK', score = -10000}]
+##   [[sonarr]]
+##     release_profiles = ['[Release Groups] Anime']
+## dry_run = true only reports the changes. Preview the changes any time at /api/trash/local?dryRun=true.
+[trash_local]
+  interval = "{{.TrashLocal.Interval}}"
+  path     = '{{.TrashLocal.Path}}'
+  mapping  = '{{.TrashLocal.Mapping}}'
+  pull     = {{.TrashLocal.Pull}}
+  dry_run  = {{.TrashLocal.DryRun}}
+
+###############
+# Starr Queue #
+###############
+
+## Controls how the Starr app (Lidarr, Radarr, Readarr, Sonarr) queues are polled for stuck and downloading items.
+## These only apply to instances with stuck or downloading items enabled on the website.
+## stuck_interval defaults to 5m and downloading_interval defaults to 1m; the minimum is 10s.
+## max_items is the number of queue records to get, default 100. Set it to -1 to get every record.
+## page_size is the number of records requested at once, default 100.
+## incremental = true only sends the queue items that changed, and the removed queue record IDs, to the website.
+## A full queue is still sent once an hour. Override the settings for a single instance with [[starr_queue.instance]].
+##
+{{template "example" "instance"}}
+#[[starr_queue.instance]]
+#  app       = "sonarr"
+#  instance  = 1
+#  interval  = "2m"
+#  page_size = 500
+#  max_items = -1
+[starr_queue]
+  stuck_interval       = "{{.StarrQueue.StuckInterval}}"
+  downloading_interval = "{{.StarrQueue.DownloadingInterval}}"
+  page_size            = {{.StarrQueue.PageSize}}
+  max_items            = {{.StarrQueue.MaxItems}}
+  incremental          = {{.StarrQueue.Incremental}}
+{{- range $inst := .StarrQueue.Instances}}{{if $inst}}
+
+[[starr_queue.instance]]
+  app       = "{{$inst.App}}"
+  instance  = {{$inst.Instance}}
+  interval  = "{{$inst.Interval}}"
+  page_size = {{$inst.PageSize}}
+  max_items = {{$inst.MaxItems}}{{end}}{{end}}
+
+###############
+# Stuck Queue #
+###############
+
+## Fix stuck Starr app (Lidarr, Radarr, Readarr, Sonarr) queue items automatically.
+## An item is stuck when it has a failed, warning or error status, or status messages. A completed item
+## is only stuck if it has a warning or error, because completed items normally wait in the queue to import.
+## Rules are checked in order and the first matching rule wins. Rules apply once the item has been
+## stuck for the age. apps, statuses and messages narrow which items a rule applies to; leave them empty
+## to match everything. Every rule needs an age, statuses or messages.
+{{template "apps"}}
+## messages match part of the item's status or error messages.
+## action may be blocklist (remove and blocklist the release), remove, or search (search again, remove nothing).
+## search = true also searches again after blocklist or remove. keep_in_client = true leaves the download
+## in the download client when it's removed. interval is how often the queues are checked, default 5m.
+## An item that is still in the queue after an action (search, dry run or an error) is left alone
+## until retry passes, default 6h.
+{{template "audit"}}
+##
+{{template "example" "rule"}}
+#[[stuck_queue.rule]]
+#  name     = "failed downloads"
+#  action   = "blocklist"
+#  apps     = ["radarr", "sonarr"]
+#  statuses = ["failed", "warning"]
+#  messages = ["not an upgrade", "sample", "no files found"]
+#  age      = "2h"
+#  search   = true
+#  keep_in_client = false
+[stuck_queue]
+  interval = "{{.StuckQueue.Interval}}"
+  retry    = "{{.StuckQueue.Retry}}"
+  dry_run  = {{.StuckQueue.DryRun}}
+{{- range $rule := .StuckQueue.Rules}}{{if $rule}}
+
+[[stuck_queue.rule]]
+  name     = '{{$rule.Name}}'
+  action   = "{{$rule.Action}}"
+  apps     = [{{range $s := $rule.Apps}}'{{$s}}',{{end}}]
+  statuses = [{{range $s := $rule.Statuses}}'{{$s}}',{{end}}]
+  messages = [{{range $s := $rule.Messages}}'{{$s}}',{{end}}]
+  age      = "{{$rule.Age}}"
+  search   = {{$rule.Search}}
+  keep_in_client = {{$rule.KeepInClient}}{{end}}{{end}}
 `
@@ -0,0 +1,184 @@
+package backlog
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"path"
+	"strconv"
+
+	"github.com/Notifiarr/notifiarr/pkg/apps"
+	"github.com/Notifiarr/notifiarr/pkg/mnd"
+	"github.com/Notifiarr/notifiarr/pkg/triggers/common"
+	"golift.io/starr"
+	"golift.io/starr/lidarr"
+	"golift.io/starr/radarr"
+	"golift.io/starr/readarr"
+	"golift.io/starr/sonarr"
+)
+
+// wantedItem is the data the backlog search needs from any Starr app wanted record.
+type wantedItem struct {
+	id    int64 // episode, movie, album or book ID.
+	title string
+}
+
+// backlogApp wraps the different Starr apps, so their backlogs can be searched the same way.
+type backlogApp struct {
+	common.StarrApp
+	wanted func(ctx context.Context, list string, page int) ([]*wantedItem, int, error)
+	search func(ctx context.Context, ids []int64) error
+}
+
+func (b *backlogApp) key(id int64) string {
+	return b.Key() + ":" + strconv.FormatInt(id, mnd.Base10)
+}
+
+// backlogApps returns all the enabled Starr apps.
+func (c *cmd) backlogApps() []*backlogApp {
+	list := []*backlogApp{}
+
+	(&common.StarrApps{
+		Lidarr:  func(instance int, app *apps.LidarrConfig) { list = append(list, lidarrBacklog(instance, app)) },
+		Radarr:  func(instance int, app *apps.RadarrConfig) { list = append(list, radarrBacklog(instance, app)) },
+		Readarr: func(instance int, app *apps.ReadarrConfig) { list = append(list, readarrBacklog(instance, app)) },
+		Sonarr:  func(instance int, app *apps.SonarrConfig) { list = append(list, sonarrBacklog(instance, app)) },
+	}).Each(c.Apps)
+
+	return list
+}
+
+// getWanted requests a page from a wanted list. The starr library does not provide these yet.
+// include is the parameter that adds the series, artist or author to each record.
+func getWanted(ctx context.Context, api starr.APIer, apiVer, list string, page int, include string, output interface{}) error {
+	query := url.Values{
+		"page":      {strconv.Itoa(page)},
+		"pageSize":  {strconv.Itoa(wantedPageSize)},
+		"monitored": {"true"},
+	}
+
+	if include != "" {
+		query.Set(include, "true")
+	}
+
+	err := api.GetInto(ctx, starr.Request{URI: path.Join(apiVer, "wanted", list), Query: query}, output)
+	if err != nil {
+		return fmt.Errorf("api.Get(wanted/%s): %w", list, err)
+	}
+
+	return nil
+}
+
+func lidarrBacklog(instance int, app *apps.LidarrConfig) *backlogApp {
+	return &backlogApp{
+		StarrApp: common.StarrApp{App: starr.Lidarr, Instance: instance},
+		search: func(ctx context.Context, ids []int64) error {
+			_, err := app.SendCommandContext(ctx, &lidarr.CommandRequest{Name: "AlbumSearch", AlbumIDs: ids})
+			return err //nolint:wrapcheck
+		},
+		wanted: func(ctx context.Context, list string, page int) ([]*wantedItem, int, error) {
+			var output struct {
+				TotalRecords int             `json:"totalRecords"`
+				Records      []*lidarr.Album `json:"records"`
+			}
+
+			if err := getWanted(ctx, app.APIer, lidarr.APIver, list, page, "includeArtist", &output); err != nil {
+				return nil, 0, err
+			}
+
+			items := make([]*wantedItem, len(output.Records))
+			for idx, rec := range output.Records {
+				items[idx] = &wantedItem{id: rec.ID, title: rec.Title}
+				if rec.Artist != nil {
+					items[idx].title = rec.Artist.ArtistName + " - " + rec.Title
+				}
+			}
+
+			return items, output.TotalRecords, nil
+		},
+	}
+}
+
+func radarrBacklog(instance int, app *apps.RadarrConfig) *backlogApp {
+	return &backlogApp{
+		StarrApp: common.StarrApp{App: starr.Radarr, Instance: instance},
+		search: func(ctx context.Context, ids []int64) error {
+			_, err := app.SendCommandContext(ctx, &radarr.CommandRequest{Name: "MoviesSearch", MovieIDs: ids})
+			return err //nolint:wrapcheck
+		},
+		wanted: func(ctx context.Context, list string, page int) ([]*wantedItem, int, error) {
+			var output struct {
+				TotalRecords int             `json:"totalRecords"`
+				Records      []*radarr.Movie `json:"records"`
+			}
+
+			if err := getWanted(ctx, app.APIer, radarr.APIver, list, page, "", &output); err != nil {
+				return nil, 0, err
+			}
+
+			items := make([]*wantedItem, len(output.Records))
+			for idx, rec := range output.Records {
+				items[idx] = &wantedItem{id: rec.ID, title: rec.Title}
+			}
+
+			return items, output.TotalRecords, nil
+		},
+	}
+}
+
+func readarrBacklog(instance int, app *apps.ReadarrConfig) *backlogApp {
+	return &backlogApp{
+		StarrApp: common.StarrApp{App: starr.Readarr, Instance: instance},
+		search: func(ctx context.Context, ids []int64) error {
+			_, err := app.SendCommandContext(ctx, &readarr.CommandRequest{Name: "BookSearch", BookIDs: ids})
+			return err //nolint:wrapcheck
+		},
+		wanted: func(ctx context.Context, list string, page int) ([]*wantedItem, int, error) {
+			var output struct {
+				TotalRecords int             `json:"totalRecords"`
+				Records      []*readarr.Book `json:"records"`
+			}
+
+			if err := getWanted(ctx, app.APIer, readarr.APIver, list, page, "includeAuthor", &output); err != nil {
+				return nil, 0, err
+			}
+
+			items := make([]*wantedItem, len(output.Records))
+			for idx, rec := range output.Records {
+				items[idx] = &wantedItem{id: rec.ID, title: rec.AuthorTitle + " - " + rec.Title}
+			}
+
+			return items, output.TotalRecords, nil
+		},
+	}
+}
+
+func sonarrBacklog(instance int, app *apps.SonarrConfig) *backlogApp {
+	return &backlogApp{
+		StarrApp: common.StarrApp{App: starr.Sonarr, Instance: instance},
+		search: func(ctx context.Context, ids []int64) error {
+			_, err := app.SendCommandContext(ctx, &sonarr.CommandRequest{Name: "EpisodeSearch", EpisodeIDs: ids})
+			return err //nolint:wrapcheck
+		},
+		wanted: func(ctx context.Context, list string, page int) ([]*wantedItem, int, error) {
+			var output struct {
+				TotalRecords int               `json:"totalRecords"`
+				Records      []*sonarr.Episode `json:"records"`
+			}
+
+			if err := getWanted(ctx, app.APIer, sonarr.APIver, list, page, "includeSeries", &output); err != nil {
+				return nil, 0, err
+			}
+
+			items := make([]*wantedItem, len(output.Records))
+			for idx, rec := range output.Records {
+				items[idx] = &wantedItem{id: rec.ID, title: fmt.Sprintf("S%02dE%02d %s", rec.SeasonNumber, rec.EpisodeNumber, rec.Title)}
+				if rec.Series != nil {
+					items[idx].title = rec.Series.Title + " " + items[idx].title
+				}
+			}
+
+			return items, output.TotalRecords, nil
+		},
+	}
+}
@@ -0,0 +1,456 @@
+package backlog
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Notifiarr/notifiarr/pkg/triggers/common"
+	"github.com/Notifiarr/notifiarr/pkg/website"
+	"golift.io/cnfg"
+	"golift.io/starr"
+)
+
+/* Backlog search slowly works through the wanted missing and cutoff unmet lists in every Starr app. */
+
+const TrigBacklogSearch common.TriggerName = "Searching Starr App Backlogs."
+
+const (
+	searchedFile = "backlog_search.json"
+	// This is the max number of searches kept in the log.
+	searchLogMax = 500
+	// This is the number of wanted records requested at once.
+	wantedPageSize = 50
+	// This is the max number of wanted pages checked per list, per run. The next run continues where it stopped.
+	wantedPagesMax = 20
+)
+
+// Defaults for the [backlog] config section.
+const (
+	defaultBatch      = 5
+	defaultMaxPerDay  = 100
+	defaultRetryAfter = 7 * 24 * time.Hour
+	defaultDelay      = 5 * time.Second
+)
+
+// Wanted lists.
+const (
+	ListMissing = "missing"
+	ListCutoff  = "cutoff"
+)
+
+// Config is the [backlog] config section.
+type Config struct {
+	Interval   cnfg.Duration `json:"interval" toml:"interval" xml:"interval" yaml:"interval"` // 0 disables the timer.
+	Missing    bool          `json:"missing" toml:"missing" xml:"missing" yaml:"missing"`
+	Cutoff     bool          `json:"cutoff" toml:"cutoff" xml:"cutoff" yaml:"cutoff"`
+	Batch      int           `json:"batch" toml:"batch" xml:"batch" yaml:"batch"`
+	MaxPerDay  int           `json:"maxPerDay" toml:"max_per_day" xml:"max_per_day" yaml:"maxPerDay"`
+	Delay      cnfg.Duration `json:"delay" toml:"delay" xml:"delay" yaml:"delay"`
+	RetryAfter cnfg.Duration `json:"retryAfter" toml:"retry_after" xml:"retry_after" yaml:"retryAfter"`
+	Apps       []string      `json:"apps" toml:"apps" xml:"apps" yaml:"apps"` // radarr, sonarr, lidarr, readarr or radarr1.
+	DryRun     bool          `json:"dryRun" toml:"dry_run" xml:"dry_run" yaml:"dryRun"`
+}
+
+// Search is a batch of backlog items searched in a Starr app instance.
+type Search struct {
+	Time     time.Time `json:"time"`
+	App      starr.App `json:"app"`
+	Instance int       `json:"instance"`
+	List     string    `json:"list"`
+	IDs      []int64   `json:"ids"`
+	Titles   []string  `json:"titles"`
+	DryRun   bool      `json:"dryRun"`
+	Error    string    `json:"error,omitempty"`
+}
+
+// daily counts the searches for an instance on a single day.
+type daily struct {
+	Day   string `json:"day"`
+	Count int    `json:"count"`
+}
+
+// state is saved to a file, so searched items are remembered between restarts.
+type state struct {
+	Searched map[string]time.Time `json:"searched"` // keyed by app, instance and item ID.
+	Days     map[string]*daily    `json:"days"`     // keyed by app and instance.
+	Pages    map[string]int       `json:"pages"`    // next wanted page to check, keyed by app, instance and list.
+	Log      []*Search            `json:"log"`      // oldest first.
+}
+
+// Action contains the exported methods for this package.
+type Action struct {
+	cmd *cmd
+}
+
+type cmd struct {
+	*common.Config
+	backlog *Config
+	state   *state
+	runs    int // rotates the list that goes first.
+	mu      sync.RWMutex
+}
+
+// New configures the library.
+func New(config *common.Config, backlog *Config) *Action {
+	return &Action{cmd: &cmd{Config: config, backlog: backlog}}
+}
+
+// Create initializes the library.
+func (a *Action) Create() {
+	a.cmd.create()
+}
+
+func (c *cmd) create() {
+	if c.backlog == nil || (!c.backlog.Missing && !c.backlog.Cutoff) {
+		return
+	}
+
+	c.state = &state{Searched: make(map[string]time.Time), Days: make(map[string]*daily), Pages: make(map[string]int)}
+	if err := c.ReadDataFile(searchedFile, c.state); err != nil {
+		c.Errorf("Loading backlog search data: %v", err)
+	}
+
+	var ticker *time.Ticker
+
+	if c.backlog.Interval.Duration > 0 {
+		ticker = time.NewTicker(c.backlog.Interval.Duration)
+		c.Printf("==> Backlog Search timer started, interval:%s batch:%d max_per_day:%d missing:%v cutoff:%v dry_run:%v",
+			c.backlog.Interval, c.backlog.Batch, c.backlog.MaxPerDay, c.backlog.Missing, c.backlog.Cutoff, c.backlog.DryRun)
+	}
+
+	c.Add(&common.Action{
+		Name: TrigBacklogSearch,
+		Fn:   c.searchBacklogs,
+		C:    make(chan *common.ActionInput, 1),
+		T:    ticker,
+	})
+}
+
+// Validate sets defaults for the backlog search config.
+func (c *Config) Validate() error {
+	if c.Batch <= 0 {
+		c.Batch = defaultBatch
+	}
+
+	if c.MaxPerDay == 0 {
+		c.MaxPerDay = defaultMaxPerDay
+	}
+
+	if c.Delay.Duration == 0 {
+		c.Delay.Duration = defaultDelay
+	}
+
+	if c.RetryAfter.Duration == 0 {
+		c.RetryAfter.Duration = defaultRetryAfter
+	}
+
+	return nil
+}
+
+// lists returns the enabled wanted lists. first rotates the order, so each list takes turns going first.
+func (c *Config) lists(first int) []string {
+	lists := []string{}
+
+	if c.Missing {
+		lists = append(lists, ListMissing)
+	}
+
+	if c.Cutoff {
+		lists = append(lists, ListCutoff)
+	}
+
+	if len(lists) == 0 {
+		return lists
+	}
+
+	first %= len(lists)
+
+	return append(lists[first:], lists[:first]...)
+}
+
+// Run searches the backlogs now. Returns false if backlog search is not enabled.
+func (a *Action) Run(event website.EventType) bool {
+	if a.cmd.state == nil {
+		return false
+	}
+
+	return a.cmd.Exec(&common.ActionInput{Type: event}, TrigBacklogSearch)
+}
+
+func (c *cmd) searchBacklogs(ctx context.Context, input *common.ActionInput) {
+	searches := []*Search{}
+	c.runs++
+
+	for _, app := range c.backlogApps() {
+		if !app.Match(c.backlog.Apps) {
+			continue
+		}
+
+		limit := c.limit(app)
+		if limit < 1 {
+			c.Debugf("[%s requested] Backlog Search: %s %d reached the daily limit (%d).",
+				input.Type, app.App, app.Instance, c.backlog.MaxPerDay)
+			continue
+		}
+
+		if len(searches) > 0 && !c.wait(ctx) {
+			break // save what was searched.
+		}
+
+		for _, search := range c.searchApp(ctx, app, limit) {
+			if search.Error != "" {
+				c.Errorf("[%s requested] Backlog Search: %s %d: %s", input.Type, app.App, app.Instance, search.Error)
+			} else {
+				c.Printf("[%s requested] Backlog Search: searching %d %s items in %s %d (dry run: %v): %s",
+					input.Type, len(search.IDs), search.List, app.App, app.Instance, search.DryRun, strings.Join(search.Titles, ", "))
+			}
+
+			searches = append(searches, search)
+		}
+	}
+
+	c.save(searches)
+}
+
+// wait sleeps between searches, so indexers are not hit all at once. Returns false if the context ends first.
+func (c *cmd) wait(ctx context.Context) bool {
+	timer := time.NewTimer(c.backlog.Delay.Duration)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+// limit returns the number of items that may be searched in an instance right now.
+func (c *cmd) limit(app *backlogApp) int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	limit := c.backlog.Batch
+	if c.backlog.MaxPerDay < 0 {
+		return limit
+	}
+
+	if day := c.state.Days[app.Key()]; day != nil && day.Day == today() {
+		if left := c.backlog.MaxPerDay - day.Count; left < limit {
+			return left
+		}
+	}
+
+	if c.backlog.MaxPerDay < limit {
+		return c.backlog.MaxPerDay
+	}
+
+	return limit
+}
+
+// searchApp finds up to limit wanted items that were not searched recently, and searches them.
+// The limit is split between the enabled lists, so cutoff unmet items are searched while items
+// are still missing. A list with fewer items leaves the rest of its share to the next list.
+func (c *cmd) searchApp(ctx context.Context, app *backlogApp, limit int) []*Search {
+	searches := []*Search{}
+	lists := c.backlog.lists(c.runs)
+
+	for idx, list := range lists {
+		left := len(lists) - idx
+		share := (limit + left - 1) / left // round up; the list that goes first rotates between runs.
+
+		if share < 1 {
+			continue
+		}
+
+		items, err := c.findItems(ctx, app, list, share)
+		if err != nil {
+			searches = append(searches,
+				&Search{Time: time.Now(), App: app.App, Instance: app.Instance, List: list, Error: err.Error()})
+			continue
+		}
+
+		if len(items) == 0 {
+			continue // nothing left to search in this list.
+		}
+
+		limit -= len(items)
+		search := &Search{Time: time.Now(), App: app.App, Instance: app.Instance, List: list, DryRun: c.backlog.DryRun}
+
+		for _, item := range items {
+			search.IDs = append(search.IDs, item.id)
+			search.Titles = append(search.Titles, item.title)
+		}
+
+		if !search.DryRun {
+			if err := app.search(ctx, search.IDs); err != nil {
+				search.Error = err.Error()
+			}
+		}
+
+		searches = append(searches, search)
+	}
+
+	return searches
+}
+
+// findItems pages through a wanted list until it finds enough items that were not searched recently.
+// It starts on the page where the last run stopped, and wraps around to the first page at the end
+// of the list, so large backlogs are worked through over many runs.
+func (c *cmd) findItems(ctx context.Context, app *backlogApp, list string, limit int) ([]*wantedItem, error) {
+	items := []*wantedItem{}
+	found := make(map[int64]bool)
+	start := c.page(app, list)
+	page := start
+
+	for checked := 0; checked < wantedPagesMax && len(items) < limit; checked++ {
+		records, total, err := app.wanted(ctx, list, page)
+		if err != nil {
+			return nil, fmt.Errorf("getting wanted %s page %d: %w", list, page, err)
+		}
+
+		for _, item := range records {
+			if len(items) >= limit {
+				break
+			}
+
+			if !found[item.id] && !c.searchedRecently(app.key(item.id)) {
+				found[item.id] = true
+				items = append(items, item)
+			}
+		}
+
+		switch {
+		case len(items) >= limit:
+			// The next run starts on this page; the items searched now are skipped.
+		case len(records) == 0 || page*wantedPageSize >= total:
+			page = 1
+		default:
+			page++
+		}
+
+		if page == start && len(items) < limit {
+			break // checked the whole list.
+		}
+	}
+
+	c.setPage(app, list, page)
+
+	return items, nil
+}
+
+// page returns the wanted page to start on for an app instance's list.
+func (c *cmd) page(app *backlogApp, list string) int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if page := c.state.Pages[app.Key()+":"+list]; page > 0 {
+		return page
+	}
+
+	return 1
+}
+
+// setPage saves the wanted page to start on next time. Dry runs always start on the same page.
+func (c *cmd) setPage(app *backlogApp, list string, page int) {
+	if c.backlog.DryRun {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.state.Pages[app.Key()+":"+list] = page
+}
+
+func (c *cmd) searchedRecently(key string) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return time.Since(c.state.Searched[key]) < c.backlog.RetryAfter.Duration
+}
+
+// save records the searches, prunes old data, and writes the state file.
+func (c *cmd) save(searches []*Search) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, search := range searches {
+		if search.DryRun || search.Error != "" {
+			continue
+		}
+
+		app := &backlogApp{StarrApp: common.StarrApp{App: search.App, Instance: search.Instance}}
+		for _, id := range search.IDs {
+			c.state.Searched[app.key(id)] = search.Time
+		}
+
+		if day := c.state.Days[app.Key()]; day != nil && day.Day == today() {
+			day.Count += len(search.IDs)
+		} else {
+			c.state.Days[app.Key()] = &daily{Day: today(), Count: len(search.IDs)}
+		}
+	}
+
+	for key, searched := range c.state.Searched {
+		if time.Since(searched) >= c.backlog.RetryAfter.Duration {
+			delete(c.state.Searched, key)
+		}
+	}
+
+	if c.state.Log = append(c.state.Log, searches...); len(c.state.Log) > searchLogMax {
+		c.state.Log = c.state.Log[len(c.state.Log)-searchLogMax:]
+	}
+
+	if err := c.WriteDataFile(searchedFile, c.state); err != nil {
+		c.Errorf("Saving backlog search data: %v", err)
+	}
+}
+
+func today() string {
+	return time.Now().Format("2006-01-02")
+}
+
+// HandleLog returns the backlog search log.
+// @Summary      Retrieve backlog search log.
+// @Description  Returns the wanted missing and cutoff unmet searches made by the backlog search, newest first.
+// @Description  Also returns the number of searches made today for each Starr app instance.
+// @Tags         Triggers
+// @Produce      json
+// @Success      200  {object} apps.Respond.apiResponse{message=backlog.Report} "search log"
+// @Failure      501  {object} apps.Respond.apiResponse{message=string} "backlog search not enabled"
+// @Failure      404  {object} string "bad token or api key"
+// @Router       /api/backlog/log [get]
+// @Security     ApiKeyAuth
+func (a *Action) HandleLog(_ *http.Request) (int, interface{}) {
+	if a.cmd.state == nil {
+		return http.StatusNotImplemented, "Backlog search is not enabled."
+	}
+
+	a.cmd.mu.RLock()
+	defer a.cmd.mu.RUnlock()
+
+	report := &Report{Today: make(map[string]int), Searches: make([]*Search, 0, len(a.cmd.state.Log))}
+
+	for key, day := range a.cmd.state.Days {
+		if day.Day == today() {
+			report.Today[key] = day.Count
+		}
+	}
+
+	for idx := len(a.cmd.state.Log) - 1; idx >= 0; idx-- {
+		report.Searches = append(report.Searches, a.cmd.state.Log[idx])
+	}
+
+	return http.StatusOK, report
+}
+
+// Report is returned by the backlog search log API.
+type Report struct {
+	Today    map[string]int `json:"today"` // searches made today, keyed by app and instance.
+	Searches []*Search      `json:"searches"`
+}
@@ -0,0 +1,138 @@
+package backlog
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Notifiarr/notifiarr/pkg/triggers/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golift.io/starr"
+)
+
+// testApp has wanted lists with the given number of items, paged like the Starr apps.
+func testApp(counts map[string]int) *backlogApp {
+	return &backlogApp{
+		StarrApp: common.StarrApp{App: starr.Sonarr, Instance: 1},
+		wanted: func(_ context.Context, list string, page int) ([]*wantedItem, int, error) {
+			items := []*wantedItem{}
+			for id := (page-1)*wantedPageSize + 1; id <= counts[list] && id <= page*wantedPageSize; id++ {
+				items = append(items, &wantedItem{id: int64(id), title: list})
+			}
+
+			return items, counts[list], nil
+		},
+		search: func(context.Context, []int64) error { return nil },
+	}
+}
+
+func testCmd(runs int) *cmd {
+	config := &Config{Missing: true, Cutoff: true, DryRun: true}
+	_ = config.Validate()
+
+	return &cmd{
+		backlog: config,
+		runs:    runs,
+		state:   &state{Searched: make(map[string]time.Time), Days: make(map[string]*daily), Pages: make(map[string]int)},
+	}
+}
+
+// counts returns the number of items searched in each list.
+func counts(searches []*Search) map[string]int {
+	output := make(map[string]int)
+	for _, search := range searches {
+		output[search.List] += len(search.IDs)
+	}
+
+	return output
+}
+
+func TestSearchAppSplitsBatch(t *testing.T) {
+	t.Parallel()
+
+	app := testApp(map[string]int{ListMissing: 10, ListCutoff: 10})
+
+	assert.Equal(t, map[string]int{ListMissing: 3, ListCutoff: 2}, counts(testCmd(0).searchApp(context.Background(), app, 5)))
+	assert.Equal(t, map[string]int{ListMissing: 2, ListCutoff: 3}, counts(testCmd(1).searchApp(context.Background(), app, 5)),
+		"the other list goes first on the next run")
+	assert.Equal(t, map[string]int{ListCutoff: 1}, counts(testCmd(1).searchApp(context.Background(), app, 1)),
+		"a batch of one alternates between the lists")
+}
+
+func TestSearchAppUnusedShare(t *testing.T) {
+	t.Parallel()
+
+	app := testApp(map[string]int{ListMissing: 1, ListCutoff: 10})
+	assert.Equal(t, map[string]int{ListMissing: 1, ListCutoff: 4}, counts(testCmd(0).searchApp(context.Background(), app, 5)),
+		"cutoff gets what missing did not use")
+
+	app = testApp(map[string]int{ListCutoff: 10})
+	assert.Equal(t, map[string]int{ListCutoff: 5}, counts(testCmd(0).searchApp(context.Background(), app, 5)))
+	assert.Empty(t, testCmd(0).searchApp(context.Background(), testApp(nil), 5), "nothing is wanted")
+}
+
+func TestFindItemsSkipsRecent(t *testing.T) {
+	t.Parallel()
+
+	c := testCmd(0)
+	app := testApp(map[string]int{ListMissing: 60})
+
+	for id := int64(1); id <= wantedPageSize; id++ {
+		c.state.Searched[app.key(id)] = time.Now()
+	}
+
+	c.state.Searched[app.key(52)] = time.Now().Add(-c.backlog.RetryAfter.Duration)
+
+	items, err := c.findItems(context.Background(), app, ListMissing, 3)
+	require.NoError(t, err)
+	require.Len(t, items, 3)
+	assert.Equal(t, []int64{51, 52, 53}, []int64{items[0].id, items[1].id, items[2].id},
+		"items searched before the retry period are skipped")
+}
+
+func TestFindItemsResumes(t *testing.T) {
+	t.Parallel()
+
+	c := testCmd(0)
+	c.backlog.DryRun = false
+	app := testApp(map[string]int{ListMissing: 60})
+
+	for id := int64(1); id <= wantedPageSize; id++ {
+		c.state.Searched[app.key(id)] = time.Now()
+	}
+
+	items, err := c.findItems(context.Background(), app, ListMissing, 3)
+	require.NoError(t, err)
+	require.Len(t, items, 3)
+	assert.Equal(t, 2, c.state.Pages[app.Key()+":"+ListMissing], "the next run starts on the page it stopped on")
+
+	// The first page is searchable again, but the next run continues on the second page.
+	c.state.Searched = map[string]time.Time{app.key(51): time.Now(), app.key(52): time.Now(), app.key(53): time.Now()}
+
+	items, err = c.findItems(context.Background(), app, ListMissing, 3)
+	require.NoError(t, err)
+	require.Len(t, items, 3)
+	assert.EqualValues(t, 54, items[0].id)
+
+	// The end of the list wraps around to the first page.
+	items, err = c.findItems(context.Background(), app, ListMissing, 20)
+	require.NoError(t, err)
+	assert.Len(t, items, 20)
+	assert.EqualValues(t, 54, items[0].id)
+	assert.EqualValues(t, 1, items[7].id)
+}
+
+func TestLimit(t *testing.T) {
+	t.Parallel()
+
+	c := testCmd(0)
+	app := testApp(nil)
+	assert.Equal(t, defaultBatch, c.limit(app))
+
+	c.state.Days[app.Key()] = &daily{Day: today(), Count: c.backlog.MaxPerDay - 1}
+	assert.Equal(t, 1, c.limit(app), "the daily limit is almost reached")
+
+	c.state.Days[app.Key()] = &daily{Day: "2006-01-02", Count: c.backlog.MaxPerDay}
+	assert.Equal(t, defaultBatch, c.limit(app), "yesterday's searches do not count")
+}
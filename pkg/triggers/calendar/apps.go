@@ -0,0 +1,254 @@
+package calendar
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"path"
+	"strconv"
+	"time"
+
+	"github.com/Notifiarr/notifiarr/pkg/apps"
+	"github.com/Notifiarr/notifiarr/pkg/triggers/common"
+	"golift.io/starr"
+	"golift.io/starr/lidarr"
+	"golift.io/starr/radarr"
+	"golift.io/starr/readarr"
+	"golift.io/starr/sonarr"
+)
+
+// calendarApp wraps the different Starr apps, so the calendars can be collected the same way.
+type calendarApp struct {
+	common.StarrApp
+	list func(ctx context.Context, start, end time.Time) ([]*Release, error)
+}
+
+// calendarApps returns all the enabled Starr apps with a calendar.
+func (c *cmd) calendarApps() []*calendarApp {
+	list := []*calendarApp{}
+
+	(&common.StarrApps{
+		Lidarr:  func(instance int, app *apps.LidarrConfig) { list = append(list, c.lidarrCalendar(instance, app)) },
+		Radarr:  func(instance int, app *apps.RadarrConfig) { list = append(list, c.radarrCalendar(instance, app)) },
+		Readarr: func(instance int, app *apps.ReadarrConfig) { list = append(list, c.readarrCalendar(instance, app)) },
+		Sonarr:  func(instance int, app *apps.SonarrConfig) { list = append(list, c.sonarrCalendar(instance, app)) },
+	}).Each(c.Apps)
+
+	return list
+}
+
+// calendarQuery returns the query parameters for a calendar request.
+// include is the parameter that adds the series, artist or author to each item.
+func (c *cmd) calendarQuery(start, end time.Time, include string) url.Values {
+	query := url.Values{
+		"start":       {start.UTC().Format(calendarTimeFormat)},
+		"end":         {end.UTC().Format(calendarTimeFormat)},
+		"unmonitored": {strconv.FormatBool(c.calendar.Unmonitored)},
+	}
+
+	if include != "" {
+		query.Set(include, "true")
+	}
+
+	return query
+}
+
+func (c *cmd) lidarrCalendar(instance int, app *apps.LidarrConfig) *calendarApp {
+	return &calendarApp{
+		StarrApp: common.StarrApp{App: starr.Lidarr, Instance: instance},
+		list: func(ctx context.Context, start, end time.Time) ([]*Release, error) {
+			names, err := common.GetStarrNames(ctx, app, lidarr.APIver)
+			if err != nil {
+				return nil, err //nolint:wrapcheck
+			}
+
+			var albums []*lidarr.Album
+
+			err = app.GetInto(ctx, starr.Request{
+				URI:   path.Join(lidarr.APIver, "calendar"),
+				Query: c.calendarQuery(start, end, "includeArtist"),
+			}, &albums)
+			if err != nil {
+				return nil, fmt.Errorf("getting calendar: %w", err)
+			}
+
+			releases := []*Release{}
+
+			for _, album := range albums {
+				release := &Release{
+					App:       starr.Lidarr,
+					Instance:  instance,
+					Name:      app.Name,
+					Type:      TypeAlbum,
+					Detail:    album.Title,
+					Date:      album.ReleaseDate,
+					Monitored: album.Monitored,
+					HasFile:   album.Statistics != nil && album.Statistics.TrackFileCount > 0,
+					Tags:      []string{},
+				}
+
+				if album.Artist != nil {
+					release.Title = album.Artist.ArtistName
+					release.Profile = names.Profiles[album.Artist.QualityProfileID]
+					release.Tags = names.TagNames(album.Artist.Tags)
+				}
+
+				releases = append(releases, release)
+			}
+
+			return releases, nil
+		},
+	}
+}
+
+func (c *cmd) radarrCalendar(instance int, app *apps.RadarrConfig) *calendarApp {
+	return &calendarApp{
+		StarrApp: common.StarrApp{App: starr.Radarr, Instance: instance},
+		list: func(ctx context.Context, start, end time.Time) ([]*Release, error) {
+			names, err := common.GetStarrNames(ctx, app, radarr.APIver)
+			if err != nil {
+				return nil, err //nolint:wrapcheck
+			}
+
+			var movies []*radarr.Movie
+
+			err = app.GetInto(ctx, starr.Request{
+				URI:   path.Join(radarr.APIver, "calendar"),
+				Query: c.calendarQuery(start, end, ""),
+			}, &movies)
+			if err != nil {
+				return nil, fmt.Errorf("getting calendar: %w", err)
+			}
+
+			releases := []*Release{}
+
+			for _, movie := range movies {
+				// A movie has up to 3 release dates; each one in the calendar range is listed, in this order.
+				for _, date := range []struct {
+					kind string
+					time.Time
+				}{
+					{kind: TypeCinema, Time: movie.InCinemas},
+					{kind: TypeDigital, Time: movie.DigitalRelease},
+					{kind: TypePhysical, Time: movie.PhysicalRelease},
+				} {
+					if date.IsZero() {
+						continue
+					}
+
+					releases = append(releases, &Release{
+						App:       starr.Radarr,
+						Instance:  instance,
+						Name:      app.Name,
+						Type:      date.kind,
+						Title:     movie.Title,
+						Date:      date.Time,
+						Monitored: movie.Monitored,
+						HasFile:   movie.HasFile,
+						Profile:   names.Profiles[movie.QualityProfileID],
+						Tags:      names.TagNames(movie.Tags),
+					})
+				}
+			}
+
+			return releases, nil
+		},
+	}
+}
+
+func (c *cmd) readarrCalendar(instance int, app *apps.ReadarrConfig) *calendarApp {
+	return &calendarApp{
+		StarrApp: common.StarrApp{App: starr.Readarr, Instance: instance},
+		list: func(ctx context.Context, start, end time.Time) ([]*Release, error) {
+			names, err := common.GetStarrNames(ctx, app, readarr.APIver)
+			if err != nil {
+				return nil, err //nolint:wrapcheck
+			}
+
+			var books []*readarr.Book
+
+			err = app.GetInto(ctx, starr.Request{
+				URI:   path.Join(readarr.APIver, "calendar"),
+				Query: c.calendarQuery(start, end, "includeAuthor"),
+			}, &books)
+			if err != nil {
+				return nil, fmt.Errorf("getting calendar: %w", err)
+			}
+
+			releases := []*Release{}
+
+			for _, book := range books {
+				release := &Release{
+					App:       starr.Readarr,
+					Instance:  instance,
+					Name:      app.Name,
+					Type:      TypeBook,
+					Title:     book.AuthorTitle,
+					Detail:    book.Title,
+					Date:      book.ReleaseDate,
+					Monitored: book.Monitored,
+					HasFile:   book.Statistics != nil && book.Statistics.BookFileCount > 0,
+					Tags:      []string{},
+				}
+
+				if book.Author != nil {
+					release.Title = book.Author.AuthorName
+					release.Profile = names.Profiles[int64(book.Author.QualityProfileID)]
+					release.Tags = names.TagNames(book.Author.Tags)
+				}
+
+				releases = append(releases, release)
+			}
+
+			return releases, nil
+		},
+	}
+}
+
+func (c *cmd) sonarrCalendar(instance int, app *apps.SonarrConfig) *calendarApp {
+	return &calendarApp{
+		StarrApp: common.StarrApp{App: starr.Sonarr, Instance: instance},
+		list: func(ctx context.Context, start, end time.Time) ([]*Release, error) {
+			names, err := common.GetStarrNames(ctx, app, sonarr.APIver)
+			if err != nil {
+				return nil, err //nolint:wrapcheck
+			}
+
+			var episodes []*sonarr.Episode
+
+			err = app.GetInto(ctx, starr.Request{
+				URI:   path.Join(sonarr.APIver, "calendar"),
+				Query: c.calendarQuery(start, end, "includeSeries"),
+			}, &episodes)
+			if err != nil {
+				return nil, fmt.Errorf("getting calendar: %w", err)
+			}
+
+			releases := []*Release{}
+
+			for _, episode := range episodes {
+				release := &Release{
+					App:       starr.Sonarr,
+					Instance:  instance,
+					Name:      app.Name,
+					Type:      TypeEpisode,
+					Detail:    fmt.Sprintf("S%02dE%02d %s", episode.SeasonNumber, episode.EpisodeNumber, episode.Title),
+					Date:      episode.AirDateUtc,
+					Monitored: episode.Monitored,
+					HasFile:   episode.HasFile,
+					Tags:      []string{},
+				}
+
+				if episode.Series != nil {
+					release.Title = episode.Series.Title
+					release.Profile = names.Profiles[episode.Series.QualityProfileID]
+					release.Tags = names.TagNames(episode.Series.Tags)
+				}
+
+				releases = append(releases, release)
+			}
+
+			return releases, nil
+		},
+	}
+}
@@ -0,0 +1,292 @@
+package calendar
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Notifiarr/notifiarr/pkg/triggers/common"
+	"github.com/Notifiarr/notifiarr/pkg/triggers/data"
+	"github.com/Notifiarr/notifiarr/pkg/website"
+	"golift.io/cnfg"
+	"golift.io/starr"
+)
+
+/* The calendar digest lists upcoming episodes, movies, albums and books from every Starr app. */
+
+const TrigCalendarDigest common.TriggerName = "Sending Upcoming Releases Digest."
+
+const (
+	// defaultHorizon is how far ahead the digest looks when it's not configured.
+	defaultHorizon = 7 * 24 * time.Hour
+	// maxHorizon keeps the calendar requests to a reasonable size.
+	maxHorizon = 90 * 24 * time.Hour
+	// The calendar API expects this time format.
+	calendarTimeFormat = "2006-01-02T15:04:05.000Z"
+)
+
+// Digest groups.
+const (
+	GroupDay  = "day"
+	GroupWeek = "week"
+)
+
+// Release types.
+const (
+	TypeEpisode  = "episode"
+	TypeCinema   = "cinema"
+	TypeDigital  = "digital"
+	TypePhysical = "physical"
+	TypeAlbum    = "album"
+	TypeBook     = "book"
+)
+
+// ErrInvalidCalendar is returned when the calendar config is not valid.
+var ErrInvalidCalendar = fmt.Errorf("invalid calendar config")
+
+// Config is the [calendar] config section.
+type Config struct {
+	Interval    cnfg.Duration `json:"interval" toml:"interval" xml:"interval" yaml:"interval"` // 0 disables the timer.
+	Horizon     cnfg.Duration `json:"horizon" toml:"horizon" xml:"horizon" yaml:"horizon"`
+	Group       string        `json:"group" toml:"group" xml:"group" yaml:"group"` // day or week.
+	Unmonitored bool          `json:"unmonitored" toml:"unmonitored" xml:"unmonitored" yaml:"unmonitored"`
+	Apps        []string      `json:"apps" toml:"apps" xml:"apps" yaml:"apps"` // radarr, sonarr, lidarr, readarr or radarr1.
+	Tags        []string      `json:"tags" toml:"tags" xml:"tags" yaml:"tags"`
+	Profiles    []string      `json:"qualityProfiles" toml:"quality_profiles" xml:"quality_profiles" yaml:"qualityProfiles"`
+}
+
+// Release is a single upcoming episode, movie release, album or book.
+type Release struct {
+	App       starr.App `json:"app"`
+	Instance  int       `json:"instance"`
+	Name      string    `json:"name"` // instance name.
+	Type      string    `json:"type"`
+	Title     string    `json:"title"`  // series, movie, artist or author.
+	Detail    string    `json:"detail"` // episode, album or book title.
+	Date      time.Time `json:"date"`
+	HasFile   bool      `json:"hasFile"`
+	Monitored bool      `json:"monitored"`
+	Profile   string    `json:"qualityProfile"`
+	Tags      []string  `json:"tags"`
+}
+
+// Period is a day or a week in the digest.
+type Period struct {
+	Start    time.Time  `json:"start"`
+	Releases []*Release `json:"releases"`
+}
+
+// Digest is the upcoming releases calendar for every Starr app.
+type Digest struct {
+	Start   time.Time `json:"start"`
+	End     time.Time `json:"end"`
+	Group   string    `json:"group"`
+	Count   int       `json:"count"`
+	Periods []*Period `json:"periods"`
+	Errors  []string  `json:"errors,omitempty"`
+}
+
+// Action contains the exported methods for this package.
+type Action struct {
+	cmd *cmd
+}
+
+type cmd struct {
+	*common.Config
+	calendar *Config
+}
+
+// New configures the library.
+func New(config *common.Config, calendar *Config) *Action {
+	return &Action{cmd: &cmd{Config: config, calendar: calendar}}
+}
+
+// Create initializes the library.
+func (a *Action) Create() {
+	a.cmd.create()
+}
+
+func (c *cmd) create() {
+	if c.calendar == nil {
+		c.calendar = &Config{}
+	}
+
+	var ticker *time.Ticker
+
+	if c.calendar.Interval.Duration > 0 {
+		ticker = time.NewTicker(c.calendar.Interval.Duration)
+		c.Printf("==> Upcoming Releases Digest timer started, interval:%s horizon:%s group:%s",
+			c.calendar.Interval, c.calendar.horizon(), c.calendar.group())
+	}
+
+	c.Add(&common.Action{
+		Name: TrigCalendarDigest,
+		Fn:   c.sendDigest,
+		C:    make(chan *common.ActionInput, 1),
+		T:    ticker,
+	})
+}
+
+// Validate checks the calendar config for errors.
+func (c *Config) Validate() error {
+	switch c.Group = strings.ToLower(c.Group); c.Group {
+	case "", GroupDay, GroupWeek:
+	default:
+		return fmt.Errorf("%w: group must be %s or %s", ErrInvalidCalendar, GroupDay, GroupWeek)
+	}
+
+	if c.Horizon.Duration > maxHorizon {
+		c.Horizon.Duration = maxHorizon
+	}
+
+	return nil
+}
+
+func (c *Config) horizon() time.Duration {
+	if c.Horizon.Duration <= 0 {
+		return defaultHorizon
+	}
+
+	return c.Horizon.Duration
+}
+
+func (c *Config) group() string {
+	if c.Group == "" {
+		return GroupDay
+	}
+
+	return c.Group
+}
+
+// Send the upcoming releases digest to the website.
+func (a *Action) Send(event website.EventType) {
+	a.cmd.Exec(&common.ActionInput{Type: event}, TrigCalendarDigest)
+}
+
+func (c *cmd) sendDigest(ctx context.Context, input *common.ActionInput) {
+	digest := c.digest(ctx, c.calendar.horizon(), c.calendar.group())
+	data.Save("calendarDigest", digest)
+
+	for _, err := range digest.Errors {
+		c.Errorf("[%s requested] Upcoming Releases Digest: %s", input.Type, err)
+	}
+
+	c.SendData(&website.Request{
+		Route:      website.CalendarRoute,
+		Event:      input.Type,
+		LogPayload: true,
+		LogMsg:     fmt.Sprintf("Upcoming Releases Digest (%d releases in %d %ss)", digest.Count, len(digest.Periods), digest.Group),
+		Payload:    digest,
+	})
+}
+
+// HandleDigest builds and returns the upcoming releases digest.
+// @Summary      Retrieve upcoming releases digest.
+// @Description  Returns upcoming episodes, movie releases, albums and books from every Starr app, grouped by day or week.
+// @Description  Uses the filters in the calendar config.
+// @Tags         Triggers
+// @Produce      json
+// @Param        days  query int    false "number of days to include, default is the configured horizon"
+// @Param        group query string false "day or week, default is the configured group"
+// @Success      200  {object} apps.Respond.apiResponse{message=calendar.Digest} "upcoming releases"
+// @Failure      404  {object} string "bad token or api key"
+// @Router       /api/calendar [get]
+// @Security     ApiKeyAuth
+func (a *Action) HandleDigest(r *http.Request) (int, interface{}) {
+	horizon := a.cmd.calendar.horizon()
+	if days, _ := strconv.Atoi(r.URL.Query().Get("days")); days > 0 {
+		horizon = time.Duration(days) * 24 * time.Hour //nolint:gomnd // hours in a day.
+		if horizon > maxHorizon {
+			horizon = maxHorizon
+		}
+	}
+
+	group := a.cmd.calendar.group()
+	if g := strings.ToLower(r.URL.Query().Get("group")); g == GroupDay || g == GroupWeek {
+		group = g
+	}
+
+	return http.StatusOK, a.cmd.digest(r.Context(), horizon, group)
+}
+
+// digest collects the upcoming releases from every app, and groups them.
+func (c *cmd) digest(ctx context.Context, horizon time.Duration, group string) *Digest {
+	start := time.Now()
+	digest := &Digest{Start: start, End: start.Add(horizon), Group: group, Periods: []*Period{}}
+	releases := []*Release{}
+
+	for _, app := range c.calendarApps() {
+		if !app.Match(c.calendar.Apps) {
+			continue
+		}
+
+		found, err := app.list(ctx, digest.Start, digest.End)
+		if err != nil {
+			digest.Errors = append(digest.Errors, fmt.Sprintf("%s %d: %v", app.App, app.Instance, err))
+			continue
+		}
+
+		for _, release := range found {
+			if release.Date.Before(digest.Start) || release.Date.After(digest.End) ||
+				(!c.calendar.Unmonitored && !release.Monitored) || !c.calendar.matchFilters(release) {
+				continue
+			}
+
+			releases = append(releases, release)
+		}
+	}
+
+	sort.SliceStable(releases, func(i, j int) bool { return releases[i].Date.Before(releases[j].Date) })
+
+	for _, release := range releases {
+		periodStart := periodStart(release.Date, group)
+		if len(digest.Periods) == 0 || !digest.Periods[len(digest.Periods)-1].Start.Equal(periodStart) {
+			digest.Periods = append(digest.Periods, &Period{Start: periodStart})
+		}
+
+		period := digest.Periods[len(digest.Periods)-1]
+		period.Releases = append(period.Releases, release)
+		digest.Count++
+	}
+
+	return digest
+}
+
+// periodStart returns the beginning of the day or week (Monday) for a date, in local time.
+func periodStart(date time.Time, group string) time.Time {
+	date = date.Local()
+	day := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.Local)
+
+	if group == GroupWeek {
+		// Weekday() is 0 on Sunday; weeks start on Monday.
+		day = day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7)) //nolint:gomnd // days in a week.
+	}
+
+	return day
+}
+
+// matchFilters returns true if the release has one of the tags and one of the quality profiles.
+// Empty filters match everything.
+func (c *Config) matchFilters(release *Release) bool {
+	return matchAny(c.Tags, release.Tags...) && matchAny(c.Profiles, release.Profile)
+}
+
+func matchAny(filter []string, values ...string) bool {
+	if len(filter) == 0 {
+		return true
+	}
+
+	for _, want := range filter {
+		for _, value := range values {
+			if strings.EqualFold(want, value) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
@@ -3,6 +3,7 @@ package cfsync
 import (
 	"math/rand"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/Notifiarr/notifiarr/pkg/apps"
@@ -20,10 +21,12 @@ const (
 )
 
 // New configures the library.
-func New(config *common.Config) *Action {
+func New(config *common.Config, local *LocalConfig) *Action {
 	return &Action{
 		cmd: &cmd{
-			Config: config,
+			Config:   config,
+			local:    local,
+			previews: make(map[*common.ActionInput]chan *LocalReport),
 		},
 	}
 }
@@ -35,6 +38,10 @@ type Action struct {
 
 type cmd struct {
 	*common.Config
+	local     *LocalConfig
+	lastLocal *LocalReport
+	previews  map[*common.ActionInput]chan *LocalReport // dry runs requested from the API, waiting for a report.
+	localMu   sync.RWMutex
 }
 
 // Create initializes the library.
@@ -44,6 +51,7 @@ func (a *Action) Create() {
 
 func (c *cmd) create() {
 	ci := clientinfo.Get()
+	c.createLocal()
 	c.setupRadarr(ci)
 	c.setupSonarr(ci)
 
@@ -0,0 +1,546 @@
+package cfsync
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Notifiarr/notifiarr/pkg/triggers/common"
+	"github.com/Notifiarr/notifiarr/pkg/triggers/data"
+	"github.com/Notifiarr/notifiarr/pkg/website"
+	"golift.io/cnfg"
+	"golift.io/cnfgfile"
+	"golift.io/starr"
+)
+
+/* Local TRaSH Sync applies custom formats, scores and release profiles from a local copy of the
+   TRaSH guides JSON files, instead of the data provided by the website. A mapping file selects the
+   formats and release profiles, and the quality profiles they are scored in.
+*/
+
+const TrigCFSyncLocal common.TriggerName = "Starting Local TRaSH Profiles sync."
+
+// ErrInvalidLocal is returned when the local TRaSH sync config is not valid.
+var ErrInvalidLocal = fmt.Errorf("invalid local trash sync config")
+
+// This is the trash_scores key used when a mapping does not provide one.
+const defaultScoreSet = "default"
+
+// Changes made to an instance by the local TRaSH sync.
+const (
+	LocalCreate = "create"
+	LocalUpdate = "update"
+	LocalError  = "error"
+)
+
+// Local TRaSH sync item types.
+const (
+	TypeCustomFormat   = "customformat"
+	TypeQualityProfile = "qualityprofile"
+	TypeReleaseProfile = "releaseprofile"
+)
+
+// LocalConfig is the [trash_local] config section.
+type LocalConfig struct {
+	Interval cnfg.Duration `json:"interval" toml:"interval" xml:"interval" yaml:"interval"` // 0 disables the timer.
+	Path     string        `json:"path" toml:"path" xml:"path" yaml:"path"`                 // TRaSH guides checkout or json folder.
+	Mapping  string        `json:"mapping" toml:"mapping" xml:"mapping" yaml:"mapping"`     // json, toml, xml or yaml file.
+	Pull     bool          `json:"pull" toml:"pull" xml:"pull" yaml:"pull"`                 // run git pull in path first.
+	DryRun   bool          `json:"dryRun" toml:"dry_run" xml:"dry_run" yaml:"dryRun"`
+}
+
+// Mapping is the local TRaSH sync mapping file.
+type Mapping struct {
+	Radarr []*MapProfile `json:"radarr" toml:"radarr" xml:"radarr" yaml:"radarr"`
+	Sonarr []*MapProfile `json:"sonarr" toml:"sonarr" xml:"sonarr" yaml:"sonarr"`
+}
+
+// MapProfile selects custom formats and release profiles, and the quality profiles the formats are scored in.
+type MapProfile struct {
+	Instances       []int        `json:"instances" toml:"instances" xml:"instances" yaml:"instances"` // empty is all.
+	Profiles        []string     `json:"qualityProfiles" toml:"quality_profiles" xml:"quality_profile" yaml:"qualityProfiles"`
+	ScoreSet        string       `json:"scoreSet" toml:"score_set" xml:"score_set" yaml:"scoreSet"` // trash_scores key.
+	Formats         []*MapFormat `json:"customFormats" toml:"custom_format" xml:"custom_format" yaml:"customFormats"`
+	ReleaseProfiles []string     `json:"releaseProfiles" toml:"release_profiles" xml:"release_profile" yaml:"releaseProfiles"`
+}
+
+// MapFormat is a custom format in a mapping, by trash ID or name. Score overrides the TRaSH score.
+type MapFormat struct {
+	TrashID string `json:"trashId" toml:"trash_id" xml:"trash_id" yaml:"trashId"`
+	Name    string `json:"name" toml:"name" xml:"name" yaml:"name"`
+	Score   *int64 `json:"score" toml:"score" xml:"score" yaml:"score"`
+}
+
+// LocalChange is a change made (or that would be made, in dry run mode) to an instance.
+type LocalChange struct {
+	App      starr.App `json:"app"`
+	Instance int       `json:"instance"`
+	Type     string    `json:"type"`
+	Name     string    `json:"name"`
+	Action   string    `json:"action"`
+	Details  []string  `json:"details,omitempty"`
+	Error    string    `json:"error,omitempty"`
+}
+
+// LocalReport is the result of running the local TRaSH sync once.
+type LocalReport struct {
+	Start   time.Time      `json:"start"`
+	Elapsed cnfg.Duration  `json:"elapsed"`
+	DryRun  bool           `json:"dryRun"`
+	Changes []*LocalChange `json:"changes"`
+	Errors  []string       `json:"errors,omitempty"`
+}
+
+// trashFormat is a custom format file from the TRaSH guides.
+type trashFormat struct {
+	TrashID               string           `json:"trash_id"`
+	Scores                map[string]int64 `json:"trash_scores"`
+	Name                  string           `json:"name"`
+	IncludeCFWhenRenaming bool             `json:"includeCustomFormatWhenRenaming"`
+	Specifications        []*trashSpec     `json:"specifications"`
+}
+
+// trashSpec is a custom format specification from the TRaSH guides.
+type trashSpec struct {
+	Name           string      `json:"name"`
+	Implementation string      `json:"implementation"`
+	Negate         bool        `json:"negate"`
+	Required       bool        `json:"required"`
+	Fields         trashFields `json:"fields"`
+}
+
+// trashFields are stored as an object in the TRaSH guides, and as a list of name/value pairs in the Starr apps.
+type trashFields []*starr.FieldInput
+
+// trashRelease is a Sonarr v3 release profile file from the TRaSH guides.
+type trashRelease struct {
+	TrashID         string            `json:"trash_id"`
+	Name            string            `json:"name"`
+	IncPrefOnRename *bool             `json:"includePreferredWhenRenaming"`
+	Required        trashTerms        `json:"required"`
+	Ignored         trashTerms        `json:"ignored"`
+	Preferred       []*trashPreferred `json:"preferred"`
+}
+
+// trashPreferred is a group of preferred terms with one score.
+type trashPreferred struct {
+	Score int        `json:"score"`
+	Terms trashTerms `json:"terms"`
+}
+
+// trashTerms are a list of strings, or a list of objects with a term, depending on the guide version.
+type trashTerms []string
+
+// trashData is the TRaSH guide data for one app, keyed by trash ID and by lower case name.
+type trashData struct {
+	formats  map[string]*trashFormat
+	releases map[string]*trashRelease
+}
+
+// UnmarshalJSON turns a TRaSH fields object into a list of fields.
+func (t *trashFields) UnmarshalJSON(b []byte) error {
+	var list []*starr.FieldInput
+	if err := json.Unmarshal(b, &list); err == nil {
+		*t = list
+		return nil
+	}
+
+	var fields map[string]interface{}
+	if err := json.Unmarshal(b, &fields); err != nil {
+		return fmt.Errorf("decoding fields: %w", err)
+	}
+
+	*t = make(trashFields, 0, len(fields))
+
+	for name, value := range fields {
+		*t = append(*t, &starr.FieldInput{Name: name, Value: value})
+	}
+
+	sort.Slice(*t, func(i, j int) bool { return (*t)[i].Name < (*t)[j].Name })
+
+	return nil
+}
+
+// UnmarshalJSON accepts a list of strings, or a list of objects with a term.
+func (t *trashTerms) UnmarshalJSON(b []byte) error {
+	var list []string
+	if err := json.Unmarshal(b, &list); err == nil {
+		*t = list
+		return nil
+	}
+
+	var terms []struct {
+		Term string `json:"term"`
+	}
+
+	if err := json.Unmarshal(b, &terms); err != nil {
+		return fmt.Errorf("decoding terms: %w", err)
+	}
+
+	*t = make(trashTerms, len(terms))
+	for idx, term := range terms {
+		(*t)[idx] = term.Term
+	}
+
+	return nil
+}
+
+// Validate checks the local TRaSH sync config for errors.
+func (l *LocalConfig) Validate() error {
+	switch {
+	case l.Path == "" && l.Mapping == "":
+		return nil
+	case l.Path == "":
+		return fmt.Errorf("%w: path is required with a mapping file", ErrInvalidLocal)
+	case l.Mapping == "":
+		return fmt.Errorf("%w: mapping is required with a path", ErrInvalidLocal)
+	default:
+		return nil
+	}
+}
+
+// enabled returns true if the local TRaSH sync is configured.
+func (l *LocalConfig) enabled() bool {
+	return l != nil && l.Path != "" && l.Mapping != ""
+}
+
+// has returns true if the mapping applies to an instance.
+func (m *MapProfile) has(instance int) bool {
+	if len(m.Instances) == 0 {
+		return true
+	}
+
+	for _, i := range m.Instances {
+		if i == instance {
+			return true
+		}
+	}
+
+	return false
+}
+
+// score returns the score for a format in a mapping.
+func (m *MapProfile) score(mapped *MapFormat, format *trashFormat) int64 {
+	if mapped.Score != nil {
+		return *mapped.Score
+	}
+
+	if m.ScoreSet != "" {
+		if score, ok := format.Scores[m.ScoreSet]; ok {
+			return score
+		}
+	}
+
+	return format.Scores[defaultScoreSet]
+}
+
+func (c *cmd) createLocal() {
+	if !c.local.enabled() {
+		return
+	}
+
+	var ticker *time.Ticker
+
+	if c.local.Interval.Duration > 0 {
+		ticker = time.NewTicker(c.local.Interval.Duration)
+		c.Printf("==> Local TRaSH Sync: interval: %s, path: %s, mapping: %s, dry_run: %v",
+			c.local.Interval, c.local.Path, c.local.Mapping, c.local.DryRun)
+	}
+
+	c.Add(&common.Action{
+		Name: TrigCFSyncLocal,
+		Fn:   c.syncLocal,
+		C:    make(chan *common.ActionInput, 1),
+		T:    ticker,
+	})
+}
+
+// SyncLocal runs the local TRaSH sync now. Returns false if it is not configured.
+func (a *Action) SyncLocal(event website.EventType) bool {
+	if !a.cmd.local.enabled() {
+		return false
+	}
+
+	return a.cmd.Exec(&common.ActionInput{Type: event}, TrigCFSyncLocal)
+}
+
+func (c *cmd) syncLocal(ctx context.Context, input *common.ActionInput) {
+	c.localMu.Lock()
+	preview := c.previews[input]
+	delete(c.previews, input)
+	c.localMu.Unlock()
+
+	if preview != nil {
+		preview <- c.runLocal(ctx, true) // buffered, the requester may be gone.
+		return
+	}
+
+	report := c.runLocal(ctx, c.local.DryRun)
+
+	c.localMu.Lock()
+	c.lastLocal = report
+	c.localMu.Unlock()
+	data.Save("trashLocal", report)
+
+	for _, err := range report.Errors {
+		c.Errorf("[%s requested] Local TRaSH Sync: %s", input.Type, err)
+	}
+
+	if len(report.Changes) == 0 && len(report.Errors) == 0 {
+		return
+	}
+
+	c.Printf("[%s requested] Local TRaSH Sync: %d changes, %d errors, dry run: %v, elapsed: %v",
+		input.Type, len(report.Changes), len(report.Errors), report.DryRun, report.Elapsed)
+
+	c.SendData(&website.Request{
+		Route:      website.TrashLocalRoute,
+		Event:      input.Type,
+		LogPayload: true,
+		LogMsg:     fmt.Sprintf("Local TRaSH Sync (%d changes)", len(report.Changes)),
+		Payload:    report,
+	})
+}
+
+// runLocal loads the mapping and guide files, and applies them to every mapped instance.
+// Dry runs do not pull, so they do not change the guide files either.
+func (c *cmd) runLocal(ctx context.Context, dryRun bool) *LocalReport {
+	report := &LocalReport{Start: time.Now(), DryRun: dryRun, Changes: []*LocalChange{}}
+	defer func() { report.Elapsed.Duration = time.Since(report.Start).Round(time.Millisecond) }()
+
+	if c.local.Pull && !dryRun {
+		if err := c.gitPull(ctx); err != nil {
+			// The files that are already there are still used.
+			report.Errors = append(report.Errors, err.Error())
+		}
+	}
+
+	var mapping Mapping
+	if err := cnfgfile.Unmarshal(&mapping, c.local.Mapping); err != nil {
+		report.Errors = append(report.Errors, fmt.Sprintf("loading mapping file: %v", err))
+		return report
+	}
+
+	if len(mapping.Radarr) > 0 {
+		guide, err := c.loadTrash("radarr")
+		if err != nil {
+			report.Errors = append(report.Errors, err.Error())
+		} else {
+			c.localRadarr(ctx, guide, mapping.Radarr, report)
+		}
+	}
+
+	if len(mapping.Sonarr) > 0 {
+		guide, err := c.loadTrash("sonarr")
+		if err != nil {
+			report.Errors = append(report.Errors, err.Error())
+		} else {
+			c.localSonarr(ctx, guide, mapping.Sonarr, report)
+		}
+	}
+
+	return report
+}
+
+// gitPull updates a local git checkout of the TRaSH guides.
+func (c *cmd) gitPull(ctx context.Context) error {
+	var stderr bytes.Buffer
+
+	cmd := exec.CommandContext(ctx, "git", "-C", c.local.Path, "pull", "--ff-only", "--quiet")
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("git pull in %s: %w: %s", c.local.Path, err, strings.TrimSpace(stderr.String()))
+	}
+
+	return nil
+}
+
+// trashDir finds a folder of TRaSH guide files for an app in the configured path.
+// The path may be a guides checkout, the json folder, or a folder with only json files.
+func (c *cmd) trashDir(app, kind string) string {
+	for _, dir := range []string{
+		filepath.Join(c.local.Path, "docs", "json", app, kind),
+		filepath.Join(c.local.Path, "json", app, kind),
+		filepath.Join(c.local.Path, app, kind),
+		filepath.Join(c.local.Path, kind),
+	} {
+		if info, err := os.Stat(dir); err == nil && info.IsDir() {
+			return dir
+		}
+	}
+
+	if kind == "cf" {
+		return c.local.Path
+	}
+
+	return ""
+}
+
+// loadTrash reads the custom format and release profile files for an app.
+func (c *cmd) loadTrash(app string) (*trashData, error) {
+	guide := &trashData{formats: make(map[string]*trashFormat), releases: make(map[string]*trashRelease)}
+
+	if err := readTrash(c.trashDir(app, "cf"), func(file []byte) error {
+		var format trashFormat
+		if err := json.Unmarshal(file, &format); err != nil || format.Name == "" {
+			return err
+		}
+
+		guide.formats[strings.ToLower(format.Name)] = &format
+		if format.TrashID != "" {
+			guide.formats[format.TrashID] = &format
+		}
+
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("reading %s custom formats: %w", app, err)
+	}
+
+	if dir := c.trashDir(app, "rp"); dir != "" {
+		if err := readTrash(dir, func(file []byte) error {
+			var release trashRelease
+			if err := json.Unmarshal(file, &release); err != nil || release.Name == "" {
+				return err
+			}
+
+			guide.releases[strings.ToLower(release.Name)] = &release
+			if release.TrashID != "" {
+				guide.releases[release.TrashID] = &release
+			}
+
+			return nil
+		}); err != nil {
+			return nil, fmt.Errorf("reading %s release profiles: %w", app, err)
+		}
+	}
+
+	return guide, nil
+}
+
+// readTrash passes every json file in a folder to a parser.
+func readTrash(dir string, parse func([]byte) error) error {
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return fmt.Errorf("finding files: %w", err)
+	}
+
+	for _, name := range files {
+		file, err := os.ReadFile(name)
+		if err != nil {
+			return fmt.Errorf("reading file: %w", err)
+		}
+
+		if err := parse(file); err != nil {
+			return fmt.Errorf("%s: %w", filepath.Base(name), err)
+		}
+	}
+
+	return nil
+}
+
+// format finds a mapped custom format in the guide data.
+func (t *trashData) format(mapped *MapFormat) *trashFormat {
+	if format := t.formats[mapped.TrashID]; mapped.TrashID != "" && format != nil {
+		return format
+	}
+
+	return t.formats[strings.ToLower(mapped.Name)]
+}
+
+// release finds a mapped release profile, by trash ID or name, in the guide data.
+func (t *trashData) release(name string) *trashRelease {
+	if release := t.releases[name]; release != nil {
+		return release
+	}
+
+	return t.releases[strings.ToLower(name)]
+}
+
+// changedFields returns the top level json fields that differ in two values.
+func changedFields(want, have interface{}) []string {
+	var wantMap, haveMap map[string]interface{}
+
+	wantJSON, _ := json.Marshal(want)
+	haveJSON, _ := json.Marshal(have)
+	_ = json.Unmarshal(wantJSON, &wantMap)
+	_ = json.Unmarshal(haveJSON, &haveMap)
+
+	output := []string{}
+
+	for key, value := range wantMap {
+		if key == "id" {
+			continue
+		}
+
+		valueJSON, _ := json.Marshal(value)
+		if haveJSON, _ := json.Marshal(haveMap[key]); !bytes.Equal(valueJSON, haveJSON) {
+			output = append(output, key)
+		}
+	}
+
+	sort.Strings(output)
+
+	return output
+}
+
+// HandleLocal returns the last local TRaSH sync report, or runs a dry run now.
+// @Summary      Retrieve or preview a local TRaSH sync.
+// @Description  Returns the report from the last local TRaSH sync.
+// @Description  With dryRun=true the sync runs now without making changes, and the report shows the changes it would make.
+// @Tags         TRaSH
+// @Produce      json
+// @Param        dryRun  query  bool  false "run a dry run now"
+// @Success      200  {object} apps.Respond.apiResponse{message=cfsync.LocalReport} "sync report"
+// @Failure      501  {object} apps.Respond.apiResponse{message=string} "local trash sync not configured"
+// @Failure      503  {object} apps.Respond.apiResponse{message=string} "triggers are not running"
+// @Failure      404  {object} string "bad token or api key"
+// @Router       /api/trash/local [get]
+// @Security     ApiKeyAuth
+func (a *Action) HandleLocal(req *http.Request) (int, interface{}) {
+	if !a.cmd.local.enabled() {
+		return http.StatusNotImplemented, "Local TRaSH sync is not configured."
+	}
+
+	if req.URL.Query().Get("dryRun") == "true" {
+		return a.cmd.previewLocal(req.Context())
+	}
+
+	a.cmd.localMu.RLock()
+	defer a.cmd.localMu.RUnlock()
+
+	return http.StatusOK, a.cmd.lastLocal
+}
+
+// previewLocal runs a dry run through the trigger channel, so it never runs at the same time as a sync.
+func (c *cmd) previewLocal(ctx context.Context) (int, interface{}) {
+	input := &common.ActionInput{Type: website.EventAPI}
+	reply := make(chan *LocalReport, 1)
+
+	c.localMu.Lock()
+	c.previews[input] = reply
+	c.localMu.Unlock()
+
+	if !c.Exec(input, TrigCFSyncLocal) {
+		c.localMu.Lock()
+		delete(c.previews, input)
+		c.localMu.Unlock()
+
+		return http.StatusServiceUnavailable, "Triggers are not running."
+	}
+
+	select {
+	case report := <-reply:
+		return http.StatusOK, report
+	case <-ctx.Done():
+		return http.StatusRequestTimeout, ctx.Err().Error()
+	}
+}
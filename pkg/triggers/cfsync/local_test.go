@@ -0,0 +1,64 @@
+package cfsync
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTrashFieldsUnmarshal(t *testing.T) {
+	t.Parallel()
+
+	assert := assert.New(t)
+
+	var fields trashFields
+
+	// The guide has an object of field names and values.
+	assert.NoError(json.Unmarshal([]byte(`{"value": 5, "exceptLanguage": false}`), &fields))
+	assert.Equal(trashFields{{Name: "exceptLanguage", Value: false}, {Name: "value", Value: float64(5)}}, fields)
+
+	// The Starr apps have a list.
+	fields = nil
+	assert.NoError(json.Unmarshal([]byte(`[{"name": "value", "value": "\\bHDR\\b"}]`), &fields))
+	assert.Equal(trashFields{{Name: "value", Value: `\bHDR\b`}}, fields)
+
+	fields = nil
+	assert.NoError(json.Unmarshal([]byte(`{}`), &fields))
+	assert.Equal(trashFields{}, fields)
+
+	assert.Error(json.Unmarshal([]byte(`"value"`), &fields))
+}
+
+func TestTrashTermsUnmarshal(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]trashTerms{
+		`["/\\bx265\\b/i", "HEVC"]`:                               {`/\bx265\b/i`, "HEVC"},
+		`[{"trash_id": "abc", "term": "HEVC"}, {"term": "x265"}]`: {"HEVC", "x265"},
+		`[]`: {},
+	}
+
+	for input, want := range tests {
+		var terms trashTerms
+		if assert.NoError(t, json.Unmarshal([]byte(input), &terms), input) {
+			assert.Equal(t, want, terms, input)
+		}
+	}
+
+	var terms trashTerms
+	assert.Error(t, json.Unmarshal([]byte(`{"term": "HEVC"}`), &terms), "terms must be a list")
+}
+
+// Make sure the guide fields are sent to the Starr apps as a list.
+func TestTrashFieldsMarshal(t *testing.T) {
+	t.Parallel()
+
+	var fields trashFields
+
+	assert.NoError(t, json.Unmarshal([]byte(`{"value": 1}`), &fields))
+
+	body, err := json.Marshal(fields)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `[{"name": "value", "value": 1}]`, string(body))
+}
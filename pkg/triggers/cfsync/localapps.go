@@ -0,0 +1,503 @@
+//nolint:dupl
+package cfsync
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Notifiarr/notifiarr/pkg/apps"
+	"golift.io/starr"
+	"golift.io/starr/radarr"
+	"golift.io/starr/sonarr"
+)
+
+/* The code in this file applies the local TRaSH guide data to Radarr and Sonarr instances. */
+
+// compareFormat is the part of a custom format that is compared to find changes.
+type compareFormat struct {
+	Name                  string       `json:"name"`
+	IncludeCFWhenRenaming bool         `json:"includeCustomFormatWhenRenaming"`
+	Specifications        []*trashSpec `json:"specifications"`
+}
+
+// convert copies one format or profile type into another using their json tags.
+func convert(from, into interface{}) {
+	b, _ := json.Marshal(from)
+	_ = json.Unmarshal(b, into)
+}
+
+// toCompare returns the comparable part of a TRaSH, Radarr or Sonarr custom format.
+func toCompare(format interface{}) *compareFormat {
+	var output compareFormat
+
+	convert(format, &output)
+	sort.SliceStable(output.Specifications, func(i, j int) bool {
+		return output.Specifications[i].Name < output.Specifications[j].Name
+	})
+
+	return &output
+}
+
+// localRun holds the data for applying the guide data to one instance.
+type localRun struct {
+	app      starr.App
+	instance int
+	dryRun   bool
+	guide    *trashData
+	maps     []*MapProfile
+	report   *LocalReport
+	formats  map[string]int64 // lower case name -> custom format ID.
+	missing  map[string]bool  // mapped formats not found in the guide data; only reported once.
+}
+
+func (r *localRun) change(kind, name, action string, details ...string) *LocalChange {
+	change := &LocalChange{App: r.app, Instance: r.instance, Type: kind, Name: name, Action: action, Details: details}
+	r.report.Changes = append(r.report.Changes, change)
+
+	return change
+}
+
+func (r *localRun) failed(change *LocalChange, err error) {
+	change.Action, change.Error = LocalError, err.Error()
+}
+
+func (r *localRun) errorf(format string, args ...interface{}) {
+	r.report.Errors = append(r.report.Errors,
+		fmt.Sprintf("%s %d: ", r.app, r.instance)+fmt.Sprintf(format, args...))
+}
+
+// mappedFormats returns every custom format in the mappings for this instance, once.
+func (r *localRun) mappedFormats() []*trashFormat {
+	output := []*trashFormat{}
+	done := make(map[*trashFormat]bool)
+
+	for _, mapping := range r.maps {
+		for _, mapped := range mapping.Formats {
+			switch format := r.guide.format(mapped); {
+			case format == nil && !r.missing[mapped.TrashID+mapped.Name]:
+				r.missing[mapped.TrashID+mapped.Name] = true
+				r.errorf("custom format not found in TRaSH files: %s%s", mapped.TrashID, mapped.Name)
+			case format != nil && !done[format]:
+				done[format] = true
+				output = append(output, format)
+			}
+		}
+	}
+
+	return output
+}
+
+// scores returns the wanted score for every mapped format, for each quality profile name.
+func (r *localRun) scores() map[string]map[*trashFormat]int64 {
+	output := make(map[string]map[*trashFormat]int64)
+
+	for _, mapping := range r.maps {
+		for _, name := range mapping.Profiles {
+			name = strings.ToLower(name)
+			if output[name] == nil {
+				output[name] = make(map[*trashFormat]int64)
+			}
+
+			for _, mapped := range mapping.Formats {
+				if format := r.guide.format(mapped); format != nil {
+					output[name][format] = mapping.score(mapped, format)
+				}
+			}
+		}
+	}
+
+	return output
+}
+
+// setScores updates the format items in a quality profile, and returns the changes.
+func (r *localRun) setScores(items *[]*starr.FormatItem, scores map[*trashFormat]int64) []string {
+	details := []string{}
+
+	for format, score := range scores {
+		var item *starr.FormatItem
+
+		for _, have := range *items {
+			if id, ok := r.formats[strings.ToLower(format.Name)]; (ok && have.Format == id) ||
+				(!ok && strings.EqualFold(have.Name, format.Name)) {
+				item = have
+			}
+		}
+
+		switch {
+		case item == nil:
+			// New formats have no ID in dry run mode. The app adds them to every profile with a score of 0.
+			details = append(details, fmt.Sprintf("%s: 0 -> %d", format.Name, score))
+
+			if id, ok := r.formats[strings.ToLower(format.Name)]; ok {
+				*items = append(*items, &starr.FormatItem{Format: id, Name: format.Name, Score: score})
+			}
+		case item.Score != score:
+			details = append(details, fmt.Sprintf("%s: %d -> %d", format.Name, item.Score, score))
+			item.Score = score
+		}
+	}
+
+	sort.Strings(details)
+
+	return details
+}
+
+// mapsFor returns the mappings for an instance.
+func mapsFor(mapping []*MapProfile, instance int) []*MapProfile {
+	output := []*MapProfile{}
+
+	for _, m := range mapping {
+		if m != nil && m.has(instance) {
+			output = append(output, m)
+		}
+	}
+
+	return output
+}
+
+func (c *cmd) localRadarr(ctx context.Context, guide *trashData, mapping []*MapProfile, report *LocalReport) {
+	for idx, app := range c.Apps.Radarr {
+		run := &localRun{
+			app:      starr.Radarr,
+			instance: idx + 1,
+			dryRun:   report.DryRun,
+			guide:    guide,
+			maps:     mapsFor(mapping, idx+1),
+			report:   report,
+			formats:  make(map[string]int64),
+			missing:  make(map[string]bool),
+		}
+
+		if app.Enabled() && len(run.maps) > 0 {
+			run.radarr(ctx, app)
+		}
+	}
+}
+
+func (r *localRun) radarr(ctx context.Context, app *apps.RadarrConfig) {
+	formats, err := app.GetCustomFormatsContext(ctx)
+	if err != nil {
+		r.errorf("getting custom formats: %v", err)
+		return
+	}
+
+	existing := make(map[string]*radarr.CustomFormatOutput)
+	for _, format := range formats {
+		existing[strings.ToLower(format.Name)] = format
+		r.formats[strings.ToLower(format.Name)] = format.ID
+	}
+
+	for _, format := range r.mappedFormats() {
+		r.radarrFormat(ctx, app, format, existing[strings.ToLower(format.Name)])
+	}
+
+	// Get the profiles after adding formats; the app adds new formats to every profile.
+	profiles, err := app.GetQualityProfilesContext(ctx)
+	if err != nil {
+		r.errorf("getting quality profiles: %v", err)
+		return
+	}
+
+	scores := r.scores()
+
+	for _, profile := range profiles {
+		if want, ok := scores[strings.ToLower(profile.Name)]; ok {
+			delete(scores, strings.ToLower(profile.Name))
+			r.radarrProfile(ctx, app, profile, want)
+		}
+	}
+
+	for name := range scores {
+		r.errorf("quality profile not found: %s", name)
+	}
+}
+
+func (r *localRun) radarrFormat(
+	ctx context.Context,
+	app *apps.RadarrConfig,
+	format *trashFormat,
+	have *radarr.CustomFormatOutput,
+) {
+	var input radarr.CustomFormatInput
+
+	convert(format, &input)
+
+	if have == nil {
+		change := r.change(TypeCustomFormat, format.Name, LocalCreate)
+		if r.dryRun {
+			return
+		}
+
+		output, err := app.AddCustomFormatContext(ctx, &input)
+		if err != nil {
+			r.failed(change, err)
+			return
+		}
+
+		r.formats[strings.ToLower(format.Name)] = output.ID
+
+		return
+	}
+
+	fields := changedFields(toCompare(format), toCompare(have))
+	if len(fields) == 0 {
+		return
+	}
+
+	change := r.change(TypeCustomFormat, format.Name, LocalUpdate, fields...)
+	if r.dryRun {
+		return
+	}
+
+	input.ID = have.ID
+	if _, err := app.UpdateCustomFormatContext(ctx, &input); err != nil {
+		r.failed(change, err)
+	}
+}
+
+func (r *localRun) radarrProfile(
+	ctx context.Context,
+	app *apps.RadarrConfig,
+	profile *radarr.QualityProfile,
+	scores map[*trashFormat]int64,
+) {
+	details := r.setScores(&profile.FormatItems, scores)
+	if len(details) == 0 {
+		return
+	}
+
+	change := r.change(TypeQualityProfile, profile.Name, LocalUpdate, details...)
+	if r.dryRun {
+		return
+	}
+
+	if _, err := app.UpdateQualityProfileContext(ctx, profile); err != nil {
+		r.failed(change, err)
+	}
+}
+
+func (c *cmd) localSonarr(ctx context.Context, guide *trashData, mapping []*MapProfile, report *LocalReport) {
+	for idx, app := range c.Apps.Sonarr {
+		run := &localRun{
+			app:      starr.Sonarr,
+			instance: idx + 1,
+			dryRun:   report.DryRun,
+			guide:    guide,
+			maps:     mapsFor(mapping, idx+1),
+			report:   report,
+			formats:  make(map[string]int64),
+			missing:  make(map[string]bool),
+		}
+
+		if app.Enabled() && len(run.maps) > 0 {
+			run.sonarr(ctx, app)
+		}
+	}
+}
+
+func (r *localRun) sonarr(ctx context.Context, app *apps.SonarrConfig) {
+	r.sonarrReleases(ctx, app)
+
+	formats := r.mappedFormats()
+	if len(formats) == 0 {
+		return
+	}
+
+	current, err := app.GetCustomFormatsContext(ctx)
+	if errors.Is(err, starr.ErrInvalidStatusCode) {
+		r.errorf("custom formats require Sonarr v4: %v", err)
+		return
+	} else if err != nil {
+		r.errorf("getting custom formats: %v", err)
+		return
+	}
+
+	existing := make(map[string]*sonarr.CustomFormat)
+	for _, format := range current {
+		existing[strings.ToLower(format.Name)] = format
+		r.formats[strings.ToLower(format.Name)] = int64(format.ID)
+	}
+
+	for _, format := range formats {
+		r.sonarrFormat(ctx, app, format, existing[strings.ToLower(format.Name)])
+	}
+
+	// Get the profiles after adding formats; the app adds new formats to every profile.
+	profiles, err := app.GetQualityProfilesContext(ctx)
+	if err != nil {
+		r.errorf("getting quality profiles: %v", err)
+		return
+	}
+
+	scores := r.scores()
+
+	for _, profile := range profiles {
+		if want, ok := scores[strings.ToLower(profile.Name)]; ok {
+			delete(scores, strings.ToLower(profile.Name))
+			r.sonarrProfile(ctx, app, profile, want)
+		}
+	}
+
+	for name := range scores {
+		r.errorf("quality profile not found: %s", name)
+	}
+}
+
+func (r *localRun) sonarrFormat(ctx context.Context, app *apps.SonarrConfig, format *trashFormat, have *sonarr.CustomFormat) {
+	var input sonarr.CustomFormat
+
+	convert(format, &input)
+
+	if have == nil {
+		change := r.change(TypeCustomFormat, format.Name, LocalCreate)
+		if r.dryRun {
+			return
+		}
+
+		output, err := app.AddCustomFormatContext(ctx, &input)
+		if err != nil {
+			r.failed(change, err)
+			return
+		}
+
+		r.formats[strings.ToLower(format.Name)] = int64(output.ID)
+
+		return
+	}
+
+	fields := changedFields(toCompare(format), toCompare(have))
+	if len(fields) == 0 {
+		return
+	}
+
+	change := r.change(TypeCustomFormat, format.Name, LocalUpdate, fields...)
+	if r.dryRun {
+		return
+	}
+
+	input.ID = have.ID
+	if _, err := app.UpdateCustomFormatContext(ctx, &input, have.ID); err != nil {
+		r.failed(change, err)
+	}
+}
+
+func (r *localRun) sonarrProfile(
+	ctx context.Context,
+	app *apps.SonarrConfig,
+	profile *sonarr.QualityProfile,
+	scores map[*trashFormat]int64,
+) {
+	details := r.setScores(&profile.FormatItems, scores)
+	if len(details) == 0 {
+		return
+	}
+
+	change := r.change(TypeQualityProfile, profile.Name, LocalUpdate, details...)
+	if r.dryRun {
+		return
+	}
+
+	if _, err := app.UpdateQualityProfileContext(ctx, profile); err != nil {
+		r.failed(change, err)
+	}
+}
+
+// sonarrReleases creates and updates the mapped release profiles. These are for Sonarr v3.
+func (r *localRun) sonarrReleases(ctx context.Context, app *apps.SonarrConfig) {
+	releases := []*trashRelease{}
+	done := make(map[*trashRelease]bool)
+
+	for _, mapping := range r.maps {
+		for _, name := range mapping.ReleaseProfiles {
+			if release := r.guide.release(name); release == nil {
+				r.errorf("release profile not found in TRaSH files: %s", name)
+			} else if !done[release] {
+				done[release] = true
+				releases = append(releases, release)
+			}
+		}
+	}
+
+	if len(releases) == 0 {
+		return
+	}
+
+	current, err := app.GetReleaseProfilesContext(ctx)
+	if err != nil {
+		r.errorf("getting release profiles: %v", err)
+		return
+	}
+
+	existing := make(map[string]*sonarr.ReleaseProfile)
+	for _, profile := range current {
+		existing[strings.ToLower(profile.Name)] = profile
+	}
+
+	for _, release := range releases {
+		r.sonarrRelease(ctx, app, release, existing[strings.ToLower(release.Name)])
+	}
+}
+
+func (r *localRun) sonarrRelease(
+	ctx context.Context,
+	app *apps.SonarrConfig,
+	release *trashRelease,
+	have *sonarr.ReleaseProfile,
+) {
+	want := &sonarr.ReleaseProfile{
+		Name:            release.Name,
+		Enabled:         true,
+		Required:        release.Required,
+		Ignored:         release.Ignored,
+		IncPrefOnRename: release.IncPrefOnRename,
+		Tags:            []int{},
+	}
+
+	if want.Required == nil {
+		want.Required = []string{}
+	}
+
+	if want.Ignored == nil {
+		want.Ignored = []string{}
+	}
+
+	for _, preferred := range release.Preferred {
+		for _, term := range preferred.Terms {
+			want.Preferred = append(want.Preferred, &starr.KeyValue{Key: term, Value: preferred.Score})
+		}
+	}
+
+	if have == nil {
+		change := r.change(TypeReleaseProfile, release.Name, LocalCreate)
+		if r.dryRun {
+			return
+		}
+
+		if _, err := app.AddReleaseProfileContext(ctx, want); err != nil {
+			r.failed(change, err)
+		}
+
+		return
+	}
+
+	// Keep the settings that are not in the guide.
+	want.ID, want.Enabled, want.IndexerID, want.Tags = have.ID, have.Enabled, have.IndexerID, have.Tags
+
+	fields := changedFields(want, have)
+	if len(fields) == 0 {
+		return
+	}
+
+	change := r.change(TypeReleaseProfile, release.Name, LocalUpdate, fields...)
+	if r.dryRun {
+		return
+	}
+
+	if _, err := app.UpdateReleaseProfileContext(ctx, want); err != nil {
+		r.failed(change, err)
+	}
+}
@@ -0,0 +1,49 @@
+package cfsync
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golift.io/starr"
+)
+
+func TestSetScores(t *testing.T) {
+	t.Parallel()
+
+	assert := assert.New(t)
+	hdr := &trashFormat{Name: "HDR"}
+	dv := &trashFormat{Name: "DV"}
+	// formats are the custom format IDs in the instance.
+	setScores := func(formats map[string]int64, scores map[*trashFormat]int64, items ...*starr.FormatItem,
+	) ([]string, []*starr.FormatItem) {
+		run := &localRun{formats: formats}
+		return run.setScores(&items, scores), items
+	}
+
+	details, items := setScores(map[string]int64{"hdr": 1}, map[*trashFormat]int64{hdr: 100},
+		&starr.FormatItem{Format: 1, Name: "HDR", Score: 100})
+	assert.Empty(details, "nothing changed")
+	assert.Equal([]*starr.FormatItem{{Format: 1, Name: "HDR", Score: 100}}, items)
+
+	details, items = setScores(map[string]int64{"hdr": 1, "dv": 2}, map[*trashFormat]int64{hdr: 500, dv: 1500},
+		&starr.FormatItem{Format: 1, Name: "HDR", Score: 100}, &starr.FormatItem{Format: 2, Name: "DV", Score: 0})
+	assert.Equal([]string{"DV: 0 -> 1500", "HDR: 100 -> 500"}, details, "details are sorted")
+	assert.Equal([]*starr.FormatItem{{Format: 1, Name: "HDR", Score: 500}, {Format: 2, Name: "DV", Score: 1500}}, items)
+
+	details, items = setScores(map[string]int64{"hdr": 1, "dv": 2}, map[*trashFormat]int64{dv: 1500},
+		&starr.FormatItem{Format: 1, Name: "HDR", Score: 100})
+	assert.Equal([]string{"DV: 0 -> 1500"}, details)
+	assert.Equal([]*starr.FormatItem{{Format: 1, Name: "HDR", Score: 100}, {Format: 2, Name: "DV", Score: 1500}}, items,
+		"a format missing from the profile is added")
+
+	// In a dry run the new format has no ID, so it can't be added to the profile.
+	details, items = setScores(map[string]int64{"hdr": 1}, map[*trashFormat]int64{dv: 1500},
+		&starr.FormatItem{Format: 1, Name: "HDR", Score: 100})
+	assert.Equal([]string{"DV: 0 -> 1500"}, details)
+	assert.Equal([]*starr.FormatItem{{Format: 1, Name: "HDR", Score: 100}}, items)
+
+	details, items = setScores(map[string]int64{}, map[*trashFormat]int64{hdr: 500},
+		&starr.FormatItem{Format: 9, Name: "hdr", Score: 0})
+	assert.Equal([]string{"HDR: 0 -> 500"}, details)
+	assert.Equal([]*starr.FormatItem{{Format: 9, Name: "hdr", Score: 500}}, items, "formats without an ID match by name")
+}
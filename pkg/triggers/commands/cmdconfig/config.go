@@ -11,6 +11,17 @@ type Config struct {
 	Log     bool          `json:"log" toml:"log" xml:"log" yaml:"log"`
 	Notify  bool          `json:"notify" toml:"notify" xml:"notify" yaml:"notify"`
 	Timeout cnfg.Duration `json:"-" toml:"timeout" xml:"timeout" yaml:"timeout"`
-	Hash    string        `json:"hash" toml:"-" xml:"-" yaml:"-"`
-	Args    int           `json:"args" toml:"-" xml:"-" yaml:"-"`
+	// History is the number of runs kept in the command's history. SaveHistory keeps it between restarts.
+	History     int  `json:"history" toml:"history" xml:"history" yaml:"history"`
+	SaveHistory bool `json:"saveHistory" toml:"save_history" xml:"save_history" yaml:"saveHistory"`
+	// Schedule runs the command on an interval like "6h", or a cron expression like "0 3 * * *".
+	Schedule string `json:"schedule" toml:"schedule" xml:"schedule" yaml:"schedule"`
+	// Env is a list of KEY=value variables added to the environment. Env values and Stdin are templates.
+	Env        []string `json:"-" toml:"env" xml:"env" yaml:"env"`
+	WorkingDir string   `json:"-" toml:"working_dir" xml:"working_dir" yaml:"workingDir"`
+	Stdin      string   `json:"-" toml:"stdin" xml:"stdin" yaml:"stdin"`
+	RunAs      string   `json:"runAs" toml:"run_as" xml:"run_as" yaml:"runAs"`         // user or user:group, linux only.
+	MaxOutput  int      `json:"-" toml:"max_output" xml:"max_output" yaml:"maxOutput"` // bytes of output kept.
+	Hash       string   `json:"hash" toml:"-" xml:"-" yaml:"-"`
+	Args       int      `json:"args" toml:"-" xml:"-" yaml:"-"`
 }
@@ -5,6 +5,7 @@ import (
 	"bytes"
 	"context"
 	"crypto/md5" //nolint:gosec
+	"errors"
 	"fmt"
 	"regexp"
 	"strings"
@@ -14,9 +15,13 @@ import (
 	"github.com/Notifiarr/notifiarr/pkg/triggers/common"
 	"github.com/Notifiarr/notifiarr/pkg/website"
 	"github.com/hugelgupf/go-shlex"
+	"golift.io/cnfg"
 )
 
-var ErrDisabled = fmt.Errorf("the command is disabled due to an error")
+var (
+	ErrDisabled = fmt.Errorf("the command is disabled due to an error")
+	ErrCanceled = fmt.Errorf("the command was canceled")
+)
 
 const (
 	argPfx = "({"
@@ -81,10 +86,16 @@ func (c *Command) run(ctx context.Context, input *common.ActionInput) {
 
 // RunNow runs the command immediately, waits for and returns the output.
 func (c *Command) RunNow(ctx context.Context, input *common.ActionInput) (string, error) {
+	return c.runNow(ctx, input, false)
+}
+
+// runNow runs the command. Scheduled runs only send a notification if they fail.
+func (c *Command) runNow(ctx context.Context, input *common.ActionInput, failureOnly bool) (string, error) {
 	if c.disable {
 		c.mu.Lock()
-		defer c.mu.Unlock()
 		c.output = ErrDisabled.Error()
+		c.mu.Unlock()
+		c.addHistory(&Run{Start: time.Now(), Source: input.Type, Args: input.Args, Error: ErrDisabled.Error()}, ErrDisabled)
 
 		return "<command disabled>", ErrDisabled
 	}
@@ -97,8 +108,17 @@ func (c *Command) RunNow(ctx context.Context, input *common.ActionInput) (string
 		eStr = err.Error()
 	}
 
+	c.addHistory(&Run{
+		Start:   time.Now().Add(-elapsed),
+		Elapsed: cnfg.Duration{Duration: elapsed.Round(time.Millisecond)},
+		Source:  input.Type,
+		Args:    input.Args,
+		Output:  oStr,
+		Error:   eStr,
+	}, err)
+
 	// Send the notification before the lock.
-	if c.Notify {
+	if c.Notify && (!failureOnly || err != nil) {
 		c.website.SendData(&website.Request{
 			Route: website.CommandRoute,
 			Event: input.Type,
@@ -172,14 +192,22 @@ func (c *Command) exec(ctx context.Context, input *common.ActionInput) (*bytes.B
 		return nil, 0, err
 	}
 
-	var out bytes.Buffer
-	cmd.Stdout = &out
-	cmd.Stderr = &out
+	if err := c.applyOptions(cmd, input); err != nil {
+		return nil, 0, err
+	}
+
+	run := c.live.start(c.Name, cancel, c.maxOutput())
+	cmd.Stdout = run
+	cmd.Stderr = run
 
 	start := time.Now()
-	if err := cmd.Run(); err != nil {
-		return &out, time.Since(start), fmt.Errorf(`running cmd %s: %w`, cmd.Args, err)
+	if err = cmd.Run(); errors.Is(ctx.Err(), context.Canceled) {
+		err = fmt.Errorf(`running cmd %s: %w`, cmd.Args, ErrCanceled)
+	} else if err != nil {
+		err = fmt.Errorf(`running cmd %s: %w`, cmd.Args, err)
 	}
 
-	return &out, time.Since(start), nil
+	elapsed := time.Since(start)
+
+	return run.finish(elapsed, err), elapsed, err
 }
@@ -0,0 +1,184 @@
+package commands
+
+import (
+	"errors"
+	"net/http"
+	"os/exec"
+	"time"
+
+	"github.com/Notifiarr/notifiarr/pkg/website"
+	"github.com/gorilla/mux"
+	"golift.io/cnfg"
+)
+
+/* Every command keeps a bounded history of its runs, so we can audit what the website or API ran on this host.
+   The history is kept in memory, and saved to a data file for commands with save_history enabled.
+*/
+
+const (
+	historyFile      = "command_history.json"
+	defaultHistory   = 10
+	maxHistoryOutput = 2048 // bytes of output kept for each run; the end of the output is kept.
+)
+
+// Run is one run of a command in the command's history.
+type Run struct {
+	Start    time.Time         `json:"start"`
+	Elapsed  cnfg.Duration     `json:"elapsed"`
+	ExitCode int               `json:"exitCode"` // -1 if the command did not start or was killed.
+	Source   website.EventType `json:"source"`
+	Args     []string          `json:"args,omitempty"`
+	Output   string            `json:"output"`
+	Error    string            `json:"error,omitempty"`
+}
+
+func (c *Command) historySize() int {
+	if c.History > 0 {
+		return c.History
+	}
+
+	return defaultHistory
+}
+
+// addHistory adds a run to the command's history, and saves the history if that's enabled.
+func (c *Command) addHistory(run *Run, err error) {
+	run.ExitCode = exitCode(err)
+
+	if len(run.Output) > maxHistoryOutput {
+		run.Output = "..." + run.Output[len(run.Output)-maxHistoryOutput:]
+	}
+
+	c.mu.Lock()
+	if c.history = append(c.history, run); len(c.history) > c.historySize() {
+		c.history = c.history[len(c.history)-c.historySize():]
+	}
+	c.mu.Unlock()
+
+	if c.SaveHistory && c.save != nil {
+		c.save()
+	}
+}
+
+// KeepSettings copies the settings that are not configurable in the GUI from another command.
+func (c *Command) KeepSettings(from *Command) {
+	c.History = from.History
+	c.SaveHistory = from.SaveHistory
+	c.Schedule = from.Schedule
+	c.Env = from.Env
+	c.WorkingDir = from.WorkingDir
+	c.Stdin = from.Stdin
+	c.RunAs = from.RunAs
+	c.MaxOutput = from.MaxOutput
+}
+
+// exitCode returns the exit code from a command error.
+func exitCode(err error) int {
+	var exitErr *exec.ExitError
+
+	switch {
+	case err == nil:
+		return 0
+	case errors.As(err, &exitErr):
+		return exitErr.ExitCode()
+	default:
+		return -1
+	}
+}
+
+// GetHistory returns the command's runs, newest first.
+func (c *Command) GetHistory() []*Run {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.newestFirst()
+}
+
+// newestFirst returns a copy of the history, newest first. Must be locked.
+func (c *Command) newestFirst() []*Run {
+	list := make([]*Run, 0, len(c.history))
+	for idx := len(c.history) - 1; idx >= 0; idx-- {
+		list = append(list, c.history[idx])
+	}
+
+	return list
+}
+
+// loadHistory restores the saved history for commands with save_history enabled.
+func (c *cmd) loadHistory() {
+	saved := make(map[string][]*Run)
+	if err := c.ReadDataFile(historyFile, &saved); err != nil {
+		c.Errorf("Loading command history: %v", err)
+	}
+
+	for _, cmd := range c.cmdlist {
+		cmd.save = c.saveHistory
+
+		if cmd.SaveHistory && len(saved[cmd.Name]) > 0 {
+			cmd.history = saved[cmd.Name]
+			if len(cmd.history) > cmd.historySize() {
+				cmd.history = cmd.history[len(cmd.history)-cmd.historySize():]
+			}
+		}
+	}
+}
+
+// saveHistory writes the history of every command with save_history enabled to the data file.
+func (c *cmd) saveHistory() {
+	c.histMu.Lock()
+	defer c.histMu.Unlock()
+
+	saved := make(map[string][]*Run)
+
+	for _, cmd := range c.cmdlist {
+		if !cmd.SaveHistory {
+			continue
+		}
+
+		cmd.mu.RLock()
+		saved[cmd.Name] = append([]*Run{}, cmd.history...)
+		cmd.mu.RUnlock()
+	}
+
+	if err := c.WriteDataFile(historyFile, saved); err != nil {
+		c.Errorf("Saving command history: %v", err)
+	}
+}
+
+// HandleHistory returns the run history for every command.
+// @Summary      Retrieve command run history.
+// @Description  Returns the recent runs of every custom command, newest first, keyed by command name.
+// @Description  Each run has the start time, duration, exit code, what triggered it, args and the end of its output.
+// @Tags         Triggers
+// @Produce      json
+// @Success      200  {object} apps.Respond.apiResponse{message=map[string][]commands.Run} "command history"
+// @Failure      404  {object} string "bad token or api key"
+// @Router       /api/commands/history [get]
+// @Security     ApiKeyAuth
+func (a *Action) HandleHistory(_ *http.Request) (int, interface{}) {
+	history := make(map[string][]*Run)
+	for _, cmd := range a.cmd.cmdlist {
+		history[cmd.Name] = cmd.GetHistory()
+	}
+
+	return http.StatusOK, history
+}
+
+// HandleCommandHistory returns the run history for one command.
+// @Summary      Retrieve a command's run history.
+// @Description  Returns the recent runs of a custom command, newest first.
+// @Tags         Triggers
+// @Produce      json
+// @Param        hash  path   string  true  "Unique hash for the command"
+// @Success      200  {object} apps.Respond.apiResponse{message=[]commands.Run} "command history"
+// @Failure      400  {object} apps.Respond.apiResponse{message=string} "bad or missing hash"
+// @Failure      404  {object} string "bad token or api key"
+// @Router       /api/commands/history/{hash} [get]
+// @Security     ApiKeyAuth
+func (a *Action) HandleCommandHistory(r *http.Request) (int, interface{}) {
+	cmd := a.GetByHash(mux.Vars(r)["hash"])
+	if cmd == nil {
+		return http.StatusBadRequest, "Invalid command Hash provided"
+	}
+
+	return http.StatusOK, cmd.GetHistory()
+}
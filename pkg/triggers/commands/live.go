@@ -0,0 +1,151 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+/* Command output is sent to live viewers (GUI websockets) as it's written.
+   A viewer gets the output written so far when it starts watching, and keeps
+   getting output from every run of the command until it stops watching.
+*/
+
+const viewerBuffer = 100 // chunks of output buffered for each viewer.
+
+// live tracks a command's viewers and running invocations.
+type live struct {
+	mu      sync.Mutex
+	viewers map[chan string]struct{}
+	runs    map[*liveRun]struct{}
+}
+
+// liveRun collects the output from one run of a command, and sends it to the viewers.
+type liveRun struct {
+	*live
+	buf    bytes.Buffer
+	cancel context.CancelFunc
+	max    int  // bytes of output kept.
+	cut    bool // output was truncated.
+}
+
+// start tracks a new run of the command. The cancel function stops it.
+// Output after max bytes is dropped.
+func (l *live) start(name string, cancel context.CancelFunc, max int) *liveRun {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.runs == nil {
+		l.runs = make(map[*liveRun]struct{})
+	}
+
+	run := &liveRun{live: l, cancel: cancel, max: max}
+	l.runs[run] = struct{}{}
+	l.send(fmt.Sprintf("==> Running Custom Command '%s' at %s\n", name, time.Now().Format(time.RFC3339)))
+
+	return run
+}
+
+// Write saves command output and sends it to the viewers.
+// Output over the max size is dropped, and the command keeps running.
+func (r *liveRun) Write(data []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	size := len(data)
+
+	if room := r.max - r.buf.Len(); room < size {
+		if data = data[:room]; !r.cut {
+			defer r.send(fmt.Sprintf("\n==> Output truncated at %d bytes.\n", r.max))
+		}
+
+		r.cut = true
+	}
+
+	r.buf.Write(data)
+	r.send(string(data))
+
+	return size, nil
+}
+
+// finish stops tracking the run, and returns its output.
+func (r *liveRun) finish(elapsed time.Duration, err error) *bytes.Buffer {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.runs, r)
+
+	if err != nil {
+		r.send(fmt.Sprintf("\n==> Command Failed (elapsed: %s): %v\n", elapsed.Round(time.Millisecond), err))
+	} else {
+		r.send(fmt.Sprintf("\n==> Command Finished (elapsed: %s)\n", elapsed.Round(time.Millisecond)))
+	}
+
+	if r.cut {
+		r.buf.WriteString(fmt.Sprintf("\n[output truncated at %d bytes]", r.max))
+	}
+
+	return bytes.NewBuffer(r.buf.Bytes())
+}
+
+// send must be called with the lock held. Output is dropped for viewers that are not keeping up.
+func (l *live) send(msg string) {
+	for viewer := range l.viewers {
+		select {
+		case viewer <- msg:
+		default:
+		}
+	}
+}
+
+// Watch returns a channel that gets the command's output as it's written, starting with the output
+// of any running invocation. Call the returned function to stop watching.
+func (c *Command) Watch() (<-chan string, func()) {
+	c.live.mu.Lock()
+	defer c.live.mu.Unlock()
+
+	if c.live.viewers == nil {
+		c.live.viewers = make(map[chan string]struct{})
+	}
+
+	viewer := make(chan string, viewerBuffer)
+	c.live.viewers[viewer] = struct{}{}
+
+	for run := range c.live.runs {
+		if run.buf.Len() > 0 {
+			select {
+			case viewer <- run.buf.String():
+			default:
+			}
+		}
+	}
+
+	return viewer, func() {
+		c.live.mu.Lock()
+		defer c.live.mu.Unlock()
+
+		delete(c.live.viewers, viewer)
+	}
+}
+
+// Running returns true if the command is running.
+func (c *Command) Running() bool {
+	c.live.mu.Lock()
+	defer c.live.mu.Unlock()
+
+	return len(c.live.runs) > 0
+}
+
+// Cancel stops every running invocation of the command. Returns false if it's not running.
+func (c *Command) Cancel() bool {
+	c.live.mu.Lock()
+	defer c.live.mu.Unlock()
+
+	for run := range c.live.runs {
+		run.cancel()
+	}
+
+	return len(c.live.runs) > 0
+}
@@ -0,0 +1,124 @@
+package commands
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"text/template"
+
+	"github.com/Notifiarr/notifiarr/pkg/mnd"
+	"github.com/Notifiarr/notifiarr/pkg/triggers/common"
+	"github.com/Notifiarr/notifiarr/pkg/website"
+)
+
+/* Options control the environment a command runs in: extra environment variables, the working directory,
+   input on stdin, the user and group it runs as (linux only), and how much output is kept.
+   Env values and stdin are templates, so they may use the trigger input, like {{index .Args 0}}.
+*/
+
+// Errors produced by this file.
+var (
+	ErrEnvValue  = fmt.Errorf("env must be KEY=value")
+	ErrRunAs     = fmt.Errorf("invalid run_as user or group")
+	ErrNoWorkDir = fmt.Errorf("working_dir is not a directory")
+)
+
+const defaultMaxOutput = mnd.Megabyte
+
+// options are the parsed env and stdin templates.
+type options struct {
+	env   []*template.Template
+	stdin *template.Template
+}
+
+// templateInput is the data available to the env and stdin templates.
+type templateInput struct {
+	Name   string
+	Source website.EventType
+	Args   []string
+}
+
+// SetupOptions parses the command's env and stdin templates, and checks the working dir and run as user.
+func (c *Command) SetupOptions() error {
+	opts := &options{}
+
+	for _, env := range c.Env {
+		key, _, ok := strings.Cut(env, "=")
+		if !ok || strings.TrimSpace(key) == "" {
+			return fmt.Errorf("command '%s': %w: %s", c.Name, ErrEnvValue, env)
+		}
+
+		tmpl, err := template.New(key).Option("missingkey=zero").Parse(env)
+		if err != nil {
+			return fmt.Errorf("command '%s': parsing env %s: %w", c.Name, key, err)
+		}
+
+		opts.env = append(opts.env, tmpl)
+	}
+
+	if c.Stdin != "" {
+		var err error
+		if opts.stdin, err = template.New("stdin").Option("missingkey=zero").Parse(c.Stdin); err != nil {
+			return fmt.Errorf("command '%s': parsing stdin: %w", c.Name, err)
+		}
+	}
+
+	if c.WorkingDir != "" {
+		if info, err := os.Stat(c.WorkingDir); err != nil || !info.IsDir() {
+			return fmt.Errorf("command '%s': %w: %s", c.Name, ErrNoWorkDir, c.WorkingDir)
+		}
+	}
+
+	if err := runAs(&exec.Cmd{}, c.RunAs); err != nil {
+		return fmt.Errorf("command '%s': %w", c.Name, err)
+	}
+
+	c.opts = opts
+
+	return nil
+}
+
+func (c *Command) maxOutput() int {
+	if c.MaxOutput > 0 {
+		return c.MaxOutput
+	}
+
+	return defaultMaxOutput
+}
+
+// applyOptions sets the environment, working dir, stdin and user on a command before it runs.
+func (c *Command) applyOptions(cmd *exec.Cmd, input *common.ActionInput) error {
+	if c.opts == nil {
+		return nil
+	}
+
+	data := &templateInput{Name: c.Name, Source: input.Type, Args: input.Args}
+
+	if len(c.opts.env) > 0 {
+		cmd.Env = os.Environ()
+
+		for _, tmpl := range c.opts.env {
+			var buf bytes.Buffer
+			if err := tmpl.Execute(&buf, data); err != nil {
+				return fmt.Errorf("env %s: %w", tmpl.Name(), err)
+			}
+
+			cmd.Env = append(cmd.Env, buf.String())
+		}
+	}
+
+	if c.opts.stdin != nil {
+		var buf bytes.Buffer
+		if err := c.opts.stdin.Execute(&buf, data); err != nil {
+			return fmt.Errorf("stdin: %w", err)
+		}
+
+		cmd.Stdin = &buf
+	}
+
+	cmd.Dir = c.WorkingDir
+
+	return runAs(cmd, c.RunAs)
+}
@@ -0,0 +1,68 @@
+package commands
+
+import (
+	"fmt"
+	"os/exec"
+	"os/user"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// runAs sets the user and group the command runs as. The spec is user or user:group, by name or ID.
+// The user's supplementary groups are kept. This only works if the app runs as root.
+func runAs(cmd *exec.Cmd, spec string) error {
+	if spec == "" {
+		return nil
+	}
+
+	name, group, _ := strings.Cut(spec, ":")
+
+	runUser, err := user.Lookup(name)
+	if err != nil {
+		if runUser, err = user.LookupId(name); err != nil {
+			return fmt.Errorf("%w: %s: %v", ErrRunAs, spec, err) //nolint:errorlint
+		}
+	}
+
+	gid := runUser.Gid
+
+	if group != "" {
+		runGroup, err := user.LookupGroup(group)
+		if err != nil {
+			if runGroup, err = user.LookupGroupId(group); err != nil {
+				return fmt.Errorf("%w: %s: %v", ErrRunAs, spec, err) //nolint:errorlint
+			}
+		}
+
+		gid = runGroup.Gid
+	}
+
+	cred := &syscall.Credential{}
+
+	if cred.Uid, err = parseID(runUser.Uid); err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrRunAs, spec, err) //nolint:errorlint
+	} else if cred.Gid, err = parseID(gid); err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrRunAs, spec, err) //nolint:errorlint
+	}
+
+	groups, _ := runUser.GroupIds()
+	for _, id := range groups {
+		if gid, err := parseID(id); err == nil {
+			cred.Groups = append(cred.Groups, gid)
+		}
+	}
+
+	cmd.SysProcAttr = &syscall.SysProcAttr{Credential: cred}
+
+	return nil
+}
+
+func parseID(id string) (uint32, error) {
+	val, err := strconv.ParseUint(id, 10, 32) //nolint:gomnd
+	if err != nil {
+		return 0, fmt.Errorf("parsing id: %w", err)
+	}
+
+	return uint32(val), nil
+}
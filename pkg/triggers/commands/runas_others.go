@@ -0,0 +1,17 @@
+//go:build !linux
+
+package commands
+
+import (
+	"fmt"
+	"os/exec"
+)
+
+// runAs only works on linux.
+func runAs(_ *exec.Cmd, spec string) error {
+	if spec == "" {
+		return nil
+	}
+
+	return fmt.Errorf("%w: run_as only works on linux: %s", ErrRunAs, spec)
+}
@@ -0,0 +1,207 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Notifiarr/notifiarr/pkg/triggers/common"
+)
+
+/* A command may have a schedule, so the client runs it without being triggered.
+   The schedule is an interval like "6h", or a cron expression like "0 3 * * *".
+   A scheduled run is skipped if the command is still running, and it only notifies if it fails.
+*/
+
+var ErrSchedule = fmt.Errorf("invalid command schedule")
+
+const (
+	cronFields   = 5
+	cronInterval = 30 * time.Second // how often a cron schedule is checked.
+	minInterval  = time.Minute      // shortest schedule interval allowed.
+)
+
+// schedule is an interval, or the allowed values for each cron field as bits.
+type schedule struct {
+	every time.Duration
+	cron  [cronFields]uint64 // minute, hour, day of month, month, day of week.
+	dom   bool               // day of month is restricted.
+	dow   bool               // day of week is restricted.
+}
+
+//nolint:gochecknoglobals
+var (
+	cronRanges = [cronFields][2]int{{0, 59}, {0, 23}, {1, 31}, {1, 12}, {0, 7}}
+	cronMacros = map[string]string{
+		"@hourly":  "0 * * * *",
+		"@daily":   "0 0 * * *",
+		"@weekly":  "0 0 * * 0",
+		"@monthly": "0 0 1 * *",
+	}
+)
+
+// SetupSchedule parses the command's schedule.
+func (c *Command) SetupSchedule() error {
+	c.schedule = nil
+
+	if c.Schedule == "" {
+		return nil
+	}
+
+	sched, err := parseSchedule(c.Schedule)
+	if err != nil {
+		return fmt.Errorf("command '%s': %w", c.Name, err)
+	}
+
+	c.schedule = sched
+
+	return nil
+}
+
+func parseSchedule(spec string) (*schedule, error) {
+	if macro, ok := cronMacros[spec]; ok {
+		spec = macro
+	}
+
+	fields := strings.Fields(spec)
+	if len(fields) == 1 {
+		every, err := time.ParseDuration(spec)
+		if err != nil {
+			return nil, fmt.Errorf("%w: not a duration or cron expression: %s", ErrSchedule, spec)
+		} else if every < minInterval {
+			return nil, fmt.Errorf("%w: interval must be at least %v: %s", ErrSchedule, minInterval, spec)
+		}
+
+		return &schedule{every: every}, nil
+	}
+
+	if len(fields) != cronFields {
+		return nil, fmt.Errorf("%w: cron expression must have %d fields: %s", ErrSchedule, cronFields, spec)
+	}
+
+	sched := &schedule{dom: !strings.HasPrefix(fields[2], "*"), dow: !strings.HasPrefix(fields[4], "*")}
+
+	for idx, field := range fields {
+		bits, err := parseCronField(field, cronRanges[idx][0], cronRanges[idx][1])
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: %v", ErrSchedule, spec, err) //nolint:errorlint
+		}
+
+		sched.cron[idx] = bits
+	}
+
+	if sched.cron[4]&(1<<7) != 0 { // 7 is also sunday.
+		sched.cron[4] |= 1
+	}
+
+	return sched, nil
+}
+
+// parseCronField returns the allowed values in a cron field, like 5 or 1-5 or */15 or 0,30.
+func parseCronField(field string, low, high int) (uint64, error) {
+	var bits uint64
+
+	for _, part := range strings.Split(field, ",") {
+		part, stepStr, hasStep := strings.Cut(part, "/")
+		start, end, step := low, high, 1
+
+		if hasStep {
+			var err error
+			if step, err = strconv.Atoi(stepStr); err != nil || step < 1 {
+				return 0, fmt.Errorf("bad step: %s", field) //nolint:goerr113
+			}
+		}
+
+		if part != "*" {
+			first, last, isRange := strings.Cut(part, "-")
+
+			var err error
+			if start, err = strconv.Atoi(first); err != nil {
+				return 0, fmt.Errorf("bad value: %s", field) //nolint:goerr113
+			}
+
+			if end = start; isRange {
+				if end, err = strconv.Atoi(last); err != nil {
+					return 0, fmt.Errorf("bad range: %s", field) //nolint:goerr113
+				}
+			} else if hasStep {
+				end = high
+			}
+		}
+
+		if start < low || end > high || start > end {
+			return 0, fmt.Errorf("out of range %d-%d: %s", low, high, field) //nolint:goerr113
+		}
+
+		for val := start; val <= end; val += step {
+			bits |= 1 << val
+		}
+	}
+
+	return bits, nil
+}
+
+// matches returns true if the cron schedule runs at this time (minute).
+// Like cron, if day of month and day of week are both restricted, either may match.
+func (s *schedule) matches(now time.Time) bool {
+	has := func(idx, val int) bool { return s.cron[idx]&(1<<val) != 0 }
+
+	if !has(0, now.Minute()) || !has(1, now.Hour()) || !has(3, int(now.Month())) {
+		return false
+	}
+
+	dom, dow := has(2, now.Day()), has(4, int(now.Weekday()))
+	if s.dom && s.dow {
+		return dom || dow
+	}
+
+	return dom && dow
+}
+
+// scheduleAction returns the timer that runs the command on its schedule.
+// Returns nil if it has no schedule, or if the command is disabled because its setup failed.
+func (c *Command) scheduleAction() *common.Action {
+	if c.schedule == nil || c.disable {
+		return nil
+	}
+
+	interval := cronInterval
+	if c.schedule.every > 0 {
+		interval = c.schedule.every
+	}
+
+	return &common.Action{
+		Name: common.TriggerName(fmt.Sprintf("Checking schedule for Custom Command '%s'", c.Name)),
+		Fn:   c.runScheduled,
+		T:    time.NewTicker(interval),
+		Hide: true, // logged when it runs.
+	}
+}
+
+// runScheduled runs the command in the background if it's time, and it's not already running.
+func (c *Command) runScheduled(ctx context.Context, input *common.ActionInput) {
+	if c.schedule.every == 0 {
+		minute := time.Now().Truncate(time.Minute)
+		if !c.schedule.matches(minute) || minute.Equal(c.lastCron) {
+			return
+		}
+
+		c.lastCron = minute
+	}
+
+	if c.Running() || !c.busy.CompareAndSwap(false, true) {
+		c.log.Printf("[%s requested] Skipping Scheduled Custom Command '%s': previous run still active", input.Type, c.Name)
+		return
+	}
+
+	c.log.Printf("[%s requested] Running Scheduled Custom Command '%s'", input.Type, c.Name)
+
+	go func() {
+		defer c.log.CapturePanic()
+		defer c.busy.Store(false)
+
+		_, _ = c.runNow(ctx, input, true)
+	}()
+}
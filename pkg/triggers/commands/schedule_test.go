@@ -0,0 +1,132 @@
+package commands
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCronField(t *testing.T) {
+	t.Parallel()
+
+	assert := assert.New(t)
+	parse := func(field string, low, high int) uint64 {
+		bits, err := parseCronField(field, low, high)
+		assert.NoError(err, field)
+
+		return bits
+	}
+	allowed := func(values ...int) (bits uint64) {
+		for _, val := range values {
+			bits |= 1 << val
+		}
+
+		return bits
+	}
+
+	assert.Equal(allowed(5), parse("5", 0, 59))
+	assert.Equal(allowed(1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12), parse("*", 1, 12))
+	assert.Equal(allowed(0, 15, 30, 45), parse("*/15", 0, 59))
+	assert.Equal(allowed(1, 2, 3, 4, 5), parse("1-5", 0, 7))
+	assert.Equal(allowed(0, 30), parse("0,30", 0, 59))
+	assert.Equal(allowed(10, 15, 20, 59), parse("10-20/5,59", 0, 59))
+	assert.Equal(allowed(20, 40), parse("20/20", 0, 59), "a step from a single value runs to the end")
+
+	for _, field := range []string{"60", "5-1", "*/0", "mon", "1-x", "-1"} {
+		_, err := parseCronField(field, 0, 59)
+		assert.Error(err, field)
+	}
+
+	_, err := parseCronField("0", 1, 31)
+	assert.Error(err, "below the lowest value")
+}
+
+func TestParseSchedule(t *testing.T) {
+	t.Parallel()
+
+	valid := map[string]time.Duration{
+		"6h":        6 * time.Hour,
+		"1m":        time.Minute,
+		"0 3 * * *": 0,
+		"@daily":    0,
+	}
+
+	for spec, every := range valid {
+		sched, err := parseSchedule(spec)
+		if assert.NoError(t, err, spec) {
+			assert.Equal(t, every, sched.every, spec)
+		}
+	}
+
+	for _, spec := range []string{"30s", "often", "0 3 * *", "0 24 * * *", "0 3 * * * *"} {
+		_, err := parseSchedule(spec)
+		assert.ErrorIs(t, err, ErrSchedule, spec)
+	}
+}
+
+func TestScheduleMatches(t *testing.T) {
+	t.Parallel()
+
+	// Monday, 2023-01-02 03:00. The 1st is a sunday.
+	monday := time.Date(2023, time.January, 2, 3, 0, 0, 0, time.UTC)
+	sunday := monday.AddDate(0, 0, -1)
+	matches := func(spec string, now time.Time) bool {
+		sched, err := parseSchedule(spec)
+		require.NoError(t, err, spec)
+
+		return sched.matches(now)
+	}
+
+	match := map[string]time.Time{
+		"* * * * *":   monday,
+		"0 3 * * *":   monday,
+		"0 3 * * 1":   monday,
+		"0 3 2 * *":   monday,
+		"0 3 15 * 1":  monday, // either day matches.
+		"0 3 2 * */1": monday, // day of month with any weekday.
+		"0 3 * * 7":   sunday,
+		"0 3 * * 0":   sunday,
+		"@weekly":     sunday.Add(-3 * time.Hour),
+	}
+
+	for spec, now := range match {
+		assert.True(t, matches(spec, now), spec)
+	}
+
+	noMatch := map[string]time.Time{
+		"0 3 * * *":  monday.Add(time.Minute),
+		"0 4 * * *":  monday,
+		"0 3 * 2 *":  monday,
+		"0 3 * * 2":  monday,
+		"0 3 3 * *":  monday,
+		"0 3 15 * 2": monday, // neither day matches.
+	}
+
+	for spec, now := range noMatch {
+		assert.False(t, matches(spec, now), spec)
+	}
+}
+
+func TestScheduleAction(t *testing.T) {
+	t.Parallel()
+
+	cmd := &Command{}
+	cmd.Name = "no schedule"
+	require.NoError(t, cmd.SetupSchedule())
+	assert.Nil(t, cmd.scheduleAction())
+
+	cmd = &Command{}
+	cmd.Name, cmd.Schedule = "scheduled", "1h"
+	require.NoError(t, cmd.SetupSchedule())
+
+	action := cmd.scheduleAction()
+	require.NotNil(t, action)
+	action.T.Stop()
+
+	cmd = &Command{disable: true}
+	cmd.Name, cmd.Schedule = "disabled", "1h"
+	require.NoError(t, cmd.SetupSchedule())
+	assert.Nil(t, cmd.scheduleAction(), "a disabled command is not scheduled")
+}
@@ -3,7 +3,9 @@ package commands
 import (
 	"fmt"
 	"regexp"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/Notifiarr/notifiarr/pkg/mnd"
@@ -27,24 +29,32 @@ type Action struct {
 type cmd struct {
 	*common.Config
 	cmdlist []*Command
+	histMu  sync.Mutex
 }
 
 // Command contains the input data for a defined command.
 // It also contains some saved data about the command being run.
 type Command struct {
 	cmdconfig.Config
-	cmd     string
-	args    []*regexp.Regexp
-	disable bool
-	fails   int
-	runs    int
-	output  string // last output logged
-	lastRun time.Time
-	lastArg []string
-	mu      sync.RWMutex
-	ch      chan *common.ActionInput
-	log     mnd.Logger
-	website *website.Server
+	cmd      string
+	args     []*regexp.Regexp
+	disable  bool
+	fails    int
+	runs     int
+	output   string // last output logged
+	lastRun  time.Time
+	lastArg  []string
+	mu       sync.RWMutex
+	ch       chan *common.ActionInput
+	log      mnd.Logger
+	website  *website.Server
+	live     live // output for live viewers.
+	history  []*Run
+	save     func() // saves the history of every command.
+	schedule *schedule
+	lastCron time.Time   // last minute the cron schedule ran.
+	busy     atomic.Bool // a scheduled run is active.
+	opts     *options
 }
 
 // New configures the library.
@@ -65,6 +75,20 @@ func (c *Command) Run(input *common.ActionInput) {
 	c.ch <- input
 }
 
+// TryRun fires a custom command without waiting. Returns false if the command is already queued.
+func (c *Command) TryRun(input *common.ActionInput) bool {
+	if c.ch == nil {
+		return false
+	}
+
+	select {
+	case c.ch <- input:
+		return true
+	default:
+		return false
+	}
+}
+
 // List returns a list of active triggers that can be executed.
 func (a *Action) List() []*cmdconfig.Config {
 	output := []*cmdconfig.Config{}
@@ -87,6 +111,17 @@ func (a *Action) GetByHash(hash string) *Command {
 	return nil
 }
 
+// GetByName returns a command by name. The name is not case sensitive.
+func (a *Action) GetByName(name string) *Command {
+	for _, cmd := range a.cmd.cmdlist {
+		if strings.EqualFold(cmd.Name, name) {
+			return cmd
+		}
+	}
+
+	return nil
+}
+
 // Create initializes the library.
 func (a *Action) Create() {
 	a.cmd.create()
@@ -101,6 +136,7 @@ type Stats struct {
 	LastOutput string           `json:"output"`
 	LastRun    string           `json:"last"`
 	LastArgs   []string         `json:"lastArgs"`
+	History    []*Run           `json:"history"` // newest first.
 }
 
 // Stats returns statistics about a command.
@@ -121,16 +157,31 @@ func (c *Command) Stats() Stats {
 		LastOutput: c.output,
 		LastRun:    last,
 		LastArgs:   c.lastArg,
+		History:    c.newestFirst(),
 	}
 }
 
 func (c *cmd) create() {
+	c.loadHistory()
+
 	for _, cmd := range c.cmdlist {
 		if err := cmd.SetupRegexpArgs(); err != nil {
 			c.Errorf("Command Setup Failed: %v", err)
 			cmd.disable = true //nolint:wsl
 		}
 
+		if err := cmd.SetupOptions(); err != nil {
+			c.Errorf("Command Setup Failed: %v", err)
+			cmd.disable = true //nolint:wsl
+		}
+
+		if err := cmd.SetupSchedule(); err != nil {
+			c.Errorf("Command Schedule Setup Failed: %v", err)
+		} else if action := cmd.scheduleAction(); action != nil {
+			c.Printf("==> Custom Command '%s' scheduled: %s", cmd.Name, cmd.Schedule)
+			c.Add(action)
+		}
+
 		cmd.ch = make(chan *common.ActionInput, 1)
 
 		c.Add(&common.Action{
@@ -0,0 +1,58 @@
+package common
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// dataFileMode is the permission used when writing data files. They may contain user names.
+const dataFileMode = 0o600
+
+// WriteDataFile JSON encodes a value and writes it to a file in the data directory.
+// This allows triggers to keep data between restarts. The file is replaced atomically.
+// Does nothing if the application is running without a config file (no data directory).
+func (c *Config) WriteDataFile(name string, value interface{}) error {
+	if c.DataDir == "" {
+		return nil
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("encoding data file %s: %w", name, err)
+	}
+
+	fileName := filepath.Join(c.DataDir, name)
+	if err := os.WriteFile(fileName+".tmp", data, dataFileMode); err != nil {
+		return fmt.Errorf("writing data file: %w", err)
+	}
+
+	if err := os.Rename(fileName+".tmp", fileName); err != nil {
+		return fmt.Errorf("renaming data file: %w", err)
+	}
+
+	return nil
+}
+
+// ReadDataFile reads a JSON encoded file from the data directory into a value.
+// A missing file (or data directory) is not an error; the value is left unchanged.
+func (c *Config) ReadDataFile(name string, value interface{}) error {
+	if c.DataDir == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(filepath.Join(c.DataDir, name))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("reading data file: %w", err)
+	}
+
+	if err := json.Unmarshal(data, value); err != nil {
+		return fmt.Errorf("decoding data file %s: %w", name, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,148 @@
+package common
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/Notifiarr/notifiarr/pkg/apps"
+	"golift.io/starr"
+)
+
+// StarrApp is a Starr app instance. The triggers that work with every Starr app embed this in
+// their own wrapper, next to the functions that do the app specific work.
+type StarrApp struct {
+	App      starr.App
+	Instance int // starts at 1.
+}
+
+// StarrApps has a function for each Starr app. Nil functions are skipped.
+type StarrApps struct {
+	Lidarr  func(instance int, app *apps.LidarrConfig)
+	Radarr  func(instance int, app *apps.RadarrConfig)
+	Readarr func(instance int, app *apps.ReadarrConfig)
+	Sonarr  func(instance int, app *apps.SonarrConfig)
+}
+
+// StarrAPI is the part of a Starr app's API used by the helpers in this file.
+// Every Starr app config satisfies it.
+type StarrAPI interface {
+	GetTagsContext(ctx context.Context) ([]*starr.Tag, error)
+	GetInto(ctx context.Context, req starr.Request, output interface{}) error
+}
+
+// StarrNames holds the tags and quality profile names for a Starr app instance.
+type StarrNames struct {
+	Tags     []*starr.Tag
+	Profiles map[int64]string // quality profile ID to name.
+}
+
+// GetStarrNames collects the tags and quality profile names from a Starr app instance.
+// apiVer is the app's API version, like radarr.APIver.
+func GetStarrNames(ctx context.Context, app StarrAPI, apiVer string) (*StarrNames, error) {
+	tags, err := app.GetTagsContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("getting tags: %w", err)
+	}
+
+	var profiles []struct {
+		ID   int64  `json:"id"`
+		Name string `json:"name"`
+	}
+
+	err = app.GetInto(ctx, starr.Request{URI: path.Join(apiVer, "qualityprofile")}, &profiles)
+	if err != nil {
+		return nil, fmt.Errorf("getting quality profiles: %w", err)
+	}
+
+	names := &StarrNames{Tags: tags, Profiles: make(map[int64]string)}
+	for _, profile := range profiles {
+		names.Profiles[profile.ID] = profile.Name
+	}
+
+	return names, nil
+}
+
+// GetStarrRootFolders returns the root folder paths from a Starr app instance.
+func GetStarrRootFolders(ctx context.Context, app StarrAPI, apiVer string) ([]string, error) {
+	var folders []struct {
+		Path string `json:"path"`
+	}
+
+	err := app.GetInto(ctx, starr.Request{URI: path.Join(apiVer, "rootfolder")}, &folders)
+	if err != nil {
+		return nil, fmt.Errorf("getting root folders: %w", err)
+	}
+
+	roots := []string{}
+	for _, folder := range folders {
+		roots = append(roots, folder.Path)
+	}
+
+	return roots, nil
+}
+
+// TagNames returns the labels for a list of tag IDs.
+func (n *StarrNames) TagNames(ids []int) []string {
+	labels := []string{}
+
+	for _, id := range ids {
+		for _, tag := range n.Tags {
+			if tag.ID == id {
+				labels = append(labels, tag.Label)
+			}
+		}
+	}
+
+	return labels
+}
+
+// Key returns the app name and instance number, like Radarr1. It's used as a key in saved data.
+func (s StarrApp) Key() string {
+	return string(s.App) + strconv.Itoa(s.Instance)
+}
+
+// Match returns true if one of the names is the app name, or the app name and instance number,
+// like radarr or radarr2. An empty list matches every app.
+func (s StarrApp) Match(names []string) bool {
+	if len(names) == 0 {
+		return true
+	}
+
+	for _, name := range names {
+		if strings.EqualFold(name, string(s.App)) || strings.EqualFold(name, s.Key()) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Each runs the functions for every enabled instance of each Starr app.
+func (s *StarrApps) Each(configs *apps.Apps) {
+	for idx, app := range configs.Lidarr {
+		if s.Lidarr != nil && app.Enabled() {
+			s.Lidarr(idx+1, app)
+		}
+	}
+
+	for idx, app := range configs.Radarr {
+		if s.Radarr != nil && app.Enabled() {
+			s.Radarr(idx+1, app)
+		}
+	}
+
+	for idx, app := range configs.Readarr {
+		if s.Readarr != nil && app.Enabled() {
+			s.Readarr(idx+1, app)
+		}
+	}
+
+	for idx, app := range configs.Sonarr {
+		if s.Sonarr != nil && app.Enabled() {
+			s.Sonarr(idx+1, app)
+		}
+	}
+}
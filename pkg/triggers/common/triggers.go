@@ -27,6 +27,7 @@ type Config struct {
 	*website.Server // send trigger responses to website.
 	Snapshot        *snapshot.Config
 	Apps            *apps.Apps
+	DataDir         string // Data files are written here. Empty disables persistence.
 	mnd.Logger
 	stop     *Action        // Triggered by calling Stop()
 	list     []*Action      // List of action triggers
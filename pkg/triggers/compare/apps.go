@@ -0,0 +1,120 @@
+package compare
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Notifiarr/notifiarr/pkg/apps"
+	"github.com/Notifiarr/notifiarr/pkg/triggers/common"
+	"golift.io/starr"
+	"golift.io/starr/radarr"
+	"golift.io/starr/sonarr"
+)
+
+// library is a Starr app instance library, keyed by TMDB or TVDB ID.
+type library struct {
+	common.StarrApp
+	name  string
+	list  func(ctx context.Context, profiles bool) (map[int64]*libraryItem, error) // profiles adds profile names.
+	items map[int64]*libraryItem
+}
+
+// libraryItem is the data compared for each movie or series.
+type libraryItem struct {
+	title     string
+	year      int
+	monitored bool
+	profile   string
+}
+
+func (c *cmd) radarrLibraries() []*library {
+	list := []*library{}
+
+	(&common.StarrApps{
+		Radarr: func(instance int, app *apps.RadarrConfig) { list = append(list, radarrLibrary(instance, app)) },
+	}).Each(c.Apps)
+
+	return list
+}
+
+func (c *cmd) sonarrLibraries() []*library {
+	list := []*library{}
+
+	(&common.StarrApps{
+		Sonarr: func(instance int, app *apps.SonarrConfig) { list = append(list, sonarrLibrary(instance, app)) },
+	}).Each(c.Apps)
+
+	return list
+}
+
+func radarrLibrary(instance int, app *apps.RadarrConfig) *library {
+	return &library{
+		StarrApp: common.StarrApp{App: starr.Radarr, Instance: instance},
+		name:     app.Name,
+		list: func(ctx context.Context, profiles bool) (map[int64]*libraryItem, error) {
+			names := &common.StarrNames{}
+			if profiles {
+				var err error
+				if names, err = common.GetStarrNames(ctx, app, radarr.APIver); err != nil {
+					return nil, err //nolint:wrapcheck
+				}
+			}
+
+			movies, err := app.GetMovieContext(ctx, 0)
+			if err != nil {
+				return nil, fmt.Errorf("getting movies: %w", err)
+			}
+
+			items := make(map[int64]*libraryItem)
+
+			for _, movie := range movies {
+				if movie.TmdbID != 0 {
+					items[movie.TmdbID] = &libraryItem{
+						title:     movie.Title,
+						year:      movie.Year,
+						monitored: movie.Monitored,
+						profile:   names.Profiles[movie.QualityProfileID],
+					}
+				}
+			}
+
+			return items, nil
+		},
+	}
+}
+
+func sonarrLibrary(instance int, app *apps.SonarrConfig) *library {
+	return &library{
+		StarrApp: common.StarrApp{App: starr.Sonarr, Instance: instance},
+		name:     app.Name,
+		list: func(ctx context.Context, profiles bool) (map[int64]*libraryItem, error) {
+			names := &common.StarrNames{}
+			if profiles {
+				var err error
+				if names, err = common.GetStarrNames(ctx, app, sonarr.APIver); err != nil {
+					return nil, err //nolint:wrapcheck
+				}
+			}
+
+			series, err := app.GetAllSeriesContext(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("getting series: %w", err)
+			}
+
+			items := make(map[int64]*libraryItem)
+
+			for _, show := range series {
+				if show.TvdbID != 0 {
+					items[show.TvdbID] = &libraryItem{
+						title:     show.Title,
+						year:      show.Year,
+						monitored: show.Monitored,
+						profile:   names.Profiles[show.QualityProfileID],
+					}
+				}
+			}
+
+			return items, nil
+		},
+	}
+}
@@ -0,0 +1,298 @@
+package compare
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Notifiarr/notifiarr/pkg/triggers/common"
+	"github.com/Notifiarr/notifiarr/pkg/triggers/data"
+	"github.com/Notifiarr/notifiarr/pkg/website"
+	"golift.io/cnfg"
+)
+
+/* Library compare finds the differences between the libraries in multiple instances of the same Starr app. */
+
+const TrigLibraryCompare common.TriggerName = "Comparing Starr App Libraries."
+
+// Mismatch types, also used as checks in the config.
+const (
+	CheckMissing   = "missing"
+	CheckMonitored = "monitored"
+	CheckProfile   = "profile"
+)
+
+// ErrInvalidCompare is returned when the compare config is not valid.
+var ErrInvalidCompare = fmt.Errorf("invalid compare config")
+
+// Config is the [compare] config section.
+type Config struct {
+	Interval cnfg.Duration `json:"interval" toml:"interval" xml:"interval" yaml:"interval"` // 0 disables the timer.
+	Apps     []string      `json:"apps" toml:"apps" xml:"apps" yaml:"apps"`                 // radarr, sonarr, or radarr1.
+	Checks   []string      `json:"checks" toml:"checks" xml:"checks" yaml:"checks"`         // default: missing, monitored.
+}
+
+// Item is a movie or series in a single instance.
+type Item struct {
+	Instance  int    `json:"instance"`
+	Name      string `json:"name"` // instance name.
+	Present   bool   `json:"present"`
+	Monitored bool   `json:"monitored"`
+	Profile   string `json:"qualityProfile"`
+}
+
+// Mismatch is a movie or series that is not the same in every compared instance.
+type Mismatch struct {
+	ID        int64    `json:"id"` // TMDB ID for radarr, TVDB ID for sonarr.
+	Title     string   `json:"title"`
+	Year      int      `json:"year"`
+	Types     []string `json:"types"` // missing, monitored and/or profile.
+	Instances []*Item  `json:"instances"`
+}
+
+// Report is the library compare output.
+type Report struct {
+	Time   time.Time      `json:"time"`
+	Counts map[string]int `json:"counts"` // mismatch type -> count.
+	Radarr []*Mismatch    `json:"radarr"`
+	Sonarr []*Mismatch    `json:"sonarr"`
+	Errors []string       `json:"errors,omitempty"`
+	Sizes  map[string]int `json:"librarySizes"` // app+instance -> items.
+	checks map[string]struct{}
+}
+
+// Action contains the exported methods for this package.
+type Action struct {
+	cmd *cmd
+}
+
+type cmd struct {
+	*common.Config
+	compare *Config
+}
+
+// New configures the library.
+func New(config *common.Config, compare *Config) *Action {
+	return &Action{cmd: &cmd{Config: config, compare: compare}}
+}
+
+// Create initializes the library.
+func (a *Action) Create() {
+	a.cmd.create()
+}
+
+func (c *cmd) create() {
+	if c.compare == nil {
+		c.compare = &Config{}
+	}
+
+	var ticker *time.Ticker
+
+	if c.compare.Interval.Duration > 0 {
+		ticker = time.NewTicker(c.compare.Interval.Duration)
+		c.Printf("==> Library Compare timer started, interval:%s checks:%s",
+			c.compare.Interval, strings.Join(c.compare.checks(), ", "))
+	}
+
+	c.Add(&common.Action{
+		Name: TrigLibraryCompare,
+		Fn:   c.sendReport,
+		C:    make(chan *common.ActionInput, 1),
+		T:    ticker,
+	})
+}
+
+// Validate checks the compare config for errors.
+func (c *Config) Validate() error {
+	for idx, check := range c.Checks {
+		switch c.Checks[idx] = strings.ToLower(check); c.Checks[idx] {
+		case CheckMissing, CheckMonitored, CheckProfile:
+		default:
+			return fmt.Errorf("%w: check '%s' must be one of %s, %s or %s",
+				ErrInvalidCompare, check, CheckMissing, CheckMonitored, CheckProfile)
+		}
+	}
+
+	return nil
+}
+
+// checks returns the configured checks. Profile names often differ on purpose
+// (like 1080p and 4K pairs), so the profile check is only done when it's listed.
+func (c *Config) checks() []string {
+	if len(c.Checks) == 0 {
+		return []string{CheckMissing, CheckMonitored}
+	}
+
+	return c.Checks
+}
+
+// Send the library compare report to the website.
+func (a *Action) Send(event website.EventType) {
+	a.cmd.Exec(&common.ActionInput{Type: event}, TrigLibraryCompare)
+}
+
+func (c *cmd) sendReport(ctx context.Context, input *common.ActionInput) {
+	report := c.report(ctx)
+	data.Save("libraryCompare", report)
+
+	for _, err := range report.Errors {
+		c.Errorf("[%s requested] Library Compare: %s", input.Type, err)
+	}
+
+	c.SendData(&website.Request{
+		Route:      website.CompareRoute,
+		Event:      input.Type,
+		LogPayload: false,
+		LogMsg: fmt.Sprintf("Library Compare (%d radarr and %d sonarr mismatches)",
+			len(report.Radarr), len(report.Sonarr)),
+		Payload: report,
+	})
+}
+
+// HandleReport compares the libraries and returns the report.
+// @Summary      Retrieve library compare report.
+// @Description  Compares the libraries in every Radarr and Sonarr instance by TMDB and TVDB ID.
+// @Description  Returns items missing from an instance, with a different monitored state, or a different quality profile.
+// @Description  Uses the apps and checks in the compare config; the quality profile check is only done when listed.
+// @Tags         Triggers
+// @Produce      json
+// @Success      200  {object} apps.Respond.apiResponse{message=compare.Report} "library differences"
+// @Failure      404  {object} string "bad token or api key"
+// @Router       /api/compare [get]
+// @Security     ApiKeyAuth
+func (a *Action) HandleReport(r *http.Request) (int, interface{}) {
+	return http.StatusOK, a.cmd.report(r.Context())
+}
+
+// report collects the libraries from every instance and compares them.
+func (c *cmd) report(ctx context.Context) *Report {
+	report := &Report{
+		Time:   time.Now(),
+		Counts: make(map[string]int),
+		Sizes:  make(map[string]int),
+		Radarr: []*Mismatch{},
+		Sonarr: []*Mismatch{},
+		checks: make(map[string]struct{}),
+	}
+
+	for _, check := range c.compare.checks() {
+		report.checks[check] = struct{}{}
+		report.Counts[check] = 0
+	}
+
+	report.Radarr = report.compare(c.libraries(ctx, report, c.radarrLibraries()))
+	report.Sonarr = report.compare(c.libraries(ctx, report, c.sonarrLibraries()))
+
+	return report
+}
+
+// libraries collects the items from each instance. Instances that return an error are not compared.
+func (c *cmd) libraries(ctx context.Context, report *Report, libs []*library) []*library {
+	output := []*library{}
+	_, profiles := report.checks[CheckProfile]
+
+	for _, lib := range libs {
+		if !lib.Match(c.compare.Apps) {
+			continue
+		}
+
+		items, err := lib.list(ctx, profiles)
+		if err != nil {
+			report.Errors = append(report.Errors, fmt.Sprintf("%s %d: %v", lib.App, lib.Instance, err))
+			continue
+		}
+
+		lib.items = items
+		report.Sizes[lib.Key()] = len(items)
+		output = append(output, lib)
+	}
+
+	return output
+}
+
+// compare returns the items that are not the same in every library.
+func (r *Report) compare(libs []*library) []*Mismatch {
+	output := []*Mismatch{}
+	if len(libs) < 2 { //nolint:gomnd // need two to compare.
+		return output
+	}
+
+	ids := make(map[int64]*libraryItem)
+
+	for _, lib := range libs {
+		for id, item := range lib.items {
+			if _, ok := ids[id]; !ok {
+				ids[id] = item
+			}
+		}
+	}
+
+	for id, first := range ids {
+		mismatch := &Mismatch{ID: id, Title: first.title, Year: first.year, Types: []string{}}
+
+		for _, lib := range libs {
+			item := &Item{Instance: lib.Instance, Name: lib.name}
+			if found := lib.items[id]; found != nil {
+				item.Present, item.Monitored, item.Profile = true, found.monitored, found.profile
+			}
+
+			mismatch.Instances = append(mismatch.Instances, item)
+		}
+
+		for _, check := range []string{CheckMissing, CheckMonitored, CheckProfile} {
+			if _, ok := r.checks[check]; ok && mismatch.differs(check) {
+				mismatch.Types = append(mismatch.Types, check)
+				r.Counts[check]++
+			}
+		}
+
+		if len(mismatch.Types) > 0 {
+			output = append(output, mismatch)
+		}
+	}
+
+	sort.Slice(output, func(i, j int) bool {
+		if output[i].Title != output[j].Title {
+			return output[i].Title < output[j].Title
+		}
+
+		return output[i].ID < output[j].ID
+	})
+
+	return output
+}
+
+// differs returns true if the check value is not the same in every instance.
+// Monitored state and quality profile only compare the instances that have the item.
+func (m *Mismatch) differs(check string) bool {
+	var first *Item
+
+	for _, item := range m.Instances {
+		if check == CheckMissing {
+			if !item.Present {
+				return true
+			}
+
+			continue
+		}
+
+		if !item.Present {
+			continue
+		}
+
+		if first == nil {
+			first = item
+			continue
+		}
+
+		if (check == CheckMonitored && item.Monitored != first.Monitored) ||
+			(check == CheckProfile && item.Profile != first.Profile) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -24,6 +24,8 @@ const randomMilliseconds = 2500
 type Cmd struct {
 	*common.Config
 	PlexCron *plexcron.Action
+	Series   SeriesConfig
+	series   *series
 }
 
 // Action contains the exported methods for this package.
@@ -94,24 +96,26 @@ type State struct {
 
 // States is our compiled states for the dashboard.
 type States struct {
-	Lidarr   []*State `json:"lidarr"`
-	Radarr   []*State `json:"radarr"`
-	Readarr  []*State `json:"readarr"`
-	Sonarr   []*State `json:"sonarr"`
-	NZBGet   []*State `json:"nzbget"`
-	RTorrent []*State `json:"rtorrent"`
-	Qbit     []*State `json:"qbit"`
-	Deluge   []*State `json:"deluge"`
-	SabNZB   []*State `json:"sabnzbd"`
-	Plex     any      `json:"plexSessions"`
+	Lidarr   []*State          `json:"lidarr"`
+	Radarr   []*State          `json:"radarr"`
+	Readarr  []*State          `json:"readarr"`
+	Sonarr   []*State          `json:"sonarr"`
+	NZBGet   []*State          `json:"nzbget"`
+	RTorrent []*State          `json:"rtorrent"`
+	Qbit     []*State          `json:"qbit"`
+	Deluge   []*State          `json:"deluge"`
+	SabNZB   []*State          `json:"sabnzbd"`
+	Plex     any               `json:"plexSessions"`
+	Series   map[string]Series `json:"series,omitempty"` // download client samples added since the last send.
 }
 
 // New configures the library.
-func New(config *common.Config, plex *plexcron.Action) *Action {
+func New(config *common.Config, plex *plexcron.Action, series SeriesConfig) *Action {
 	return &Action{
 		cmd: &Cmd{
 			Config:   config,
 			PlexCron: plex,
+			Series:   series,
 		},
 	}
 }
@@ -137,6 +141,8 @@ func (c *Cmd) create() {
 		C:    make(chan *common.ActionInput, 1),
 		T:    ticker,
 	})
+
+	c.createSeries()
 }
 
 // Send the current states for the dashboard to the website.
@@ -176,6 +182,7 @@ func (c *Cmd) getStates(ctx context.Context) *States {
 		Sonarr:   c.getSonarrStates(ctx),
 		SabNZB:   c.getSabNZBStates(ctx),
 		Plex:     sessions,
+		Series:   c.series.unsent(),
 	}
 }
 
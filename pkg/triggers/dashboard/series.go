@@ -0,0 +1,418 @@
+package dashboard
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Notifiarr/notifiarr/pkg/apps"
+	"github.com/Notifiarr/notifiarr/pkg/mnd"
+	"github.com/Notifiarr/notifiarr/pkg/triggers/common"
+	"github.com/Notifiarr/notifiarr/pkg/triggers/data"
+	"golift.io/cnfg"
+	"golift.io/nzbget"
+)
+
+/* The download client time series samples transfer speeds, active counts, ratio and free space on an interval. */
+
+// TrigDownloadStats samples download client statistics for the time series.
+const TrigDownloadStats common.TriggerName = "Sampling Download Client Statistics."
+
+const (
+	seriesFile    = "download_stats.json"
+	defaultRetain = 24 * time.Hour
+	// Save the series to disk every this many samples. Saving every sample is excessive.
+	seriesSaveEvery = 10
+)
+
+// SeriesConfig controls the download client time series.
+type SeriesConfig struct {
+	Interval cnfg.Duration `json:"interval" toml:"interval" xml:"interval" yaml:"interval"` // 0 disables sampling.
+	Retain   cnfg.Duration `json:"retain" toml:"retain" xml:"retain" yaml:"retain"`
+}
+
+// Sample is one point in a download client's time series.
+type Sample struct {
+	Time   time.Time `json:"time"`
+	Down   int64     `json:"down"`            // bytes per second.
+	Up     int64     `json:"up"`              // bytes per second.
+	Active int       `json:"active"`          // transfers moving data.
+	Ratio  float64   `json:"ratio,omitempty"` // torrent clients only.
+	Free   int64     `json:"free,omitempty"`  // bytes free in the download folder, if the client reports it.
+}
+
+// Series is a list of samples for one download client instance, oldest first.
+type Series []*Sample
+
+type series struct {
+	retain  time.Duration
+	samples map[string]Series // keyed by app name and instance, like qbit1.
+	count   int
+	sent    time.Time // time of the newest sample sent with the dashboard state.
+	mu      sync.RWMutex
+}
+
+// createSeries loads the saved time series and starts a timer to sample download clients.
+func (c *Cmd) createSeries() {
+	if c.Series.Interval.Duration <= 0 {
+		return
+	}
+
+	c.series = &series{retain: c.Series.Retain.Duration, samples: make(map[string]Series)}
+	if c.series.retain <= 0 {
+		c.series.retain = defaultRetain
+	}
+
+	if err := c.ReadDataFile(seriesFile, &c.series.samples); err != nil {
+		c.Errorf("Loading download client statistics: %v", err)
+	}
+
+	c.series.trim(time.Now())
+	data.Save("downloadStats", c.series.copy())
+	c.Printf("==> Download Client Statistics timer started, interval:%s retain:%s",
+		c.Series.Interval, cnfg.Duration{Duration: c.series.retain})
+	c.Add(&common.Action{
+		Name: TrigDownloadStats,
+		Hide: true, // do not log this one.
+		Fn:   c.sampleDownloaders,
+		T:    time.NewTicker(c.Series.Interval.Duration),
+	})
+}
+
+func (c *Cmd) sampleDownloaders(ctx context.Context, _ *common.ActionInput) {
+	now := time.Now()
+	samples := make(map[string]*Sample)
+
+	for idx, app := range c.Apps.Qbit {
+		if app.Enabled() {
+			samples["qbit"+strconv.Itoa(idx+1)] = c.sampleQbit(ctx, idx+1, app)
+		}
+	}
+
+	for idx, app := range c.Apps.Deluge {
+		if app.Enabled() {
+			samples["deluge"+strconv.Itoa(idx+1)] = c.sampleDeluge(ctx, idx+1, app)
+		}
+	}
+
+	for idx, app := range c.Apps.Rtorrent {
+		if app.Enabled() {
+			samples["rtorrent"+strconv.Itoa(idx+1)] = c.sampleRtorrent(idx+1, app)
+		}
+	}
+
+	for idx, app := range c.Apps.SabNZB {
+		if app.Enabled() {
+			samples["sabnzbd"+strconv.Itoa(idx+1)] = c.sampleSabNZB(ctx, idx+1, app)
+		}
+	}
+
+	for idx, app := range c.Apps.NZBGet {
+		if app.Enabled() {
+			samples["nzbget"+strconv.Itoa(idx+1)] = c.sampleNZBGet(ctx, idx+1, app)
+		}
+	}
+
+	if c.series.add(samples, now) {
+		if err := c.WriteDataFile(seriesFile, c.series.copy()); err != nil {
+			c.Errorf("Saving download client statistics: %v", err)
+		}
+	}
+
+	data.Save("downloadStats", c.series.copy())
+}
+
+func (c *Cmd) sampleQbit(ctx context.Context, instance int, app *apps.QbitConfig) *Sample {
+	xfers, err := app.GetXfersContext(ctx)
+	if err != nil {
+		c.Errorf("Sampling Qbit Data from %d:%s: %v", instance, app.URL, err)
+		return nil
+	}
+
+	var (
+		sample     = &Sample{}
+		downloaded int64
+		uploaded   int64
+	)
+
+	for _, xfer := range xfers {
+		sample.Down += int64(xfer.Dlspeed)
+		sample.Up += xfer.Upspeed
+		downloaded += int64(xfer.Downloaded)
+		uploaded += xfer.Uploaded
+
+		if xfer.Dlspeed > 0 || xfer.Upspeed > 0 {
+			sample.Active++
+		}
+	}
+
+	sample.Ratio = ratio(uploaded, downloaded)
+	sample.Free, _ = app.FreeSpace(ctx)
+
+	return sample
+}
+
+func (c *Cmd) sampleDeluge(ctx context.Context, instance int, app *apps.DelugeConfig) *Sample {
+	xfers, err := app.GetXfersCompatContext(ctx)
+	if err != nil {
+		c.Errorf("Sampling Deluge Data from %d:%s: %v", instance, app.URL, err)
+		return nil
+	}
+
+	var (
+		sample     = &Sample{}
+		downloaded int64
+		uploaded   int64
+	)
+
+	for _, xfer := range xfers {
+		sample.Down += int64(xfer.DownloadPayloadRate)
+		sample.Up += int64(xfer.UploadPayloadRate)
+		downloaded += int64(xfer.AllTimeDownload)
+		uploaded += int64(xfer.TotalUploaded)
+
+		if xfer.DownloadPayloadRate > 0 || xfer.UploadPayloadRate > 0 {
+			sample.Active++
+		}
+	}
+
+	sample.Ratio = ratio(uploaded, downloaded)
+
+	// Free space is optional; older Deluge versions may not support this method.
+	sample.Free, _ = app.FreeSpace(ctx)
+
+	return sample
+}
+
+func (c *Cmd) sampleRtorrent(instance int, app *apps.RtorrentConfig) *Sample {
+	stats, err := getRtorrentData(app)
+	if err != nil {
+		c.Errorf("Sampling rTorrent Data from %d:%s: %v", instance, app.URL, err)
+		return nil
+	}
+
+	sample := &Sample{Ratio: ratio(int64(stats.UpTotal), int64(stats.DownTotal))}
+
+	for _, xfer := range stats.Torrents {
+		sample.Down += int64(xfer.DownRate)
+		sample.Up += int64(xfer.UpRate)
+
+		if xfer.DownRate > 0 || xfer.UpRate > 0 {
+			sample.Active++
+		}
+	}
+
+	return sample
+}
+
+func (c *Cmd) sampleSabNZB(ctx context.Context, instance int, app *apps.SabNZBConfig) *Sample {
+	queue, err := app.GetQueue(ctx)
+	if err != nil {
+		c.Errorf("Sampling SabNZB Data from %d:%s: %v", instance, app.URL, err)
+		return nil
+	}
+
+	sample := &Sample{
+		Down: int64(queue.Kbpersec * mnd.Kilobyte),
+		Free: int64(queue.Diskspace1 * mnd.Megabyte * mnd.Kilobyte), // reported in GB.
+	}
+
+	for _, xfer := range queue.Slots {
+		if strings.EqualFold(xfer.Status, "Downloading") {
+			sample.Active++
+		}
+	}
+
+	return sample
+}
+
+func (c *Cmd) sampleNZBGet(ctx context.Context, instance int, app *apps.NZBGetConfig) *Sample {
+	queue, err := app.ListGroupsContext(ctx)
+	if err != nil {
+		c.Errorf("Sampling NZBGet Data from %d:%s: %v", instance, app.URL, err)
+		return nil
+	}
+
+	stat, err := app.StatusContext(ctx)
+	if err != nil {
+		c.Errorf("Sampling NZBGet Data from %d:%s: %v", instance, app.URL, err)
+		return nil
+	}
+
+	sample := &Sample{Down: stat.DownloadRate, Free: stat.FreeDiskSpaceMB * mnd.Megabyte}
+
+	for _, xfer := range queue {
+		if xfer.Status == nzbget.GroupDOWNLOADING {
+			sample.Active++
+		}
+	}
+
+	return sample
+}
+
+func ratio(uploaded, downloaded int64) float64 {
+	if downloaded <= 0 {
+		return 0
+	}
+
+	return float64(uploaded) / float64(downloaded)
+}
+
+// add appends new samples, and drops the ones older than the retention period.
+// Failed samples (nil) are skipped. Returns true when it's time to save the series to disk.
+func (s *series) add(samples map[string]*Sample, now time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for key, sample := range samples {
+		if sample != nil {
+			sample.Time = now.Round(time.Second)
+			s.samples[key] = append(s.samples[key], sample)
+		}
+	}
+
+	s.trim(now)
+	s.count++
+
+	return s.count%seriesSaveEvery == 0
+}
+
+// trim drops samples older than the retention period. Must be locked, or not in use yet.
+func (s *series) trim(now time.Time) {
+	cutoff := now.Add(-s.retain)
+
+	for key, samples := range s.samples {
+		idx := 0
+		for idx < len(samples) && samples[idx].Time.Before(cutoff) {
+			idx++
+		}
+
+		if idx == len(samples) {
+			delete(s.samples, key)
+		} else {
+			s.samples[key] = samples[idx:]
+		}
+	}
+}
+
+// copy returns a copy of the series map, so it can be used while sampling continues.
+func (s *series) copy() map[string]Series {
+	if s == nil {
+		return nil
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	samples := make(map[string]Series, len(s.samples))
+	for key, list := range s.samples {
+		samples[key] = append(Series{}, list...)
+	}
+
+	return samples
+}
+
+// unsent returns the samples added since the last call, and marks them sent. The first call
+// returns the whole series, so the website can fill in its graphs; later calls only add to it.
+func (s *series) unsent() map[string]Series {
+	if s == nil {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	samples := make(map[string]Series)
+	newest := s.sent
+
+	for key, list := range s.samples {
+		idx := sort.Search(len(list), func(i int) bool { return list[i].Time.After(s.sent) })
+		if idx == len(list) {
+			continue
+		}
+
+		samples[key] = append(Series{}, list[idx:]...)
+
+		if last := list[len(list)-1].Time; last.After(newest) {
+			newest = last
+		}
+	}
+
+	s.sent = newest
+
+	return samples
+}
+
+// Max returns the largest value of the provided sample fields: down, up, active or free.
+// Useful in templates to put multiple fields on the same scale.
+func (s Series) Max(fields ...string) int64 {
+	var top int64
+
+	for _, sample := range s {
+		for _, field := range fields {
+			if val := sample.value(field); val > top {
+				top = val
+			}
+		}
+	}
+
+	return top
+}
+
+// Last returns the newest sample in the series. Useful in templates.
+func (s Series) Last() *Sample {
+	if len(s) == 0 {
+		return &Sample{}
+	}
+
+	return s[len(s)-1]
+}
+
+// Points returns SVG polyline points for a sample field (down, up, active or free), scaled to fit
+// width and height. The top of the graph is top, see Max. Useful to graph the series in templates.
+func (s Series) Points(field string, top int64, width, height int) string {
+	if len(s) == 0 {
+		return ""
+	}
+
+	var (
+		first  = s[0].Time
+		span   = s[len(s)-1].Time.Sub(first)
+		points = make([]string, len(s))
+	)
+
+	for idx, sample := range s {
+		xPos := float64(width)
+		if span > 0 {
+			xPos = float64(width) * float64(sample.Time.Sub(first)) / float64(span)
+		}
+
+		yPos := float64(height)
+		if top > 0 {
+			yPos -= float64(height) * float64(sample.value(field)) / float64(top)
+		}
+
+		points[idx] = fmt.Sprintf("%.1f,%.1f", xPos, yPos)
+	}
+
+	return strings.Join(points, " ")
+}
+
+func (s *Sample) value(field string) int64 {
+	switch field {
+	case "down":
+		return s.Down
+	case "up":
+		return s.Up
+	case "active":
+		return int64(s.Active)
+	case "free":
+		return s.Free
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,57 @@
+package dashboard
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSeriesAdd(t *testing.T) {
+	t.Parallel()
+
+	assert := assert.New(t)
+	start := time.Now().Round(time.Second)
+	stats := &series{retain: time.Hour, samples: make(map[string]Series)}
+
+	for idx := 1; idx < seriesSaveEvery; idx++ {
+		assert.False(stats.add(map[string]*Sample{"qbit1": {Down: 1}}, start.Add(time.Duration(idx)*time.Minute)))
+	}
+
+	assert.True(stats.add(map[string]*Sample{"qbit1": {Down: 2}, "nzbget1": nil}, start.Add(time.Hour)),
+		"the series is saved every few samples")
+
+	samples := stats.copy()
+	assert.NotContains(samples, "nzbget1", "failed samples are skipped")
+	assert.Len(samples["qbit1"], seriesSaveEvery, "no samples are older than the retention period")
+	assert.Equal(start.Add(time.Hour), samples["qbit1"].Last().Time)
+
+	stats.add(nil, start.Add(3*time.Hour))
+	assert.Empty(stats.copy(), "series without new samples are dropped")
+	assert.Nil((*series)(nil).copy(), "sampling is disabled")
+}
+
+func TestSeriesUnsent(t *testing.T) {
+	t.Parallel()
+
+	start := time.Now().Round(time.Second)
+	stats := &series{retain: time.Hour, samples: make(map[string]Series)}
+	stats.add(map[string]*Sample{"qbit1": {Down: 1}, "sabnzbd1": {Down: 2}}, start)
+	stats.add(map[string]*Sample{"qbit1": {Down: 3}, "sabnzbd1": nil}, start.Add(time.Minute))
+
+	first := stats.unsent()
+	assert.Len(t, first["qbit1"], 2, "the first send has the whole series")
+	assert.Len(t, first["sabnzbd1"], 1)
+	assert.Empty(t, stats.unsent(), "nothing new was sampled")
+
+	stats.add(map[string]*Sample{"qbit1": {Down: 4}, "sabnzbd1": {Down: 5}}, start.Add(2*time.Minute))
+
+	next := stats.unsent()
+	if assert.Len(t, next["qbit1"], 1) && assert.Len(t, next["sabnzbd1"], 1) {
+		assert.EqualValues(t, 4, next["qbit1"][0].Down)
+		assert.EqualValues(t, 5, next["sabnzbd1"][0].Down)
+	}
+
+	assert.Len(t, stats.copy()["qbit1"], 3, "the local copy keeps the whole series")
+	assert.Nil(t, (*series)(nil).unsent(), "sampling is disabled")
+}
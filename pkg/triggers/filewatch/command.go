@@ -0,0 +1,79 @@
+package filewatch
+
+import (
+	"time"
+
+	"github.com/Notifiarr/notifiarr/pkg/mnd"
+	"github.com/Notifiarr/notifiarr/pkg/triggers/common"
+	"github.com/Notifiarr/notifiarr/pkg/website"
+)
+
+/* A watcher may run a custom command when a line matches, like a restart script.
+   The command gets the capture groups (or fields) as arguments, and the command's
+   argument regexps validate them. The cooldown keeps a command from running in a loop.
+*/
+
+const defaultCooldown = time.Minute
+
+func (w *WatchFile) cooldown() time.Duration {
+	if w.Cooldown.Duration > 0 {
+		return w.Cooldown.Duration
+	}
+
+	return defaultCooldown
+}
+
+// commandArgs returns the fields named in args, or every capture group in the regexp.
+func (w *WatchFile) commandArgs(match *Match, text string) []string {
+	if len(w.Args) > 0 {
+		args := make([]string, len(w.Args))
+		for idx, name := range w.Args {
+			args[idx] = match.Fields[name]
+		}
+
+		return args
+	}
+
+	if w.re == nil || w.Regexp == "" {
+		return nil
+	}
+
+	if submatch := w.re.FindStringSubmatch(text); len(submatch) > 1 {
+		return submatch[1:]
+	}
+
+	return nil
+}
+
+// runCommand runs the custom command for a watcher, if it has one and it's not cooling down.
+func (c *cmd) runCommand(tail *WatchFile, match *Match, text string) {
+	if tail.Command == "" {
+		return
+	}
+
+	command := c.commands.GetByName(tail.Command)
+	if command == nil {
+		mnd.FileWatcher.Add(tail.Path+" Errors", 1)
+		return
+	}
+
+	// Files found by a glob watcher share a cooldown.
+	owner := tail.owner()
+	if time.Since(owner.lastCmd) < tail.cooldown() {
+		mnd.FileWatcher.Add(tail.Path+" Cooldowns", 1)
+		return
+	}
+
+	owner.lastCmd = time.Now()
+	args := tail.commandArgs(match, text)
+
+	if !command.TryRun(&common.ActionInput{Type: website.EventFile, Args: args}) {
+		c.Errorf("File watcher %s: custom command '%s' is already queued; not running it again.", tail.Path, command.Name)
+		mnd.FileWatcher.Add(tail.Path+" Errors", 1)
+
+		return
+	}
+
+	mnd.FileWatcher.Add(tail.Path+" Commands", 1)
+	c.Printf("File watcher %s: running custom command '%s' with %d args.", tail.Path, command.Name, len(args))
+}
@@ -0,0 +1,204 @@
+package filewatch
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"text/template"
+)
+
+/* Fields are named capture groups from the regexp, the values in a json log line,
+   or the values in a journal or docker log entry.
+   They're sent with the match, used in json match conditions, and used in the message template.
+*/
+
+// ErrInvalidWhere is returned when a json match condition is not valid.
+var ErrInvalidWhere = fmt.Errorf("invalid json match condition")
+
+// condition is a json field and the regexp its value must match.
+type condition struct {
+	field string
+	re    *regexp.Regexp
+}
+
+func (w *WatchFile) setupFields() error {
+	w.where = nil
+
+	for _, where := range w.Where {
+		field, expr, ok := strings.Cut(where, "=")
+		if !ok || strings.TrimSpace(field) == "" {
+			return fmt.Errorf("%w: must be field=regexp: %s: %s", ErrInvalidWhere, where, w.Path)
+		}
+
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return fmt.Errorf("%w: %s: %s: %s", ErrInvalidWhere, where, w.Path, err.Error())
+		}
+
+		w.where = append(w.where, &condition{field: strings.TrimSpace(field), re: re})
+	}
+
+	w.format = nil
+
+	if w.Format != "" {
+		var err error
+		if w.format, err = template.New(w.Path).Option("missingkey=zero").Parse(w.Format); err != nil {
+			return fmt.Errorf("parsing format template: %s: %w", w.Path, err)
+		}
+	}
+
+	return nil
+}
+
+// match checks text for a match and returns the match, or nil if it does not match.
+// JSON lines are checked with the json match conditions if there are any, otherwise with the regexp.
+func (w *WatchFile) match(text string) *Match {
+	if text == "" {
+		return nil
+	}
+
+	var (
+		fields  map[string]string
+		matches []string
+	)
+
+	if w.JSON {
+		fields = jsonFields(text)
+	}
+
+	for key, value := range w.lineFields {
+		if fields == nil {
+			fields = make(map[string]string)
+		}
+
+		if _, ok := fields[key]; !ok {
+			fields[key] = value
+		}
+	}
+
+	switch {
+	case fields != nil && len(w.where) > 0:
+		var ok bool
+		if matches, ok = w.jsonMatch(fields); !ok {
+			return nil
+		}
+	case w.re != nil && w.Regexp != "" && w.re.MatchString(text):
+		matches = w.re.FindAllString(text, -1)
+		fields = regexpFields(w.re, text, fields)
+	default:
+		return nil
+	}
+
+	match := &Match{
+		File:    w.Path,
+		Line:    strings.TrimSpace(text),
+		Matches: matches,
+	}
+
+	if len(fields) > 0 {
+		match.Fields = fields
+	}
+
+	match.Message = w.message(fields)
+
+	return match
+}
+
+// jsonMatch returns the matched values if every json match condition matches.
+func (w *WatchFile) jsonMatch(fields map[string]string) ([]string, bool) {
+	matches := []string{}
+
+	for _, where := range w.where {
+		value, ok := fields[where.field]
+		if !ok || !where.re.MatchString(value) {
+			return nil, false
+		}
+
+		matches = append(matches, where.re.FindString(value))
+	}
+
+	return matches, true
+}
+
+// message returns the notification message from the format template, or an empty string if there is no template.
+func (w *WatchFile) message(fields map[string]string) string {
+	if w.format == nil {
+		return ""
+	}
+
+	if fields == nil {
+		fields = map[string]string{}
+	}
+
+	var buf bytes.Buffer
+	if err := w.format.Execute(&buf, fields); err != nil {
+		return "format template: " + err.Error()
+	}
+
+	return strings.TrimSpace(buf.String())
+}
+
+// regexpFields adds the named capture groups from the first match to fields.
+func regexpFields(re *regexp.Regexp, text string, fields map[string]string) map[string]string {
+	submatch := re.FindStringSubmatch(text)
+
+	for idx, name := range re.SubexpNames() {
+		if name == "" || idx >= len(submatch) {
+			continue
+		}
+
+		if fields == nil {
+			fields = make(map[string]string)
+		}
+
+		fields[name] = submatch[idx]
+	}
+
+	return fields
+}
+
+// jsonFields flattens a json object into fields. Nested keys are joined with a dot.
+// Returns nil if the text is not a json object.
+func jsonFields(text string) map[string]string {
+	if text = strings.TrimSpace(text); !strings.HasPrefix(text, "{") {
+		return nil
+	}
+
+	var object map[string]interface{}
+
+	decoder := json.NewDecoder(strings.NewReader(text))
+	decoder.UseNumber() // so large numbers are not written in scientific notation.
+
+	if err := decoder.Decode(&object); err != nil {
+		return nil
+	}
+
+	fields := make(map[string]string)
+	flatten("", object, fields)
+
+	return fields
+}
+
+func flatten(prefix string, value interface{}, fields map[string]string) {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		for key, val := range value {
+			if prefix != "" {
+				key = prefix + "." + key
+			}
+
+			flatten(key, val, fields)
+		}
+	case []interface{}:
+		b, _ := json.Marshal(value)
+		fields[prefix] = string(b)
+	case nil:
+		fields[prefix] = ""
+	case string:
+		fields[prefix] = value
+	default:
+		fields[prefix] = fmt.Sprint(value)
+	}
+}
@@ -0,0 +1,121 @@
+package filewatch
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJSONFields(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]map[string]string{
+		"2023-01-02 ERROR boom": nil,
+		`{"level": "error"`:     nil,
+		`["error"]`:             nil,
+		` {"level": "error", "msg": "boom", "ok": false} `: {"level": "error", "msg": "boom", "ok": "false"},
+		`{"log": {"level": "warn", "origin": {"line": 12}}, "tags": ["a", 1], "user": null}`: {
+			"log.level": "warn", "log.origin.line": "12", "tags": `["a",1]`, "user": "",
+		},
+		`{"id": 12345678901234567890, "took": 0.5}`: {"id": "12345678901234567890", "took": "0.5"},
+	}
+
+	for text, want := range tests {
+		assert.Equal(t, want, jsonFields(text), text)
+	}
+}
+
+func TestFlatten(t *testing.T) {
+	t.Parallel()
+
+	assert := assert.New(t)
+	flat := func(prefix string, value interface{}) map[string]string {
+		fields := make(map[string]string)
+		flatten(prefix, value, fields)
+
+		return fields
+	}
+
+	assert.Equal(map[string]string{"msg": "boom"}, flat("msg", "boom"))
+	assert.Equal(map[string]string{"user": ""}, flat("user", nil))
+	assert.Equal(map[string]string{"ok": "true"}, flat("ok", true))
+	assert.Equal(map[string]string{"tags": `["a","b"]`}, flat("tags", []interface{}{"a", "b"}), "lists stay json")
+	assert.Equal(map[string]string{"a.b": "c"}, flat("", map[string]interface{}{"a": map[string]interface{}{"b": "c"}}))
+	assert.Equal(map[string]string{"log.level": "info"}, flat("log", map[string]interface{}{"level": "info"}))
+}
+
+func TestMatch(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		tail *WatchFile
+		text string
+		want *Match // File is filled in by the test.
+	}{
+		"empty":           {tail: &WatchFile{Regexp: "."}, text: ""},
+		"regexp no match": {tail: &WatchFile{Regexp: "ERROR"}, text: "INFO ok"},
+		"regexp": {
+			tail: &WatchFile{Regexp: "ERR(OR)?"},
+			text: " ERROR boom ERR ",
+			want: &Match{Line: "ERROR boom ERR", Matches: []string{"ERROR", "ERR"}},
+		},
+		"regexp fields": {
+			tail: &WatchFile{Regexp: `(?P<level>ERROR) (?P<msg>\w+)`, Format: "{{.level}}: {{.msg}}"},
+			text: "ERROR boom",
+			want: &Match{
+				Line:    "ERROR boom",
+				Matches: []string{"ERROR boom"},
+				Fields:  map[string]string{"level": "ERROR", "msg": "boom"},
+				Message: "ERROR: boom",
+			},
+		},
+		"json where": {
+			tail: &WatchFile{JSON: true, Where: []string{"level=^(error|fatal)$", "log.origin= .+"}},
+			text: `{"level": "error", "log": {"origin": " main.go"}}`,
+			want: &Match{
+				Line:    `{"level": "error", "log": {"origin": " main.go"}}`,
+				Matches: []string{"error", " main.go"},
+				Fields:  map[string]string{"level": "error", "log.origin": " main.go"},
+			},
+		},
+		"json where no match": {
+			tail: &WatchFile{JSON: true, Where: []string{"level=^error$"}},
+			text: `{"level": "info"}`,
+		},
+		"json where missing field": {
+			tail: &WatchFile{JSON: true, Where: []string{"level=.*"}},
+			text: `{"msg": "boom"}`,
+		},
+		"not json uses regexp": {
+			tail: &WatchFile{JSON: true, Regexp: "ERROR", Where: []string{"level=^error$"}},
+			text: "ERROR boom",
+			want: &Match{Line: "ERROR boom", Matches: []string{"ERROR"}},
+		},
+		"json fields with regexp": {
+			tail: &WatchFile{JSON: true, Regexp: "boom", Format: "{{.msg}} {{.missing}}"},
+			text: `{"msg": "boom"}`,
+			want: &Match{
+				Line:    `{"msg": "boom"}`,
+				Matches: []string{"boom"},
+				Fields:  map[string]string{"msg": "boom"},
+				Message: "boom",
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			test.tail.Path = "/var/log/test.log"
+			test.tail.re = regexp.MustCompile(test.tail.Regexp)
+			require.NoError(t, test.tail.setupFields())
+
+			if test.want != nil {
+				test.want.File = test.tail.Path
+			}
+
+			assert.Equal(t, test.want, test.tail.match(test.text))
+		})
+	}
+}
@@ -2,28 +2,34 @@ package filewatch
 
 import (
 	"fmt"
-	"io"
 	"reflect"
 	"regexp"
 	"strings"
 	"sync"
+	"text/template"
 	"time"
 
 	"github.com/Notifiarr/notifiarr/pkg/mnd"
+	"github.com/Notifiarr/notifiarr/pkg/triggers/commands"
 	"github.com/Notifiarr/notifiarr/pkg/triggers/common"
 	"github.com/Notifiarr/notifiarr/pkg/website"
 	"github.com/nxadm/tail"
 	"github.com/nxadm/tail/ratelimiter"
+	"golift.io/cnfg"
 )
 
-var ErrInvalidRegexp = fmt.Errorf("invalid regexp")
+var (
+	ErrInvalidRegexp = fmt.Errorf("invalid regexp")
+	ErrStopGlob      = fmt.Errorf("stopping pattern watcher")
+)
 
 const (
 	maxRetries    = 6                                  // how many times to retry watching a file.
 	retryInterval = 10 * time.Second                   // how often channels are checked for being closed.
-	specialCase   = 2                                  // We have two special channels in our select cases.
-	burstRate     = 6                                  // burst to this many 'matches' before throttling.
-	requestPer    = time.Second + 500*time.Millisecond // 1 request per this time period allowed + burst rate.
+	flushInterval = time.Second                        // how often multi-line events and summaries are checked.
+	specialCase   = 3                                  // We have three special channels in our select cases.
+	burstRate     = 6                                  // default: burst to this many 'matches' before throttling.
+	requestPer    = time.Second + 500*time.Millisecond // default: 1 request per this time period allowed + burst rate.
 )
 
 type cmd struct {
@@ -32,7 +38,7 @@ type cmd struct {
 	stopWatcher chan struct{}
 	awMutex     sync.RWMutex
 	files       []*WatchFile
-	limiter     *ratelimiter.LeakyBucket
+	commands    *commands.Action
 }
 
 // Action contains the exported methods for this package.
@@ -49,27 +55,70 @@ type WatchFile struct {
 	Pipe      bool   `json:"pipe" toml:"pipe" xml:"pipe" yaml:"pipe"`
 	MustExist bool   `json:"mustExist" toml:"must_exist" xml:"must_exist" yaml:"mustExist"`
 	LogMatch  bool   `json:"logMatch" toml:"log_match" xml:"log_match" yaml:"logMatch"`
-	re        *regexp.Regexp
-	skip      *regexp.Regexp
-	tail      *tail.Tail
-	mu        sync.RWMutex
-	retries   uint
+	// Multi-line events. Setting start, continue or indent enables multi-line mode.
+	Start    string        `json:"start" toml:"start" xml:"start" yaml:"start"`             // first line of an event.
+	Continue string        `json:"continue" toml:"continue" xml:"continue" yaml:"continue"` // other lines of an event.
+	Indent   bool          `json:"indent" toml:"indent" xml:"indent" yaml:"indent"`         // indented lines continue an event.
+	MaxLines int           `json:"maxLines" toml:"max_lines" xml:"max_lines" yaml:"maxLines"`
+	Timeout  cnfg.Duration `json:"timeout" toml:"timeout" xml:"timeout" yaml:"timeout"` // send an event after no new lines.
+	// JSON log lines are turned into fields, and may be matched with field=regexp conditions instead of the regexp.
+	JSON   bool     `json:"json" toml:"json" xml:"json" yaml:"json"`
+	Where  []string `json:"where" toml:"where" xml:"where" yaml:"where"`
+	Format string   `json:"format" toml:"format" xml:"format" yaml:"format"` // message template using fields.
+	// Run a custom command when a line matches. Args are field names; the default is every capture group.
+	Command  string        `json:"command" toml:"command" xml:"command" yaml:"command"`
+	Args     []string      `json:"args" toml:"args" xml:"args" yaml:"args"`
+	Cooldown cnfg.Duration `json:"cooldown" toml:"cooldown" xml:"cooldown" yaml:"cooldown"`
+	// Rate limit: send burst matches, then one per rate. Matches over the limit are counted and sent as one summary per period.
+	Burst      int           `json:"burst" toml:"burst" xml:"burst" yaml:"burst"`
+	Rate       cnfg.Duration `json:"rate" toml:"rate" xml:"rate" yaml:"rate"`
+	Summary    cnfg.Duration `json:"summary" toml:"summary" xml:"summary" yaml:"summary"`
+	re         *regexp.Regexp
+	skip       *regexp.Regexp
+	start      *regexp.Regexp
+	cont       *regexp.Regexp
+	where      []*condition
+	format     *template.Template
+	lastCmd    time.Time                // last time the command ran.
+	limiter    *ratelimiter.LeakyBucket // rate limit for this watcher, and the files found by its pattern.
+	dropped    int                      // matches dropped by the rate limit since the last summary.
+	droppedAt  time.Time                // time of the first dropped match since the last summary.
+	lastDrop   *Match                   // the last dropped match, sent with the summary.
+	glob       string                   // pattern for a glob or directory watcher.
+	globbed    map[string]*WatchFile    // files being watched by a glob or directory watcher.
+	parent     *WatchFile               // the glob watcher that found this file.
+	fromStart  bool                     // read a file found after the glob watcher started from the beginning.
+	event      []string                 // multi-line event being assembled.
+	eventAt    time.Time                // time of the last line in the event.
+	lineFields map[string]string        // values in the last journal or docker entry.
+	partial    string                   // docker log line being assembled.
+	entry      map[string]string        // journal export entry being assembled.
+	tail       *tail.Tail
+	mu         sync.RWMutex
+	retries    uint
 }
 
 // Match is what we send to the website.
+// Line contains every line of a multi-line event, and Lines has them separately.
+// Fields are the named capture groups, or the values in a json line. Message is from the format template.
+// Dropped is the number of matches over the rate limit, in a summary of them.
 type Match struct {
-	File    string   `json:"file"`
-	Matches []string `json:"matches"`
-	Line    string   `json:"line"`
+	File    string            `json:"file"`
+	Matches []string          `json:"matches"`
+	Line    string            `json:"line"`
+	Lines   []string          `json:"lines,omitempty"`
+	Fields  map[string]string `json:"fields,omitempty"`
+	Message string            `json:"message,omitempty"`
+	Dropped int               `json:"dropped,omitempty"`
 }
 
 // New configures the library.
-func New(config *common.Config, files []*WatchFile) *Action {
+func New(config *common.Config, files []*WatchFile, cmds *commands.Action) *Action {
 	return &Action{
 		cmd: &cmd{
-			Config:  config,
-			files:   files,
-			limiter: ratelimiter.NewLeakyBucket(burstRate, requestPer),
+			Config:   config,
+			files:    files,
+			commands: cmds,
 		},
 	}
 }
@@ -91,7 +140,7 @@ func (a *Action) Stop() {
 
 func (c *cmd) run() {
 	// two fake tails for internal channels.
-	validTails := []*WatchFile{{Path: "/add watcher channel/"}, {Path: "/retry ticker/"}}
+	validTails := []*WatchFile{{Path: "/add watcher channel/"}, {Path: "/retry ticker/"}, {Path: "/flush ticker/"}}
 
 	for _, item := range c.files {
 		if err := item.setup(&logger{Logger: c.Config.Logger}); err != nil {
@@ -99,12 +148,24 @@ func (c *cmd) run() {
 			continue
 		}
 
+		if item.Command != "" && c.commands.GetByName(item.Command) == nil {
+			c.Errorf("File watcher %s: custom command not found: %s", item.Path, item.Command)
+		}
+
+		if item.glob != "" {
+			children := c.scanGlob(item, true)
+			validTails = append(validTails, children...)
+			c.Printf("==> Watching pattern: %s, files: %d", item.glob, len(children))
+
+			continue
+		}
+
 		validTails = append(validTails, item)
 	}
 
 	if len(validTails) != 0 {
-		cases, ticker := c.collectFileTails(validTails)
-		go c.tailFiles(cases, validTails, ticker)
+		cases, tickers := c.collectFileTails(validTails)
+		go c.tailFiles(cases, validTails, tickers)
 	}
 }
 
@@ -113,25 +174,34 @@ func (w *WatchFile) setup(logger *logger) error {
 
 	w.retries = maxRetries // so it will not get "restarted" unless it passes validation.
 
-	if w.Regexp == "" {
+	if w.Regexp == "" && (len(w.Where) == 0 || (!w.JSON && !w.source())) {
 		return fmt.Errorf("%w: no regexp match provided, ignored: %s", ErrInvalidRegexp, w.Path)
 	} else if w.re, err = regexp.Compile(w.Regexp); err != nil {
 		return fmt.Errorf("%w: regexp match compile failed, ignored: %s", ErrInvalidRegexp, w.Path)
 	} else if w.skip, err = regexp.Compile(w.Skip); err != nil {
 		return fmt.Errorf("%w: regexp skip compile failed, ignored: %s", ErrInvalidRegexp, w.Path)
+	} else if err = w.setupMultiline(); err != nil {
+		return err
+	} else if err = w.setupFields(); err != nil {
+		return err
 	}
 
-	w.tail, err = tail.TailFile(w.Path, tail.Config{
-		Follow:        true,
-		ReOpen:        true,
-		MustExist:     w.MustExist,
-		Poll:          w.Poll,
-		Pipe:          w.Pipe,
-		CompleteLines: true,
-		Location:      &tail.SeekInfo{Whence: io.SeekEnd},
-		Logger:        logger,
-	})
-	if err != nil {
+	if w.parent == nil {
+		w.setupLimiter()
+
+		if w.glob = globPattern(w.Path); w.glob != "" && !w.source() {
+			w.mu.Lock()
+			w.globbed = make(map[string]*WatchFile)
+			w.mu.Unlock()
+			w.retries = 0
+
+			return nil // the files that match the pattern are watched, not the pattern.
+		}
+	}
+
+	w.lineFields, w.partial, w.entry = nil, "", nil
+
+	if w.tail, err = w.open(logger); err != nil {
 		mnd.FileWatcher.Add(w.Path+" Errors", 1)
 		return fmt.Errorf("watching file %s: %w", w.Path, err)
 	}
@@ -142,10 +212,11 @@ func (w *WatchFile) setup(logger *logger) error {
 }
 
 // collectFileTails uses reflection to watch a dynamic list of files in one go routine.
-func (c *cmd) collectFileTails(tails []*WatchFile) ([]reflect.SelectCase, *time.Ticker) {
+func (c *cmd) collectFileTails(tails []*WatchFile) ([]reflect.SelectCase, []*time.Ticker) {
 	c.addWatcher = make(chan *WatchFile, 1)
 	c.stopWatcher = make(chan struct{})
 	ticker := time.NewTicker(retryInterval)
+	flush := time.NewTicker(flushInterval)
 	cases := make([]reflect.SelectCase, len(tails))
 
 	for idx, item := range tails {
@@ -156,6 +227,9 @@ func (c *cmd) collectFileTails(tails []*WatchFile) ([]reflect.SelectCase, *time.
 		} else if idx == 1 {
 			cases[idx] = reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(ticker.C)}
 			continue
+		} else if idx == 2 { //nolint:gomnd
+			cases[idx] = reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(flush.C)}
+			continue
 		}
 
 		cases[idx] = reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(item.tail.Lines)}
@@ -169,13 +243,17 @@ func (c *cmd) collectFileTails(tails []*WatchFile) ([]reflect.SelectCase, *time.
 		}
 	}
 
-	return cases, ticker
+	return cases, []*time.Ticker{ticker, flush}
 }
 
-func (c *cmd) tailFiles(cases []reflect.SelectCase, tails []*WatchFile, ticker *time.Ticker) {
+func (c *cmd) tailFiles(cases []reflect.SelectCase, tails []*WatchFile, tickers []*time.Ticker) {
 	defer func() {
 		defer c.CapturePanic()
-		ticker.Stop()
+
+		for _, ticker := range tickers {
+			ticker.Stop()
+		}
+
 		c.Printf("==> All file watchers stopped.")
 		close(c.stopWatcher) // signal we're done.
 	}()
@@ -194,9 +272,18 @@ func (c *cmd) tailFiles(cases []reflect.SelectCase, tails []*WatchFile, ticker *
 		case !running:
 			tails = append(tails[:idx], tails[idx+1:]...) // The channel was closed? okay, remove it.
 			cases = append(cases[:idx], cases[idx+1:]...)
+			c.sendEvent(item)
 			died = c.killWatcher(item)
 		case idx == 1:
 			died = c.fileWatcherTicker(died)
+
+			for _, item := range c.scanGlobs() {
+				tails = append(tails, item)
+				cases = append(cases, reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(item.tail.Lines)})
+			}
+		case idx == 2: //nolint:gomnd
+			c.flushEvents(tails[specialCase:])
+			c.sendSummaries()
 		case data.IsNil(), data.IsZero(), !data.Elem().CanInterface():
 			c.Errorf("Got non-addressable file watcher data from %s", item.Path)
 			mnd.FileWatcher.Add(item.Path+" Errors", 1)
@@ -239,7 +326,7 @@ func (c *cmd) fileWatcherTicker(died bool) bool {
 	var stilldead bool
 
 	for _, item := range c.files {
-		if item.Active() || item.retries >= maxRetries {
+		if item.glob != "" || item.Active() || item.retries >= maxRetries {
 			continue
 		}
 
@@ -266,24 +353,47 @@ func (c *cmd) fileWatcherTicker(died bool) bool {
 // checkLineMatch runs when a watched file has a new line written.
 // If a match is found a notification is sent.
 func (c *cmd) checkLineMatch(line *tail.Line, tail *WatchFile) {
-	if tail.re == nil || line.Text == "" || !tail.re.MatchString(line.Text) {
+	text, ok := tail.sourceLine(line.Text)
+	if !ok {
+		return // only part of an entry.
+	}
+
+	if tail.multiline() {
+		c.checkEventLine(text, tail)
+		return
+	}
+
+	c.checkMatch(tail, []string{text})
+}
+
+// checkMatch checks a single line, or all the lines in a multi-line event, for a match.
+func (c *cmd) checkMatch(tail *WatchFile, lines []string) {
+	text := strings.Join(lines, "\n")
+
+	match := tail.match(text)
+	if match == nil {
 		return // no match
 	}
 
-	if tail.skip != nil && tail.Skip != "" && tail.skip.MatchString(line.Text) {
+	if tail.skip != nil && tail.Skip != "" && tail.skip.MatchString(text) {
 		mnd.FileWatcher.Add(tail.Path+" Skipped", 1)
 		return // skip matches
 	}
 
 	mnd.FileWatcher.Add(tail.Path+" Matched", 1)
+	c.runCommand(tail, match, text)
+
+	logLine := match.Line
+	if len(lines) > 1 {
+		match.Lines = lines
+		logLine = fmt.Sprintf("%s (+%d lines)", strings.TrimSpace(lines[0]), len(lines)-1)
+	}
 
-	match := &Match{
-		File:    tail.Path,
-		Line:    strings.TrimSpace(line.Text),
-		Matches: tail.re.FindAllString(line.Text, -1),
+	if match.Message != "" {
+		logLine = match.Message
 	}
 
-	if !c.limiter.Pour(1) {
+	if !tail.allow(match) {
 		mnd.FileWatcher.Add(tail.Path+" Dropped", 1)
 		return // rate limited.
 	}
@@ -292,7 +402,7 @@ func (c *cmd) checkLineMatch(line *tail.Line, tail *WatchFile) {
 		Route:      website.LogLineRoute,
 		Event:      website.EventFile,
 		LogPayload: tail.LogMatch,
-		LogMsg:     fmt.Sprintf("Watched-File Line Match: %s: %s", tail.Path, match.Line),
+		LogMsg:     fmt.Sprintf("Watched-File Line Match: %s: %s", tail.Path, logLine),
 		Payload:    match,
 	})
 }
@@ -313,6 +423,17 @@ func (c *cmd) addFileWatcher(file *WatchFile) error {
 		return err
 	}
 
+	if file.glob != "" {
+		children := c.scanGlob(file, true)
+		c.Printf("Watching Pattern: %s, files: %d", file.glob, len(children))
+
+		for _, child := range children {
+			c.addWatcher <- child
+		}
+
+		return nil
+	}
+
 	c.Printf("Watching File: %s, regexp: '%s' skip: '%s' poll:%v pipe:%v must:%v log:%v",
 		file.Path, file.Regexp, file.Skip, file.Poll, file.Pipe, file.MustExist, file.LogMatch)
 
@@ -328,6 +449,10 @@ func (w *WatchFile) Stop() error {
 
 	w.retries = maxRetries // so it will not get "restarted" after manually being stopped.
 
+	if w.glob != "" {
+		return w.stopGlob()
+	}
+
 	if err := w.stop(); err != nil {
 		return err
 	}
@@ -364,14 +489,59 @@ func (w *WatchFile) deactivate() error {
 	return w.stop()
 }
 
+// KeepSettings copies the settings that are not configurable in the GUI from another watcher.
+func (w *WatchFile) KeepSettings(from *WatchFile) {
+	w.Start = from.Start
+	w.Continue = from.Continue
+	w.Indent = from.Indent
+	w.MaxLines = from.MaxLines
+	w.Timeout = from.Timeout
+	w.JSON = from.JSON
+	w.Where = from.Where
+	w.Format = from.Format
+	w.Command = from.Command
+	w.Args = from.Args
+	w.Cooldown = from.Cooldown
+	w.Burst = from.Burst
+	w.Rate = from.Rate
+	w.Summary = from.Summary
+}
+
 // Active returns true if the tail channel is still open.
+// A glob or directory watcher is active until it's stopped.
 func (w *WatchFile) Active() bool {
 	w.mu.RLock()
 	defer w.mu.RUnlock()
 
+	if w.glob != "" {
+		return w.globbed != nil
+	}
+
 	return w.tail != nil
 }
 
+// stopGlob stops watching every file found by a glob or directory watcher.
+func (w *WatchFile) stopGlob() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	var errs []string
+
+	for path, child := range w.globbed {
+		if err := child.Stop(); err != nil {
+			errs = append(errs, path+": "+err.Error())
+		}
+	}
+
+	w.globbed = nil
+
+	if len(errs) > 0 {
+		return fmt.Errorf("%w: %s", ErrStopGlob, strings.Join(errs, ", "))
+	}
+
+	return nil
+}
+
 // stop stops a file watcher.
 func (w *WatchFile) stop() error {
 	w.mu.RLock()
@@ -0,0 +1,164 @@
+package filewatch
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/Notifiarr/notifiarr/pkg/mnd"
+)
+
+/* A watcher path may be a glob pattern or a directory. Every file that matches is watched
+   with the same settings. The retry ticker looks for new files to watch, and stops watching
+   files that were removed. Files found after the watcher starts are read from the beginning.
+*/
+
+// globPattern returns the pattern to watch, or an empty string if the path is a single file.
+// A directory watches every file in it. A path is only a pattern if it has a * or ?, because
+// [ is common in file names; character classes work in a pattern that has one of those.
+func globPattern(path string) string {
+	if strings.ContainsAny(path, "*?") {
+		return path
+	}
+
+	if info, err := os.Stat(path); err == nil && info.IsDir() {
+		return filepath.Join(path, "*")
+	}
+
+	return ""
+}
+
+// globFiles returns the files that match the pattern. Directories are skipped.
+func (w *WatchFile) globFiles() map[string]bool {
+	paths, _ := filepath.Glob(w.glob)
+	files := make(map[string]bool)
+
+	for _, path := range paths {
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			files[path] = true
+		}
+	}
+
+	return files
+}
+
+// child returns a watcher for one file that matches a glob watcher's pattern.
+func (w *WatchFile) child(path string, fromStart bool) *WatchFile {
+	child := &WatchFile{
+		Path:      path,
+		Regexp:    w.Regexp,
+		Skip:      w.Skip,
+		Poll:      w.Poll,
+		Pipe:      w.Pipe,
+		MustExist: true,
+		LogMatch:  w.LogMatch,
+		parent:    w,
+		fromStart: fromStart,
+	}
+	child.KeepSettings(w)
+
+	return child
+}
+
+// owner returns the watcher from the config; that's the glob watcher for a file found by a pattern.
+func (w *WatchFile) owner() *WatchFile {
+	if w.parent != nil {
+		return w.parent
+	}
+
+	return w
+}
+
+// Files returns the files being watched by a glob or directory watcher.
+func (w *WatchFile) Files() []string {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	files := make([]string, 0, len(w.globbed))
+	for path := range w.globbed {
+		files = append(files, path)
+	}
+
+	sort.Strings(files)
+
+	return files
+}
+
+// scanGlob starts watching new files that match a glob watcher's pattern, and stops watching removed files.
+// Files that stopped for another reason are restarted. Returns the watchers that need to be added to the select loop.
+func (c *cmd) scanGlob(item *WatchFile, initial bool) []*WatchFile {
+	files := item.globFiles()
+
+	item.mu.Lock()
+	defer item.mu.Unlock()
+
+	if item.globbed == nil {
+		return nil // stopped.
+	}
+
+	added := []*WatchFile{}
+
+	for path, child := range item.globbed {
+		switch {
+		case !files[path]:
+			delete(item.globbed, path)
+			c.Printf("==> File removed, no longer watching: %s (pattern: %s)", path, item.glob)
+
+			if err := child.Stop(); err != nil {
+				c.Errorf("Stopping File Watcher: %s: %v", path, err)
+			}
+		case !child.Active() && child.retries < maxRetries:
+			retries := child.retries + 1
+			mnd.FileWatcher.Add(path+" Retries", 1)
+
+			if err := child.setup(&logger{Logger: c.Config.Logger}); err != nil {
+				// setup gives up on the file after any error; the pattern was validated, so try again.
+				child.retries = retries
+				c.Errorf("Restarting File Watcher (retries: %d): %s: %v", child.retries, path, err)
+
+				continue
+			}
+
+			mnd.FileWatcher.Add(path+" Restarts", 1)
+			added = append(added, child)
+		}
+	}
+
+	for path := range files {
+		if _, ok := item.globbed[path]; ok {
+			continue
+		}
+
+		child := item.child(path, !initial)
+		item.globbed[path] = child // saved if it fails, so it gets retried.
+
+		if err := child.setup(&logger{Logger: c.Config.Logger}); err != nil {
+			child.retries = 0
+			c.Errorf("Unable to watch file %v", err)
+
+			continue
+		}
+
+		if !initial {
+			c.Printf("==> New file matches pattern %s, watching: %s", item.glob, path)
+		}
+
+		added = append(added, child)
+	}
+
+	return added
+}
+
+// scanGlobs checks every glob watcher for new and removed files.
+func (c *cmd) scanGlobs() []*WatchFile {
+	added := []*WatchFile{}
+
+	for _, item := range c.files {
+		if item.glob != "" {
+			added = append(added, c.scanGlob(item, false)...)
+		}
+	}
+
+	return added
+}
@@ -0,0 +1,27 @@
+package filewatch
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGlobPattern(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	file := filepath.Join(dir, "show [1080p].log")
+	require.NoError(t, os.WriteFile(file, []byte("line\n"), 0o600))
+
+	assert.Equal(t, filepath.Join(dir, "*"), globPattern(dir), "a directory watches every file in it")
+	assert.Equal(t, "", globPattern(file), "brackets in a file name are not a pattern")
+	assert.Equal(t, "", globPattern(filepath.Join(dir, "missing.log")))
+	assert.Equal(t, dir+"/*.log", globPattern(dir+"/*.log"))
+	assert.Equal(t, dir+"/show [0-9]?.log", globPattern(dir+"/show [0-9]?.log"))
+
+	watch := &WatchFile{glob: filepath.Join(dir, "*.log")}
+	assert.Equal(t, map[string]bool{file: true}, watch.globFiles())
+}
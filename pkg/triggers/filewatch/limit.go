@@ -0,0 +1,83 @@
+package filewatch
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Notifiarr/notifiarr/pkg/mnd"
+	"github.com/Notifiarr/notifiarr/pkg/website"
+	"github.com/hako/durafmt"
+	"github.com/nxadm/tail/ratelimiter"
+)
+
+/* Every watcher has its own rate limit, so one noisy file does not keep the others from sending.
+   Matches over the limit are dropped, unless a summary period is set. Then they're counted,
+   and one summary is sent for them at the end of the period. Files found by a glob watcher
+   share the glob watcher's limit.
+*/
+
+// setupLimiter creates the rate limiter from the burst and rate settings.
+func (w *WatchFile) setupLimiter() {
+	burst, rate := uint16(burstRate), requestPer
+
+	if w.Burst > 0 {
+		burst = uint16(w.Burst)
+	}
+
+	if w.Rate.Duration > 0 {
+		rate = w.Rate.Duration
+	}
+
+	w.limiter = ratelimiter.NewLeakyBucket(burst, rate)
+}
+
+// allow returns true if a match may be sent. Otherwise it's counted for the summary.
+func (w *WatchFile) allow(match *Match) bool {
+	owner := w.owner()
+	if owner.limiter == nil || owner.limiter.Pour(1) {
+		return true
+	}
+
+	if owner.Summary.Duration <= 0 {
+		return false
+	}
+
+	if owner.dropped == 0 {
+		owner.droppedAt = time.Now()
+	}
+
+	owner.dropped++
+	owner.lastDrop = match
+
+	return false
+}
+
+// sendSummaries sends a summary for every watcher with dropped matches after its summary period.
+func (c *cmd) sendSummaries() {
+	now := time.Now()
+
+	for _, item := range c.files {
+		if item.dropped == 0 || now.Sub(item.droppedAt) < item.Summary.Duration {
+			continue
+		}
+
+		period := durafmt.ParseShort(item.Summary.Duration).String()
+		match := &Match{
+			File:    item.Path,
+			Matches: item.lastDrop.Matches,
+			Line:    item.lastDrop.Line,
+			Message: fmt.Sprintf("%d more matches in the last %s", item.dropped, period),
+			Dropped: item.dropped,
+		}
+		item.dropped, item.lastDrop = 0, nil
+
+		mnd.FileWatcher.Add(item.Path+" Summaries", 1)
+		c.SendData(&website.Request{
+			Route:      website.LogLineRoute,
+			Event:      website.EventFile,
+			LogPayload: item.LogMatch,
+			LogMsg:     fmt.Sprintf("Watched-File Match Summary: %s: %s", item.Path, match.Message),
+			Payload:    match,
+		})
+	}
+}
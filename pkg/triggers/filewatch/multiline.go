@@ -0,0 +1,114 @@
+package filewatch
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+/* Multi-line mode assembles stack traces and other multi-line log entries into one event.
+   The event is matched and sent when the next event starts, when it reaches max_lines,
+   or when no new lines are written before the timeout.
+*/
+
+const (
+	defaultMaxLines = 100
+	defaultTimeout  = 2 * time.Second
+)
+
+// multiline returns true if multi-line mode is enabled.
+func (w *WatchFile) multiline() bool {
+	return w.Start != "" || w.Continue != "" || w.Indent
+}
+
+func (w *WatchFile) setupMultiline() error {
+	var err error
+
+	if w.start, err = regexp.Compile(w.Start); err != nil {
+		return fmt.Errorf("%w: regexp start compile failed, ignored: %s", ErrInvalidRegexp, w.Path)
+	} else if w.cont, err = regexp.Compile(w.Continue); err != nil {
+		return fmt.Errorf("%w: regexp continue compile failed, ignored: %s", ErrInvalidRegexp, w.Path)
+	}
+
+	w.event = nil
+
+	return nil
+}
+
+func (w *WatchFile) maxLines() int {
+	if w.MaxLines > 0 {
+		return w.MaxLines
+	}
+
+	return defaultMaxLines
+}
+
+func (w *WatchFile) timeout() time.Duration {
+	if w.Timeout.Duration > 0 {
+		return w.Timeout.Duration
+	}
+
+	return defaultTimeout
+}
+
+// continues returns true if a line belongs to the event before it.
+// With only a start regexp, every line that does not start an event continues the last one.
+func (w *WatchFile) continues(text string) bool {
+	switch {
+	case w.Indent && (strings.HasPrefix(text, " ") || strings.HasPrefix(text, "\t")):
+		return true
+	case w.Continue != "":
+		return w.cont.MatchString(text)
+	case w.Start != "" && !w.Indent:
+		return !w.start.MatchString(text)
+	default:
+		return false
+	}
+}
+
+// checkEventLine adds a line to the current event, or sends the current event and starts a new one.
+// Lines that do not start an event, and do not continue one, are checked by themselves.
+func (c *cmd) checkEventLine(text string, tail *WatchFile) {
+	if len(tail.event) > 0 && tail.continues(text) {
+		if tail.event = append(tail.event, text); len(tail.event) >= tail.maxLines() {
+			c.sendEvent(tail)
+		} else {
+			tail.eventAt = time.Now()
+		}
+
+		return
+	}
+
+	c.sendEvent(tail) // the previous event is complete.
+
+	if tail.Start != "" && !tail.start.MatchString(text) {
+		c.checkMatch(tail, []string{text})
+		return
+	}
+
+	tail.event, tail.eventAt = []string{text}, time.Now()
+}
+
+// sendEvent checks the lines in the current event for a match, and clears the event.
+func (c *cmd) sendEvent(tail *WatchFile) {
+	if len(tail.event) == 0 {
+		return
+	}
+
+	lines := tail.event
+	tail.event = nil
+
+	c.checkMatch(tail, lines)
+}
+
+// flushEvents sends the events that did not get a new line before their timeout.
+func (c *cmd) flushEvents(tails []*WatchFile) {
+	now := time.Now()
+
+	for _, tail := range tails {
+		if len(tail.event) > 0 && now.Sub(tail.eventAt) >= tail.timeout() {
+			c.sendEvent(tail)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package filewatch
+
+import (
+	"expvar"
+	"regexp"
+	"testing"
+
+	"github.com/Notifiarr/notifiarr/pkg/mnd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContinues(t *testing.T) {
+	t.Parallel()
+
+	assert := assert.New(t)
+	continues := func(tail *WatchFile, text string) bool {
+		require.NoError(t, tail.setupMultiline())
+		return tail.continues(text)
+	}
+
+	assert.True(continues(&WatchFile{Indent: true}, "\tat main.go:10"), "tabs are indents")
+	assert.True(continues(&WatchFile{Indent: true}, "  at main.go:10"), "spaces are indents")
+	assert.False(continues(&WatchFile{Indent: true}, "2023-01-02 ERROR"))
+	assert.True(continues(&WatchFile{Continue: `^Caused by`}, "Caused by: boom"))
+	assert.False(continues(&WatchFile{Continue: `^Caused by`}, "2023-01-02 INFO"))
+	assert.True(continues(&WatchFile{Start: `^\d{4}-`}, "panic: boom"), "with only start, anything else continues")
+	assert.False(continues(&WatchFile{Start: `^\d{4}-`}, "2023-01-02 INFO"))
+	assert.False(continues(&WatchFile{Start: `^\d{4}-`, Indent: true}, "panic: boom"), "indent limits what continues")
+	assert.True(continues(&WatchFile{Continue: `^Caused`, Indent: true}, " x"), "either indent or continue works")
+}
+
+func TestCheckEventLine(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		tail    *WatchFile
+		lines   []string
+		checked int64    // events (and single lines) checked for a match.
+		pending []string // event still being assembled.
+	}{
+		"indented stack trace": {
+			tail:    &WatchFile{Indent: true},
+			lines:   []string{"ERROR boom", "\tat a", "\tat b", "INFO next"},
+			checked: 1,
+			pending: []string{"INFO next"},
+		},
+		"start lines begin events": {
+			tail:    &WatchFile{Start: `^\d{4}-`},
+			lines:   []string{"2023-01-01 ERROR", "panic: boom", "goroutine 1", "2023-01-02 INFO"},
+			checked: 1,
+			pending: []string{"2023-01-02 INFO"},
+		},
+		"lines before the first start are checked alone": {
+			tail:    &WatchFile{Start: `^\d{4}-`, Continue: `^\s`},
+			lines:   []string{"garbage", "more garbage", "2023-01-01 ERROR", " detail"},
+			checked: 2,
+			pending: []string{"2023-01-01 ERROR", " detail"},
+		},
+		"other lines end an event": {
+			tail:    &WatchFile{Start: `^\d{4}-`, Continue: `^\s`},
+			lines:   []string{"2023-01-01 ERROR", " detail", "garbage"},
+			checked: 2,
+		},
+		"max lines sends the event": {
+			tail:    &WatchFile{Indent: true, MaxLines: 3},
+			lines:   []string{"ERROR boom", " a", " b", " c"},
+			checked: 1,
+			pending: []string{" c"}, // there is no event to continue after max lines, so this starts one.
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			// Every event matches and is skipped, so the skip counter counts the events checked.
+			tail := test.tail
+			tail.Path = "multiline test: " + name
+			tail.Regexp, tail.re = ".", regexp.MustCompile(".")
+			tail.Skip, tail.skip = ".", regexp.MustCompile(".")
+			require.NoError(t, tail.setupMultiline())
+
+			c := &cmd{}
+			for _, line := range test.lines {
+				c.checkEventLine(line, tail)
+			}
+
+			checked, _ := mnd.FileWatcher.Get(tail.Path + " Skipped").(*expvar.Int)
+			if test.checked == 0 {
+				assert.Nil(t, checked)
+			} else if assert.NotNil(t, checked) {
+				assert.Equal(t, test.checked, checked.Value())
+			}
+
+			assert.Equal(t, test.pending, tail.event)
+		})
+	}
+}
@@ -0,0 +1,261 @@
+package filewatch
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/nxadm/tail"
+)
+
+/* A watcher path may be a log source instead of a file.
+   journal: reads systemd-journald entries from journalctl, or from a file in json or export format.
+     journal:sonarr.service, journal:unit=sonarr.service, journal:identifier=sshd, journal:/path/to/export.log
+   docker: tails a container's json-file log by container name or ID. Like docker:sonarr
+   The regexp matches the log message. The other values in the entry are fields, like PRIORITY or stream.
+*/
+
+const (
+	journalPrefix = "journal:"
+	dockerPrefix  = "docker:"
+	maxLineSize   = 1024 * 1024 // journal entries longer than this are skipped.
+)
+
+// dockerRoot is the docker data folder; container logs are in the containers folder inside it.
+var dockerRoot = "/var/lib/docker" //nolint:gochecknoglobals
+
+var ErrNoContainer = fmt.Errorf("docker container not found")
+
+// open starts reading the file or log source in the path.
+func (w *WatchFile) open(logger *logger) (*tail.Tail, error) {
+	switch {
+	case strings.HasPrefix(w.Path, dockerPrefix):
+		path, err := dockerLogPath(strings.TrimPrefix(w.Path, dockerPrefix))
+		if err != nil {
+			return nil, err
+		}
+
+		return w.tailFile(path, logger)
+	case !strings.HasPrefix(w.Path, journalPrefix):
+		return w.tailFile(w.Path, logger)
+	}
+
+	if spec := strings.TrimPrefix(w.Path, journalPrefix); filepath.IsAbs(spec) {
+		return w.tailFile(spec, logger)
+	}
+
+	return w.journalctl()
+}
+
+// source returns true if the path is a journal or docker log source.
+func (w *WatchFile) source() bool {
+	return strings.HasPrefix(w.Path, journalPrefix) || strings.HasPrefix(w.Path, dockerPrefix)
+}
+
+func (w *WatchFile) tailFile(path string, logger *logger) (*tail.Tail, error) {
+	location := &tail.SeekInfo{Whence: io.SeekEnd}
+	if w.fromStart {
+		location.Whence = io.SeekStart
+	}
+
+	return tail.TailFile(path, tail.Config{ //nolint:wrapcheck
+		Follow:        true,
+		ReOpen:        true,
+		MustExist:     w.MustExist,
+		Poll:          w.Poll,
+		Pipe:          w.Pipe,
+		CompleteLines: true,
+		Location:      location,
+		Logger:        logger,
+	})
+}
+
+// journalArgs returns the journalctl arguments for the units and identifiers in the path.
+func (w *WatchFile) journalArgs() []string {
+	args := []string{"--follow", "--output=json", "--lines=0", "--no-pager"}
+
+	for _, filter := range strings.Split(strings.TrimPrefix(w.Path, journalPrefix), ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(filter), "=")
+		if !ok {
+			key, value = "unit", key
+		}
+
+		switch value = strings.TrimSpace(value); {
+		case value == "":
+			continue
+		case key == "identifier":
+			args = append(args, "--identifier="+value)
+		default:
+			args = append(args, "--unit="+value)
+		}
+	}
+
+	return args
+}
+
+// journalctl runs journalctl and sends the json entries it writes on a tail's lines channel.
+// Stopping the tail stops journalctl.
+func (w *WatchFile) journalctl() (*tail.Tail, error) {
+	cmd := exec.Command("journalctl", w.journalArgs()...) //nolint:gosec
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("journalctl: %w", err)
+	}
+
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("journalctl: %w", err)
+	}
+
+	stream := &tail.Tail{Filename: w.Path, Lines: make(chan *tail.Line)}
+
+	go func() {
+		<-stream.Dying()
+		_ = cmd.Process.Kill()
+	}()
+
+	go func() {
+		defer stream.Done()
+		defer close(stream.Lines)
+
+		scanner := bufio.NewScanner(stdout)
+		scanner.Buffer(make([]byte, bufio.MaxScanTokenSize), maxLineSize)
+
+		for scanner.Scan() {
+			select {
+			case stream.Lines <- &tail.Line{Text: scanner.Text(), Time: time.Now()}:
+			case <-stream.Dying():
+				_ = cmd.Wait()
+				return
+			}
+		}
+
+		err := cmd.Wait()
+		select {
+		case <-stream.Dying(): // stopped.
+		default:
+			stream.Kill(fmt.Errorf("journalctl exited: %w", err))
+		}
+	}()
+
+	return stream, nil
+}
+
+// dockerLogPath returns the json log file for a container name or ID.
+func dockerLogPath(name string) (string, error) {
+	folder := filepath.Join(dockerRoot, "containers")
+
+	dirs, err := os.ReadDir(folder)
+	if err != nil {
+		return "", fmt.Errorf("%w: %s: %v", ErrNoContainer, name, err) //nolint:errorlint
+	}
+
+	ids := []string{}
+
+	for _, dir := range dirs {
+		switch id := dir.Name(); {
+		case !dir.IsDir():
+			continue
+		case containerName(filepath.Join(folder, id)) == name:
+			return filepath.Join(folder, id, id+"-json.log"), nil
+		case strings.HasPrefix(id, name):
+			ids = append(ids, id)
+		}
+	}
+
+	if len(ids) != 1 {
+		return "", fmt.Errorf("%w: %s: %d containers with this ID", ErrNoContainer, name, len(ids))
+	}
+
+	return filepath.Join(folder, ids[0], ids[0]+"-json.log"), nil
+}
+
+// containerName returns the name of the container in a docker container folder.
+func containerName(folder string) string {
+	data, err := os.ReadFile(filepath.Join(folder, "config.v2.json"))
+	if err != nil {
+		return ""
+	}
+
+	var config struct {
+		Name string `json:"Name"`
+	}
+
+	_ = json.Unmarshal(data, &config)
+
+	return strings.TrimPrefix(config.Name, "/")
+}
+
+// sourceLine returns the log message in a line from a journal or docker source, and saves the other values
+// in the entry as fields. Returns false if the line is only part of an entry.
+func (w *WatchFile) sourceLine(text string) (string, bool) {
+	switch {
+	case strings.HasPrefix(w.Path, dockerPrefix):
+		return w.dockerLine(text)
+	case strings.HasPrefix(w.Path, journalPrefix):
+		return w.journalLine(text)
+	default:
+		return text, true
+	}
+}
+
+// dockerLine returns the message in a docker json log line. Docker splits long lines into
+// several entries, and only the last one ends with a newline.
+func (w *WatchFile) dockerLine(text string) (string, bool) {
+	var entry struct {
+		Log    string `json:"log"`
+		Stream string `json:"stream"`
+		Time   string `json:"time"`
+	}
+
+	if err := json.Unmarshal([]byte(text), &entry); err != nil {
+		w.lineFields = nil
+		return text, true
+	}
+
+	if w.partial += entry.Log; !strings.HasSuffix(entry.Log, "\n") && len(w.partial) < maxLineSize {
+		return "", false
+	}
+
+	text, w.partial = strings.TrimRight(w.partial, "\r\n"), ""
+	w.lineFields = map[string]string{
+		"container": strings.TrimPrefix(w.Path, dockerPrefix),
+		"stream":    entry.Stream,
+		"time":      entry.Time,
+	}
+
+	return text, true
+}
+
+// journalLine returns the MESSAGE in a journal entry. Entries from journalctl are json.
+// Entries in export format are field=value lines with an empty line after each entry.
+func (w *WatchFile) journalLine(text string) (string, bool) {
+	if fields := jsonFields(text); fields != nil {
+		w.lineFields = fields
+		return fields["MESSAGE"], true
+	}
+
+	if key, value, ok := strings.Cut(text, "="); ok && key != "" {
+		if w.entry == nil {
+			w.entry = make(map[string]string)
+		}
+
+		w.entry[key] = value
+
+		return "", false
+	}
+
+	if strings.TrimSpace(text) != "" || w.entry == nil {
+		return "", false // binary fields are not supported.
+	}
+
+	w.lineFields, w.entry = w.entry, nil
+
+	return w.lineFields["MESSAGE"], true
+}
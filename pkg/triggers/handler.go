@@ -54,6 +54,8 @@ func (a *Actions) runTrigger(input *common.ActionInput, trigger, content string)
 		return a.cfsync(input, content)
 	case "rpsync":
 		return a.rpsync(input, content)
+	case "trashlocal":
+		return a.trashlocal(input)
 	case "services":
 		return a.services(input)
 	case "sessions":
@@ -76,6 +78,20 @@ func (a *Actions) runTrigger(input *common.ActionInput, trigger, content string)
 		return a.notification(content)
 	case "emptyplextrash":
 		return a.emptyplextrash(input, content)
+	case "plexscan":
+		return a.plexscan(input)
+	case "seeding":
+		return a.seeding(input)
+	case "fixstuck":
+		return a.fixstuck(input)
+	case "calendar":
+		return a.calendar(input)
+	case "backlog":
+		return a.backlog(input)
+	case "compare":
+		return a.compare(input)
+	case "starrsync":
+		return a.starrsync(input)
 	default:
 		return http.StatusBadRequest, "Unknown trigger provided:'" + trigger + "'"
 	}
@@ -366,3 +382,142 @@ func (a *Actions) emptyplextrash(input *common.ActionInput, content string) (int
 
 	return http.StatusOK, "Emptying Plex Trash for library " + content
 }
+
+// @Description  Requests a partial Plex library scan for one or more folders.
+// @Description  Folders are translated with the Plex path maps, then matched to the library that contains them.
+// @Summary      Scan Plex Library Folders
+// @Tags         Triggers,Plex
+// @Produce      json
+// @Param        args formData []string true "provide folders as multiple 'args' paramers in POST body" collectionFormat(multi) example(args=/movies/Movie (2020))
+// @Accept       application/x-www-form-urlencoded
+// @Success      200  {object} apps.Respond.apiResponse{message=string} "started"
+// @Failure      400  {object} apps.Respond.apiResponse{message=string} "no folders provided"
+// @Failure      501  {object} apps.Respond.apiResponse{message=string} "plex not enabled"
+// @Failure      404  {object} string "bad token or api key"
+// @Router       /api/trigger/plexscan [post]
+// @Security     ApiKeyAuth
+//
+//nolint:lll
+func (a *Actions) plexscan(input *common.ActionInput) (int, string) {
+	if !a.Timers.Apps.Plex.Enabled() {
+		return http.StatusNotImplemented, "Plex is not enabled."
+	} else if len(input.Args) == 0 {
+		return http.StatusBadRequest, "No folders provided."
+	}
+
+	a.PlexScan.Scan(input.Type, input.Args...)
+
+	return http.StatusOK, "Scanning Plex library folders: " + strings.Join(input.Args, ", ")
+}
+
+// @Description  Applies the torrent seeding rules now. Check the seeding report for the results.
+// @Summary      Apply Seeding Rules
+// @Tags         Triggers
+// @Produce      json
+// @Success      200  {object} apps.Respond.apiResponse{message=string} "started"
+// @Failure      501  {object} apps.Respond.apiResponse{message=string} "no seeding rules"
+// @Failure      404  {object} string "bad token or api key"
+// @Router       /api/trigger/seeding [get]
+// @Security     ApiKeyAuth
+func (a *Actions) seeding(input *common.ActionInput) (int, string) {
+	if !a.Seeding.Run(input.Type) {
+		return http.StatusNotImplemented, "No seeding rules configured."
+	}
+
+	return http.StatusOK, "Seeding rules triggered."
+}
+
+// @Description  Checks the Starr app queues for stuck items and fixes them with the stuck queue rules now.
+// @Summary      Fix Stuck Queue Items
+// @Tags         Triggers
+// @Produce      json
+// @Success      200  {object} apps.Respond.apiResponse{message=string} "started"
+// @Failure      501  {object} apps.Respond.apiResponse{message=string} "no stuck queue rules"
+// @Failure      404  {object} string "bad token or api key"
+// @Router       /api/trigger/fixstuck [get]
+// @Security     ApiKeyAuth
+func (a *Actions) fixstuck(input *common.ActionInput) (int, string) {
+	if !a.StarrQueue.FixStuckItems(input.Type) {
+		return http.StatusNotImplemented, "No stuck queue rules configured."
+	}
+
+	return http.StatusOK, "Fixing stuck queue items."
+}
+
+// @Description  Sends the upcoming releases digest from every Starr app to the website.
+// @Summary      Send Upcoming Releases Digest
+// @Tags         Triggers
+// @Produce      json
+// @Success      200  {object} apps.Respond.apiResponse{message=string} "success"
+// @Failure      404  {object} string "bad token or api key"
+// @Router       /api/trigger/calendar [get]
+// @Security     ApiKeyAuth
+func (a *Actions) calendar(input *common.ActionInput) (int, string) {
+	a.Calendar.Send(input.Type)
+	return http.StatusOK, "Upcoming releases digest initiated."
+}
+
+// @Description  Searches a batch of wanted missing and cutoff unmet items in every Starr app.
+// @Description  Items searched recently are skipped, and the daily search limit is respected.
+// @Summary      Search Starr App Backlogs
+// @Tags         Triggers
+// @Produce      json
+// @Success      200  {object} apps.Respond.apiResponse{message=string} "started"
+// @Failure      501  {object} apps.Respond.apiResponse{message=string} "backlog search not enabled"
+// @Failure      404  {object} string "bad token or api key"
+// @Router       /api/trigger/backlog [get]
+// @Security     ApiKeyAuth
+func (a *Actions) backlog(input *common.ActionInput) (int, string) {
+	if !a.Backlog.Run(input.Type) {
+		return http.StatusNotImplemented, "Backlog search is not enabled."
+	}
+
+	return http.StatusOK, "Backlog search initiated."
+}
+
+// @Description  Compares the Radarr and Sonarr libraries across instances, and sends the differences to the website.
+// @Summary      Send Library Compare Report
+// @Tags         Triggers
+// @Produce      json
+// @Success      200  {object} apps.Respond.apiResponse{message=string} "success"
+// @Failure      404  {object} string "bad token or api key"
+// @Router       /api/trigger/compare [get]
+// @Security     ApiKeyAuth
+func (a *Actions) compare(input *common.ActionInput) (int, string) {
+	a.Compare.Send(input.Type)
+	return http.StatusOK, "Library compare initiated."
+}
+
+// @Description  Runs the Starr sync rules: mirrors new items, monitored state and deletions to target instances.
+// @Summary      Run Starr Sync Rules
+// @Tags         Triggers
+// @Produce      json
+// @Success      200  {object} apps.Respond.apiResponse{message=string} "started"
+// @Failure      501  {object} apps.Respond.apiResponse{message=string} "no sync rules"
+// @Failure      404  {object} string "bad token or api key"
+// @Router       /api/trigger/starrsync [get]
+// @Security     ApiKeyAuth
+func (a *Actions) starrsync(input *common.ActionInput) (int, string) {
+	if !a.StarrSync.Run(input.Type) {
+		return http.StatusNotImplemented, "No starr sync rules configured."
+	}
+
+	return http.StatusOK, "Starr sync initiated."
+}
+
+// @Description  Applies custom formats, scores and release profiles from the local TRaSH guide files and mapping file.
+// @Summary      Run Local TRaSH Sync
+// @Tags         Triggers,TRaSH
+// @Produce      json
+// @Success      200  {object} apps.Respond.apiResponse{message=string} "started"
+// @Failure      501  {object} apps.Respond.apiResponse{message=string} "local trash sync not configured"
+// @Failure      404  {object} string "bad token or api key"
+// @Router       /api/trigger/trashlocal [get]
+// @Security     ApiKeyAuth
+func (a *Actions) trashlocal(input *common.ActionInput) (int, string) {
+	if !a.CFSync.SyncLocal(input.Type) {
+		return http.StatusNotImplemented, "Local TRaSH sync is not configured."
+	}
+
+	return http.StatusOK, "Local TRaSH sync initiated."
+}
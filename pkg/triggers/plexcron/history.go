@@ -0,0 +1,312 @@
+package plexcron
+
+import (
+	"context"
+	"math/rand"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Notifiarr/notifiarr/pkg/apps/apppkg/plex"
+	"github.com/Notifiarr/notifiarr/pkg/triggers/common"
+	"github.com/Notifiarr/notifiarr/pkg/triggers/data"
+	"golift.io/cnfg"
+)
+
+/* The session history keeps a bounded list of finished Plex sessions, so we can report watch time without Tautulli. */
+
+const (
+	historyFile         = "plex_history.json"
+	defaultReportDays   = 30
+	defaultHistoryCount = 100
+)
+
+// TrigPlexHistory polls Plex for sessions to keep the session history up to date.
+const TrigPlexHistory common.TriggerName = "Tracking Plex session history."
+
+// HistoryItem is one Plex session (playback) in the local session history.
+type HistoryItem struct {
+	ID       string        `json:"id"`
+	User     string        `json:"user"`
+	Title    string        `json:"title"`
+	Type     string        `json:"type"`
+	Library  string        `json:"library"`
+	Player   string        `json:"player"`
+	Decision string        `json:"decision"` // direct play, direct stream or transcode.
+	Start    time.Time     `json:"start"`
+	Stop     time.Time     `json:"stop"`
+	Duration cnfg.Duration `json:"duration"` // time spent playing; does not include paused time.
+	last     time.Time     // last time this (active) session was seen.
+}
+
+// WatchTime is the total count and duration of plays in a history report.
+type WatchTime struct {
+	Plays    int           `json:"plays"`
+	Duration cnfg.Duration `json:"duration"`
+}
+
+// HistoryReport is the watch time from the local Plex session history, totaled by user, library and day.
+type HistoryReport struct {
+	Since     time.Time             `json:"since"`
+	Total     WatchTime             `json:"total"`
+	Users     map[string]*WatchTime `json:"users"`
+	Libraries map[string]*WatchTime `json:"libraries"`
+	Days      map[string]*WatchTime `json:"days"` // keyed by date: 2006-01-02
+}
+
+type history struct {
+	size   int
+	active map[string]*HistoryItem
+	items  []*HistoryItem // oldest first.
+	mu     sync.RWMutex
+}
+
+// runHistory loads the saved session history and starts a timer to keep it updated.
+func (c *cmd) runHistory() {
+	if c.Plex.HistorySize <= 0 {
+		return
+	}
+
+	c.history = &history{size: c.Plex.HistorySize, active: make(map[string]*HistoryItem)}
+	if err := c.ReadDataFile(historyFile, &c.history.items); err != nil {
+		c.Errorf("[PLEX] Loading session history: %v", err)
+	}
+
+	c.history.trim()
+	data.Save("plexHistoryReport", c.history.report(time.Now().AddDate(0, 0, -defaultReportDays)))
+	c.Printf("==> Plex Session History Started, interval:1m size:%d saved:%d", c.history.size, len(c.history.items))
+	c.Add(&common.Action{
+		Name: TrigPlexHistory,
+		Hide: true, // do not log this one.
+		Fn:   c.trackHistory,
+		T:    time.NewTicker(time.Minute + time.Duration(rand.Intn(randomMilliseconds2))*time.Millisecond), //nolint:gosec
+	})
+}
+
+// trackHistory polls sessions. The history is updated by the session tracker when new sessions are collected.
+func (c *cmd) trackHistory(ctx context.Context, _ *common.ActionInput) {
+	if _, err := c.getSessions(ctx, time.Minute); err != nil {
+		c.Errorf("[PLEX] Getting Sessions for history from %s: %v", c.Plex.URL, err)
+	}
+}
+
+// updateHistory adds new sessions to the history, and finishes the ones that are gone.
+// The history is saved to disk any time a session finishes.
+func (c *cmd) updateHistory(sessions []*plex.Session, now time.Time) {
+	if c.history == nil {
+		return
+	}
+
+	if !c.history.update(sessions, now) {
+		return
+	}
+
+	c.history.mu.RLock()
+	err := c.WriteDataFile(historyFile, c.history.items)
+	c.history.mu.RUnlock()
+
+	if err != nil {
+		c.Errorf("[PLEX] Saving session history: %v", err)
+	}
+
+	data.Save("plexHistoryReport", c.history.report(now.AddDate(0, 0, -defaultReportDays)))
+}
+
+// update returns true if any sessions finished.
+func (h *history) update(sessions []*plex.Session, now time.Time) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	current := make(map[string]struct{})
+
+	for _, session := range sessions {
+		id := session.Session.ID + session.SessionKey
+		current[id] = struct{}{}
+
+		item, ok := h.active[id]
+		if !ok {
+			item = newHistoryItem(id, session, now)
+			h.active[id] = item
+		} else if session.Player.State == playing {
+			item.Duration.Duration += now.Sub(item.last).Round(time.Second)
+		}
+
+		if decision := playDecision(session); decision != "direct play" {
+			item.Decision = decision // sessions may start transcoding after they start.
+		}
+
+		item.last = now
+	}
+
+	finished := false
+
+	for id, item := range h.active {
+		if _, ok := current[id]; ok {
+			continue
+		}
+
+		item.Stop = item.last
+		h.items = append(h.items, item)
+		finished = true
+
+		delete(h.active, id)
+	}
+
+	h.trim()
+
+	return finished
+}
+
+// trim keeps the history bounded. Must be locked, or not in use yet.
+func (h *history) trim() {
+	if len(h.items) > h.size {
+		h.items = h.items[len(h.items)-h.size:]
+	}
+}
+
+func newHistoryItem(id string, session *plex.Session, now time.Time) *HistoryItem {
+	title := session.Title
+	if session.GrandparentTitle != "" {
+		title = session.GrandparentTitle + " - " + title
+	}
+
+	return &HistoryItem{
+		ID:       id,
+		User:     session.User.Title,
+		Title:    title,
+		Type:     session.Type,
+		Library:  session.LibrarySectionTitle,
+		Player:   session.Player.Title,
+		Decision: playDecision(session),
+		Start:    now,
+		last:     now,
+	}
+}
+
+// playDecision turns a session's transcode data into a simple playback decision.
+func playDecision(session *plex.Session) string {
+	switch xcode := session.TranscodeSession; {
+	case xcode.VideoDecision == "transcode" || xcode.AudioDecision == "transcode":
+		return "transcode"
+	case xcode.VideoDecision == "copy" || xcode.AudioDecision == "copy":
+		return "direct stream"
+	default:
+		return "direct play"
+	}
+}
+
+// list returns up to count finished history items, newest first.
+func (h *history) list(count int) []*HistoryItem {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	list := make([]*HistoryItem, 0, count)
+	for idx := len(h.items) - 1; idx >= 0 && len(list) < count; idx-- {
+		list = append(list, h.items[idx])
+	}
+
+	return list
+}
+
+// report totals the watch time of finished sessions that started after since.
+func (h *history) report(since time.Time) *HistoryReport {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	report := &HistoryReport{
+		Since:     since,
+		Users:     make(map[string]*WatchTime),
+		Libraries: make(map[string]*WatchTime),
+		Days:      make(map[string]*WatchTime),
+	}
+
+	for _, item := range h.items {
+		if item.Start.Before(since) {
+			continue
+		}
+
+		report.Total.add(item)
+		addWatchTime(report.Users, item.User, item)
+		addWatchTime(report.Libraries, item.Library, item)
+		addWatchTime(report.Days, item.Start.Local().Format("2006-01-02"), item)
+	}
+
+	return report
+}
+
+func addWatchTime(totals map[string]*WatchTime, key string, item *HistoryItem) {
+	if totals[key] == nil {
+		totals[key] = &WatchTime{}
+	}
+
+	totals[key].add(item)
+}
+
+func (w *WatchTime) add(item *HistoryItem) {
+	w.Plays++
+	w.Duration.Duration += item.Duration.Duration
+}
+
+// SortedDays returns the report's days in order, newest first. Useful in templates.
+func (r *HistoryReport) SortedDays() []string {
+	days := make([]string, 0, len(r.Days))
+	for day := range r.Days {
+		days = append(days, day)
+	}
+
+	sort.Sort(sort.Reverse(sort.StringSlice(days)))
+
+	return days
+}
+
+// HandleHistory returns the most recent finished sessions in the local Plex session history.
+// @Summary      Retrieve Plex session history.
+// @Description  Returns the most recent finished sessions from the local Plex session history, newest first.
+// @Description  Requires history_size to be set in the plex config.
+// @Tags         Plex
+// @Produce      json
+// @Param        count query int false "number of items to return, default 100"
+// @Success      200  {object} apps.Respond.apiResponse{message=[]plexcron.HistoryItem} "session history"
+// @Failure      501  {object} apps.Respond.apiResponse{message=string} "history not enabled"
+// @Failure      404  {object} string "bad token or api key"
+// @Router       /api/plex/1/history [get]
+// @Security     ApiKeyAuth
+func (a *Action) HandleHistory(r *http.Request) (int, interface{}) {
+	if a.cmd.history == nil {
+		return http.StatusNotImplemented, "Plex session history is not enabled."
+	}
+
+	count, _ := strconv.Atoi(r.URL.Query().Get("count"))
+	if count <= 0 {
+		count = defaultHistoryCount
+	}
+
+	return http.StatusOK, a.cmd.history.list(count)
+}
+
+// HandleHistoryReport returns watch time totals from the local Plex session history.
+// @Summary      Retrieve Plex watch time report.
+// @Description  Returns watch time totals by user, library and day from the local Plex session history.
+// @Description  Requires history_size to be set in the plex config.
+// @Tags         Plex
+// @Produce      json
+// @Param        days query int false "number of days to include, default 30"
+// @Success      200  {object} apps.Respond.apiResponse{message=plexcron.HistoryReport} "watch time report"
+// @Failure      501  {object} apps.Respond.apiResponse{message=string} "history not enabled"
+// @Failure      404  {object} string "bad token or api key"
+// @Router       /api/plex/1/history/report [get]
+// @Security     ApiKeyAuth
+func (a *Action) HandleHistoryReport(r *http.Request) (int, interface{}) {
+	if a.cmd.history == nil {
+		return http.StatusNotImplemented, "Plex session history is not enabled."
+	}
+
+	days, _ := strconv.Atoi(r.URL.Query().Get("days"))
+	if days <= 0 {
+		days = defaultReportDays
+	}
+
+	return http.StatusOK, a.cmd.history.report(time.Now().AddDate(0, 0, -days))
+}
@@ -26,8 +26,9 @@ type Action struct {
 
 type cmd struct {
 	*common.Config
-	Plex *apps.PlexConfig
-	sent map[string]struct{} // Tracks Finished sessions already sent.
+	Plex    *apps.PlexConfig
+	sent    map[string]struct{} // Tracks Finished sessions already sent.
+	history *history            // Local session history, nil if disabled.
 	sync.Mutex
 }
 
@@ -65,8 +66,15 @@ func (a *Action) Create() {
 }
 
 func (c *cmd) run() {
+	if !c.Plex.Enabled() {
+		return
+	}
+
+	// Session history is kept locally, so it does not need the website.
+	c.runHistory()
+
 	ci := clientinfo.Get()
-	if !c.Plex.Enabled() || ci == nil {
+	if ci == nil {
 		return
 	}
 
@@ -123,10 +131,15 @@ func (c *cmd) sendWebhook(ctx context.Context, hook *plex.IncomingWebhook) {
 		}
 	}
 
+	matches, err := c.Apps.EnrichPlexWebhook(ctx, hook)
+	if err != nil {
+		c.Errorf("Matching Plex Webhook item to Starr apps: %v", err)
+	}
+
 	c.SendData(&website.Request{
 		Route:      website.PlexRoute,
 		Event:      website.EventHook,
-		Payload:    &website.Payload{Snap: c.getMetaSnap(ctx), Load: hook, Plex: sessions},
+		Payload:    &website.Payload{Snap: c.getMetaSnap(ctx), Load: hook, Plex: sessions, Starr: matches},
 		LogMsg:     "Plex Webhook (and sessions)",
 		LogPayload: true,
 	})
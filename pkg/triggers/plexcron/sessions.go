@@ -64,6 +64,7 @@ func (c *cmd) plexSessionTracker(ctx context.Context, current, previous *plex.Se
 
 	// data.Save("plexPreviousSessions", previous)
 	data.Save("plexCurrentSessions", current)
+	c.updateHistory(current.Sessions, now)
 
 	for _, currSess := range current.Sessions {
 		// make sure every session has a start time.
@@ -74,7 +75,7 @@ func (c *cmd) plexSessionTracker(ctx context.Context, current, previous *plex.Se
 			continue // this only happens once.
 		case c.checkExistingSession(ctx, currSess, current, previous):
 			continue // existing session.
-		case currSess.Player.State == playing && ci.Actions.Plex.TrackSess:
+		case currSess.Player.State == playing && ci != nil && ci.Actions.Plex.TrackSess:
 			// We are tracking sessions (no webhooks); send this brand new session to website.
 			c.sendSessionPlaying(ctx, currSess, current, mediaPlay)
 		}
@@ -95,7 +96,7 @@ func (c *cmd) checkExistingSession(ctx context.Context, currSess *plex.Session,
 		} else
 		// Check for a session that was paused and is now playing (resumed).
 		if ci := clientinfo.Get(); currSess.Player.State == playing &&
-			prevSess.Player.State == paused && ci.Actions.Plex.TrackSess {
+			prevSess.Player.State == paused && ci != nil && ci.Actions.Plex.TrackSess {
 			// Check if we're tracking sessions. If yes, send this resumed session.
 			c.sendSessionPlaying(ctx, currSess, current, mediaResume)
 		}
@@ -0,0 +1,75 @@
+package plexscan
+
+import (
+	"context"
+	"strings"
+
+	"github.com/Notifiarr/notifiarr/pkg/triggers/common"
+	"github.com/Notifiarr/notifiarr/pkg/website"
+)
+
+/* Plex Scan requests partial library scans for folders imported by Starr apps. */
+
+const TrigPlexScan common.TriggerName = "Scanning Plex Library Folders"
+
+// Action contains the exported methods for this package.
+type Action struct {
+	cmd *cmd
+}
+
+type cmd struct {
+	*common.Config
+}
+
+// New configures the library.
+func New(config *common.Config) *Action {
+	return &Action{cmd: &cmd{Config: config}}
+}
+
+// Create initializes the library.
+func (a *Action) Create() {
+	a.cmd.create()
+}
+
+func (c *cmd) create() {
+	c.Add(&common.Action{
+		Name: TrigPlexScan,
+		Fn:   c.scanPlexFolders,
+		C:    make(chan *common.ActionInput, 1),
+	})
+}
+
+// Scan requests a partial Plex library scan for each provided (Starr app) folder.
+func (a *Action) Scan(event website.EventType, folders ...string) {
+	a.cmd.Exec(&common.ActionInput{Type: event, Args: folders}, TrigPlexScan)
+}
+
+func (c *cmd) scanPlexFolders(ctx context.Context, input *common.ActionInput) {
+	if !c.Apps.Plex.Enabled() || len(input.Args) == 0 {
+		return
+	}
+
+	directory, err := c.Apps.Plex.GetDirectoryWithContext(ctx)
+	if err != nil {
+		c.Errorf("[%s requested] Getting Plex library directory failed: %v", input.Type, err)
+		return
+	}
+
+	for _, folder := range input.Args {
+		folder = c.Apps.Plex.MapPath(strings.TrimSpace(folder))
+
+		section := directory.SectionForPath(folder)
+		if section == nil {
+			c.Errorf("[%s requested] No Plex library contains folder '%s'; check your path maps.", input.Type, folder)
+			continue
+		}
+
+		if err := c.Apps.Plex.ScanPathWithContext(ctx, section.Key, folder); err != nil {
+			c.ErrorfNoShare("[%s requested] Scanning Plex library '%s' folder '%s' failed: %v",
+				input.Type, section.Title, folder, err)
+			continue
+		}
+
+		c.Printf("[%s requested] Requested Plex library '%s' scan of folder: %s", input.Type, section.Title, folder)
+	}
+}
@@ -0,0 +1,323 @@
+package seeding
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Notifiarr/notifiarr/pkg/apps"
+	"github.com/mrobinsn/go-rtorrent/rtorrent"
+)
+
+// starrQueuePage is the number of queue records requested at once. Every record is inspected.
+const starrQueuePage = 1000
+
+var ErrInvalidResponse = fmt.Errorf("invalid response")
+
+// torrent is the data the seeding rules need from any download client.
+type torrent struct {
+	hash       string
+	name       string
+	tracker    string
+	categories []string // qbit category and tags, deluge label, rtorrent label.
+	ratio      float64
+	seedTime   time.Duration
+	size       int64
+	paused     bool
+}
+
+// client wraps the different download clients, so the rules can be applied to them the same way.
+type client struct {
+	name     string
+	instance int
+	list     func(ctx context.Context) ([]*torrent, error) // only finished torrents.
+	free     func(ctx context.Context) (int64, error)
+	pause    func(ctx context.Context, hash string) error
+	remove   func(ctx context.Context, deleteData bool, hash string) error
+}
+
+// clients returns all the enabled torrent clients.
+func (c *cmd) clients() []*client {
+	clients := []*client{}
+
+	for idx, app := range c.Apps.Qbit {
+		if app.Enabled() {
+			clients = append(clients, qbitClient(idx+1, app))
+		}
+	}
+
+	for idx, app := range c.Apps.Deluge {
+		if app.Enabled() {
+			clients = append(clients, delugeClient(idx+1, app))
+		}
+	}
+
+	for idx, app := range c.Apps.Rtorrent {
+		if app.Enabled() {
+			clients = append(clients, rtorrentClient(idx+1, app, c.needTrackers()))
+		}
+	}
+
+	return clients
+}
+
+func qbitClient(instance int, app *apps.QbitConfig) *client {
+	return &client{
+		name:     "qbit",
+		instance: instance,
+		free:     app.FreeSpace,
+		pause:    func(ctx context.Context, hash string) error { return app.PauseTorrents(ctx, hash) },
+		remove: func(ctx context.Context, deleteData bool, hash string) error {
+			return app.DeleteTorrents(ctx, deleteData, hash)
+		},
+		list: func(ctx context.Context) ([]*torrent, error) {
+			xfers, err := app.GetXfersContext(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("getting transfers: %w", err)
+			}
+
+			torrents := []*torrent{}
+
+			for _, xfer := range xfers {
+				if xfer.AmountLeft > 0 || xfer.Progress < 1 {
+					continue
+				}
+
+				torrents = append(torrents, &torrent{
+					hash:       xfer.Hash,
+					name:       xfer.Name,
+					tracker:    xfer.Tracker,
+					categories: append([]string{xfer.Category}, strings.Split(xfer.Tags, ",")...),
+					ratio:      xfer.Ratio,
+					seedTime:   time.Duration(xfer.SeedingTime) * time.Second,
+					size:       xfer.Size,
+					paused:     strings.HasPrefix(strings.ToLower(xfer.State), "paused"),
+				})
+			}
+
+			return torrents, nil
+		},
+	}
+}
+
+func delugeClient(instance int, app *apps.DelugeConfig) *client {
+	return &client{
+		name:     "deluge",
+		instance: instance,
+		free:     app.FreeSpace,
+		pause:    func(ctx context.Context, hash string) error { return app.PauseTorrents(ctx, hash) },
+		remove: func(ctx context.Context, deleteData bool, hash string) error {
+			return app.DeleteTorrents(ctx, deleteData, hash)
+		},
+		list: func(ctx context.Context) ([]*torrent, error) {
+			xfers, err := app.GetXfersCompatContext(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("getting transfers: %w", err)
+			}
+
+			torrents := []*torrent{}
+
+			for hash, xfer := range xfers {
+				if !xfer.IsFinished {
+					continue
+				}
+
+				if xfer.Hash == "" {
+					xfer.Hash = hash
+				}
+
+				torrents = append(torrents, &torrent{
+					hash:       xfer.Hash,
+					name:       xfer.Name,
+					tracker:    xfer.TrackerHost + " " + xfer.Tracker,
+					categories: []string{xfer.Label},
+					ratio:      xfer.Ratio,
+					seedTime:   time.Duration(xfer.SeedingTime) * time.Second,
+					size:       int64(xfer.TotalSize),
+					paused:     xfer.Paused,
+				})
+			}
+
+			return torrents, nil
+		},
+	}
+}
+
+func rtorrentClient(instance int, app *apps.RtorrentConfig, trackers bool) *client {
+	var free int64
+
+	return &client{
+		name:     "rtorrent",
+		instance: instance,
+		// Free space is collected while listing torrents; list always runs first.
+		free:  func(context.Context) (int64, error) { return free, nil },
+		pause: func(ctx context.Context, hash string) error { return app.PauseTorrents(ctx, hash) },
+		remove: func(ctx context.Context, deleteData bool, hash string) error {
+			return app.DeleteTorrents(ctx, deleteData, hash)
+		},
+		list: func(context.Context) (torrents []*torrent, err error) {
+			torrents, free, err = rtorrentTorrents(app, trackers)
+			return torrents, err
+		},
+	}
+}
+
+// rtorrentTorrents returns the finished torrents and the free space. Getting trackers is slow, so it's optional.
+func rtorrentTorrents(app *apps.RtorrentConfig, trackers bool) ([]*torrent, int64, error) {
+	results, err := app.Call("d.multicall2", "", string(rtorrent.ViewMain),
+		rtorrent.DHash.Query(),
+		rtorrent.DName.Query(),
+		rtorrent.DLabel.Query(),
+		rtorrent.DRatio.Query(), // per mille.
+		rtorrent.DComplete.Query(),
+		rtorrent.DIsActive.Query(),
+		rtorrent.DSizeInBytes.Query(),
+		rtorrent.DFinishedTime.Query(),
+		"d.free_diskspace=",
+	)
+	if err != nil {
+		return nil, 0, fmt.Errorf("%w: d.multicall2 XMLRPC call failed", err)
+	}
+
+	var (
+		torrents = []*torrent{}
+		free     int64
+	)
+
+	resInt, _ := results.([]interface{})
+	for _, outerResult := range resInt {
+		resOut, _ := outerResult.([]interface{})
+		for _, innerResult := range resOut {
+			data, ok := innerResult.([]interface{})
+			if !ok || len(data) < 9 { //nolint:gomnd // 9 fields requested.
+				return nil, 0, fmt.Errorf("%w: data returned from query is unusable", ErrInvalidResponse)
+			}
+
+			//nolint:forcetypeassert // if these are bad it crashes here, like the dashboard.
+			if free = int64(data[8].(int)); data[4].(int) == 0 {
+				continue // not complete.
+			}
+
+			//nolint:forcetypeassert
+			item := &torrent{
+				hash:       data[0].(string),
+				name:       data[1].(string),
+				categories: []string{data[2].(string)},
+				ratio:      float64(data[3].(int)) / 1000, //nolint:gomnd // per mille.
+				paused:     data[5].(int) == 0,
+				size:       int64(data[6].(int)),
+				seedTime:   time.Since(time.Unix(int64(data[7].(int)), 0)).Round(time.Second),
+			}
+
+			if trackers {
+				item.tracker = rtorrentTracker(app, item.hash)
+			}
+
+			torrents = append(torrents, item)
+		}
+	}
+
+	return torrents, free, nil
+}
+
+// rtorrentTracker returns the tracker URLs for a torrent; rtorrent does not provide them in the main list.
+func rtorrentTracker(app *apps.RtorrentConfig, hash string) string {
+	results, err := app.Call("t.multicall", hash, "", "t.url=")
+	if err != nil {
+		return ""
+	}
+
+	urls := []string{}
+
+	resInt, _ := results.([]interface{})
+	for _, outerResult := range resInt {
+		resOut, _ := outerResult.([]interface{})
+		for _, innerResult := range resOut {
+			if data, ok := innerResult.([]interface{}); ok && len(data) > 0 {
+				url, _ := data[0].(string)
+				urls = append(urls, url)
+			}
+		}
+	}
+
+	return strings.Join(urls, " ")
+}
+
+// needTrackers returns true if any rule matches trackers.
+func (c *cmd) needTrackers() bool {
+	for _, rule := range c.seeding.Rules {
+		if rule != nil && len(rule.Trackers) > 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// starrQueueHashes returns the download IDs (torrent hashes) in all Starr app queues.
+func (c *cmd) starrQueueHashes(ctx context.Context) (map[string]bool, error) {
+	hashes := make(map[string]bool)
+
+	for idx, app := range c.Apps.Lidarr {
+		if !app.Enabled() {
+			continue
+		}
+
+		queue, err := app.GetQueueContext(ctx, 0, starrQueuePage)
+		if err != nil {
+			return nil, fmt.Errorf("getting lidarr %d queue: %w", idx+1, err)
+		}
+
+		for _, item := range queue.Records {
+			hashes[strings.ToUpper(item.DownloadID)] = true
+		}
+	}
+
+	for idx, app := range c.Apps.Radarr {
+		if !app.Enabled() {
+			continue
+		}
+
+		queue, err := app.GetQueueContext(ctx, 0, starrQueuePage)
+		if err != nil {
+			return nil, fmt.Errorf("getting radarr %d queue: %w", idx+1, err)
+		}
+
+		for _, item := range queue.Records {
+			hashes[strings.ToUpper(item.DownloadID)] = true
+		}
+	}
+
+	for idx, app := range c.Apps.Readarr {
+		if !app.Enabled() {
+			continue
+		}
+
+		queue, err := app.GetQueueContext(ctx, 0, starrQueuePage)
+		if err != nil {
+			return nil, fmt.Errorf("getting readarr %d queue: %w", idx+1, err)
+		}
+
+		for _, item := range queue.Records {
+			hashes[strings.ToUpper(item.DownloadID)] = true
+		}
+	}
+
+	for idx, app := range c.Apps.Sonarr {
+		if !app.Enabled() {
+			continue
+		}
+
+		queue, err := app.GetQueueContext(ctx, 0, starrQueuePage)
+		if err != nil {
+			return nil, fmt.Errorf("getting sonarr %d queue: %w", idx+1, err)
+		}
+
+		for _, item := range queue.Records {
+			hashes[strings.ToUpper(item.DownloadID)] = true
+		}
+	}
+
+	return hashes, nil
+}
@@ -0,0 +1,463 @@
+package seeding
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Notifiarr/notifiarr/pkg/mnd"
+	"github.com/Notifiarr/notifiarr/pkg/triggers/common"
+	"github.com/Notifiarr/notifiarr/pkg/triggers/data"
+	"github.com/Notifiarr/notifiarr/pkg/website"
+	"golift.io/cnfg"
+)
+
+/* Seeding rules pause or remove finished torrents in qBittorrent, Deluge and rTorrent. */
+
+const TrigSeedingRules common.TriggerName = "Applying Torrent Seeding Rules."
+
+const (
+	actionsFile = "seeding_actions.json"
+	// This is the max number of actions kept in the audit log.
+	actionsMax = 250
+)
+
+// ErrInvalidRule is returned when a seeding rule is not valid.
+var ErrInvalidRule = fmt.Errorf("invalid seeding rule")
+
+// Rule actions.
+const (
+	ActionPause  = "pause"
+	ActionRemove = "remove" // remove the torrent, keep the data.
+	ActionDelete = "delete" // remove the torrent and its data.
+)
+
+// Config is the [seeding] config section.
+type Config struct {
+	Interval cnfg.Duration `json:"interval" toml:"interval" xml:"interval" yaml:"interval"` // 0 disables the timer.
+	DryRun   bool          `json:"dryRun" toml:"dry_run" xml:"dry_run" yaml:"dryRun"`
+	// IgnoreStarr disables the safeguard that skips torrents still in a Starr app queue.
+	IgnoreStarr bool    `json:"ignoreStarrQueue" toml:"ignore_starr_queue" xml:"ignore_starr_queue" yaml:"ignoreStarrQueue"`
+	Rules       []*Rule `json:"rules" toml:"rule" xml:"rule" yaml:"rules"`
+}
+
+// Rule matches finished torrents and pauses or removes them. Trackers and categories narrow the
+// matched torrents. A torrent is acted on when it reaches the ratio or the seed time. When free_space
+// is set, the rule only applies while the client has less free space (in GB) than that. A delete
+// rule may set only free_space; it then deletes the oldest torrents until there is enough space.
+// The first matching rule wins.
+type Rule struct {
+	Name       string        `json:"name" toml:"name" xml:"name" yaml:"name"`
+	Action     string        `json:"action" toml:"action" xml:"action" yaml:"action"`
+	Clients    []string      `json:"clients" toml:"clients" xml:"clients" yaml:"clients"` // qbit, deluge, rtorrent or qbit1.
+	Trackers   []string      `json:"trackers" toml:"trackers" xml:"trackers" yaml:"trackers"`
+	Categories []string      `json:"categories" toml:"categories" xml:"categories" yaml:"categories"`
+	Ratio      float64       `json:"ratio" toml:"ratio" xml:"ratio" yaml:"ratio"`
+	SeedTime   cnfg.Duration `json:"seedTime" toml:"seed_time" xml:"seed_time" yaml:"seedTime"`
+	FreeSpace  int64         `json:"freeSpace" toml:"free_space" xml:"free_space" yaml:"freeSpace"`
+}
+
+// Taken is an action taken (or that would be taken, in dry run mode) on a torrent.
+type Taken struct {
+	Time     time.Time `json:"time"`
+	Client   string    `json:"client"`
+	Instance int       `json:"instance"`
+	Name     string    `json:"name"`
+	Hash     string    `json:"hash"`
+	Rule     string    `json:"rule"`
+	Action   string    `json:"action"`
+	Reason   string    `json:"reason"`
+	DryRun   bool      `json:"dryRun"`
+	Error    string    `json:"error,omitempty"`
+}
+
+// Report is the result of applying the seeding rules once.
+type Report struct {
+	Start   time.Time     `json:"start"`
+	Elapsed cnfg.Duration `json:"elapsed"`
+	DryRun  bool          `json:"dryRun"`
+	Checked int           `json:"checked"` // finished torrents checked.
+	Skipped int           `json:"skipped"` // matched, but still in a Starr queue.
+	Actions []*Taken      `json:"actions"`
+	Errors  []string      `json:"errors,omitempty"`
+}
+
+// Action contains the exported methods for this package.
+type Action struct {
+	cmd *cmd
+}
+
+type cmd struct {
+	*common.Config
+	seeding *Config
+	last    *Report
+	actions []*Taken // audit log, oldest first.
+	mu      sync.RWMutex
+}
+
+// New configures the library.
+func New(config *common.Config, seeding *Config) *Action {
+	return &Action{cmd: &cmd{Config: config, seeding: seeding}}
+}
+
+// Create initializes the library.
+func (a *Action) Create() {
+	a.cmd.create()
+}
+
+func (c *cmd) create() {
+	if c.seeding == nil || len(c.seeding.Rules) == 0 {
+		return
+	}
+
+	if err := c.ReadDataFile(actionsFile, &c.actions); err != nil {
+		c.Errorf("Loading seeding rules audit log: %v", err)
+	}
+
+	var ticker *time.Ticker
+
+	if c.seeding.Interval.Duration > 0 {
+		ticker = time.NewTicker(c.seeding.Interval.Duration)
+		c.Printf("==> Seeding Rules timer started, interval:%s rules:%d dry_run:%v",
+			c.seeding.Interval, len(c.seeding.Rules), c.seeding.DryRun)
+	}
+
+	c.Add(&common.Action{
+		Name: TrigSeedingRules,
+		Fn:   c.applyRules,
+		C:    make(chan *common.ActionInput, 1),
+		T:    ticker,
+	})
+}
+
+// Validate checks the seeding rules for errors. rtorrent is true if rTorrent is configured;
+// rTorrent cannot delete torrent data, so a delete rule cannot apply to it.
+func (c *Config) Validate(rtorrent bool) error {
+	for idx, rule := range c.Rules {
+		if rule == nil {
+			continue
+		}
+
+		if rule.Name == "" {
+			rule.Name = "rule " + strconv.Itoa(idx+1)
+		}
+
+		switch rule.Action = strings.ToLower(rule.Action); rule.Action {
+		case ActionPause, ActionRemove, ActionDelete:
+		default:
+			return fmt.Errorf("%w: seeding %s: action must be one of %s, %s or %s",
+				ErrInvalidRule, rule.Name, ActionPause, ActionRemove, ActionDelete)
+		}
+
+		if rule.Action == ActionDelete && rule.matchRtorrent(rtorrent) {
+			return fmt.Errorf("%w: seeding %s: rtorrent cannot delete data; list the other clients in this rule",
+				ErrInvalidRule, rule.Name)
+		}
+
+		if rule.Ratio <= 0 && rule.SeedTime.Duration <= 0 && rule.FreeSpace <= 0 {
+			return fmt.Errorf("%w: seeding %s: set a ratio, seed_time or free_space", ErrInvalidRule, rule.Name)
+		}
+
+		// Only deleting data frees space, so a free_space-only pause or remove rule would match every torrent.
+		if rule.Ratio <= 0 && rule.SeedTime.Duration <= 0 && rule.Action != ActionDelete {
+			return fmt.Errorf("%w: seeding %s: a %s rule needs a ratio or seed_time; only %s frees space",
+				ErrInvalidRule, rule.Name, rule.Action, ActionDelete)
+		}
+	}
+
+	return nil
+}
+
+// Run applies the seeding rules now.
+func (a *Action) Run(event website.EventType) bool {
+	return a.cmd.Exec(&common.ActionInput{Type: event}, TrigSeedingRules)
+}
+
+func (c *cmd) applyRules(ctx context.Context, input *common.ActionInput) {
+	report := &Report{Start: time.Now(), DryRun: c.seeding.DryRun, Actions: []*Taken{}}
+
+	queued := make(map[string]bool)
+
+	if !c.seeding.IgnoreStarr {
+		var err error
+		// Never remove torrents that may still be in a Starr queue.
+		if queued, err = c.starrQueueHashes(ctx); err != nil {
+			c.Errorf("[%s requested] Seeding Rules: not applying rules: %v", input.Type, err)
+			report.Errors = append(report.Errors, err.Error())
+			c.saveReport(report)
+
+			return
+		}
+	}
+
+	for _, client := range c.clients() {
+		c.applyClientRules(ctx, client, queued, report)
+	}
+
+	report.Elapsed.Duration = time.Since(report.Start).Round(time.Millisecond)
+	c.saveReport(report)
+
+	if len(report.Actions) > 0 || len(report.Errors) > 0 {
+		c.Printf("[%s requested] Seeding Rules: checked %d torrents, %d actions, %d skipped (in Starr queue), "+
+			"%d errors, dry run: %v, elapsed: %v", input.Type, report.Checked, len(report.Actions),
+			report.Skipped, len(report.Errors), report.DryRun, report.Elapsed)
+	}
+}
+
+// applyClientRules applies the rules to every finished torrent in one download client.
+func (c *cmd) applyClientRules(ctx context.Context, client *client, queued map[string]bool, report *Report) {
+	torrents, err := client.list(ctx)
+	if err != nil {
+		c.Errorf("Seeding Rules: getting torrents from %s %d: %v", client.name, client.instance, err)
+		report.Errors = append(report.Errors, fmt.Sprintf("%s %d: %v", client.name, client.instance, err))
+
+		return
+	}
+
+	free := int64(-1) // unknown.
+	if c.needFreeSpace(client) {
+		if free, err = client.free(ctx); err != nil {
+			free = -1
+
+			c.Errorf("Seeding Rules: getting free space from %s %d: %v", client.name, client.instance, err)
+		}
+	}
+
+	// Act on the torrents that have seeded the longest first; they go first when space is low.
+	sort.Slice(torrents, func(i, j int) bool { return torrents[i].seedTime > torrents[j].seedTime })
+
+	for _, torrent := range torrents {
+		report.Checked++
+
+		rule, reason := c.matchRule(client, torrent, free)
+		if rule == nil {
+			continue
+		}
+
+		if queued[strings.ToUpper(torrent.hash)] {
+			report.Skipped++
+			continue
+		}
+
+		taken := c.takeAction(ctx, client, torrent, rule, reason)
+		report.Actions = append(report.Actions, taken)
+
+		if taken.Error != "" {
+			report.Errors = append(report.Errors, taken.Error)
+		} else if rule.Action == ActionDelete && free >= 0 {
+			free += torrent.size
+		}
+	}
+}
+
+// matchRule returns the first rule that matches a torrent, and the reason it matched.
+func (c *cmd) matchRule(client *client, torrent *torrent, free int64) (*Rule, string) {
+	for _, rule := range c.seeding.Rules {
+		if rule == nil || !rule.matchClient(client) || !rule.matchTracker(torrent) || !rule.matchCategory(torrent) {
+			continue
+		}
+
+		if rule.Action == ActionPause && torrent.paused {
+			continue
+		}
+
+		pressure := ""
+
+		if rule.FreeSpace > 0 {
+			if free < 0 || free >= rule.FreeSpace*mnd.Megabyte*mnd.Kilobyte {
+				continue // not enough pressure, or unknown free space.
+			}
+
+			pressure = fmt.Sprintf("free space %.1fGB below %dGB",
+				float64(free)/float64(mnd.Megabyte*mnd.Kilobyte), rule.FreeSpace)
+		}
+
+		switch {
+		case rule.Ratio > 0 && torrent.ratio >= rule.Ratio:
+			return rule, joinReason(fmt.Sprintf("ratio %.2f reached %.2f", torrent.ratio, rule.Ratio), pressure)
+		case rule.SeedTime.Duration > 0 && torrent.seedTime >= rule.SeedTime.Duration:
+			return rule, joinReason(fmt.Sprintf("seed time %v reached %v",
+				torrent.seedTime.Round(time.Minute), rule.SeedTime), pressure)
+		case rule.Ratio <= 0 && rule.SeedTime.Duration <= 0: // free_space-only delete rule.
+			return rule, pressure
+		}
+	}
+
+	return nil, ""
+}
+
+func joinReason(reason, pressure string) string {
+	if pressure == "" {
+		return reason
+	}
+
+	return reason + ", " + pressure
+}
+
+func (c *cmd) needFreeSpace(client *client) bool {
+	for _, rule := range c.seeding.Rules {
+		if rule != nil && rule.FreeSpace > 0 && rule.matchClient(client) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (c *cmd) takeAction(ctx context.Context, client *client, torrent *torrent, rule *Rule, reason string) *Taken {
+	taken := &Taken{
+		Time:     time.Now(),
+		Client:   client.name,
+		Instance: client.instance,
+		Name:     torrent.name,
+		Hash:     torrent.hash,
+		Rule:     rule.Name,
+		Action:   rule.Action,
+		Reason:   reason,
+		DryRun:   c.seeding.DryRun,
+	}
+
+	if c.seeding.DryRun {
+		c.Printf("Seeding Rules (dry run): would %s %s %d torrent '%s': %s",
+			rule.Action, client.name, client.instance, torrent.name, reason)
+		return taken
+	}
+
+	var err error
+
+	switch rule.Action {
+	case ActionPause:
+		err = client.pause(ctx, torrent.hash)
+	case ActionRemove:
+		err = client.remove(ctx, false, torrent.hash)
+	case ActionDelete:
+		err = client.remove(ctx, true, torrent.hash)
+	}
+
+	if err != nil {
+		taken.Error = fmt.Sprintf("%s %s %d torrent '%s': %v", rule.Action, client.name, client.instance, torrent.name, err)
+		c.Errorf("Seeding Rules: %s", taken.Error)
+	} else {
+		c.Printf("Seeding Rules: %s %s %d torrent '%s': %s", rule.Action, client.name, client.instance, torrent.name, reason)
+	}
+
+	return taken
+}
+
+func (r *Rule) matchClient(client *client) bool {
+	if len(r.Clients) == 0 {
+		return true
+	}
+
+	for _, name := range r.Clients {
+		if strings.EqualFold(name, client.name) || strings.EqualFold(name, client.name+strconv.Itoa(client.instance)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// matchRtorrent returns true if the rule applies to rTorrent. configured is true if rTorrent is configured.
+func (r *Rule) matchRtorrent(configured bool) bool {
+	if len(r.Clients) == 0 {
+		return configured
+	}
+
+	for _, name := range r.Clients {
+		if strings.HasPrefix(strings.ToLower(name), "rtorrent") {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (r *Rule) matchTracker(torrent *torrent) bool {
+	if len(r.Trackers) == 0 {
+		return true
+	}
+
+	for _, tracker := range r.Trackers {
+		if strings.Contains(strings.ToLower(torrent.tracker), strings.ToLower(tracker)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (r *Rule) matchCategory(torrent *torrent) bool {
+	if len(r.Categories) == 0 {
+		return true
+	}
+
+	for _, category := range r.Categories {
+		for _, have := range torrent.categories {
+			if strings.EqualFold(strings.TrimSpace(have), category) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// saveReport stores the report for the API and GUI, and adds its actions to the audit log.
+func (c *cmd) saveReport(report *Report) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.last = report
+	data.Save("seedingReport", report)
+
+	if len(report.Actions) == 0 {
+		return
+	}
+
+	c.actions = append(c.actions, report.Actions...)
+	if len(c.actions) > actionsMax {
+		c.actions = c.actions[len(c.actions)-actionsMax:]
+	}
+
+	if err := c.WriteDataFile(actionsFile, c.actions); err != nil {
+		c.Errorf("Saving seeding rules audit log: %v", err)
+	}
+}
+
+// HandleReport returns the last seeding rules report and the audit log of actions taken.
+// @Summary      Retrieve seeding rules report.
+// @Description  Returns the report from the last time the seeding rules were applied,
+// @Description  and the audit log of recent actions taken (newest first).
+// @Tags         Triggers
+// @Produce      json
+// @Success      200  {object} apps.Respond.apiResponse{message=seeding.HandleReport.report} "seeding report"
+// @Failure      501  {object} apps.Respond.apiResponse{message=string} "no seeding rules"
+// @Failure      404  {object} string "bad token or api key"
+// @Router       /api/seeding/report [get]
+// @Security     ApiKeyAuth
+func (a *Action) HandleReport(_ *http.Request) (int, interface{}) {
+	if a.cmd.seeding == nil || len(a.cmd.seeding.Rules) == 0 {
+		return http.StatusNotImplemented, "No seeding rules configured."
+	}
+
+	a.cmd.mu.RLock()
+	defer a.cmd.mu.RUnlock()
+
+	type report struct {
+		Last    *Report  `json:"last"`
+		Actions []*Taken `json:"actions"`
+	}
+
+	actions := make([]*Taken, 0, len(a.cmd.actions))
+	for idx := len(a.cmd.actions) - 1; idx >= 0; idx-- {
+		actions = append(actions, a.cmd.actions[idx])
+	}
+
+	return http.StatusOK, &report{Last: a.cmd.last, Actions: actions}
+}
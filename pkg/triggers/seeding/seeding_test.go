@@ -0,0 +1,179 @@
+package seeding
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Notifiarr/notifiarr/pkg/logs"
+	"github.com/Notifiarr/notifiarr/pkg/mnd"
+	"github.com/Notifiarr/notifiarr/pkg/triggers/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golift.io/cnfg"
+)
+
+const gigabyte = mnd.Megabyte * mnd.Kilobyte
+
+func TestValidate(t *testing.T) {
+	t.Parallel()
+
+	valid := map[string]*Rule{
+		"ratio pause":             {Action: "Pause", Ratio: 1},
+		"seed time remove":        {Action: ActionRemove, SeedTime: cnfg.Duration{Duration: time.Hour}},
+		"free space with ratio":   {Action: ActionRemove, Ratio: 2, FreeSpace: 10},
+		"free space only delete":  {Action: ActionDelete, FreeSpace: 10, Clients: []string{"qbit"}},
+		"delete without rtorrent": {Action: ActionDelete, Ratio: 1, Clients: []string{"deluge"}},
+	}
+
+	for name, rule := range valid {
+		assert.NoError(t, (&Config{Rules: []*Rule{rule}}).Validate(true), name)
+	}
+
+	invalid := map[string]*Rule{
+		"unknown action":         {Action: "stop", Ratio: 1},
+		"no limits":              {Action: ActionPause},
+		"free space only pause":  {Action: ActionPause, FreeSpace: 10},
+		"free space only remove": {Action: ActionRemove, FreeSpace: 10},
+		"delete with rtorrent":   {Action: ActionDelete, Ratio: 1},
+		"delete names rtorrent":  {Action: ActionDelete, Ratio: 1, Clients: []string{"rtorrent2"}},
+	}
+
+	for name, rule := range invalid {
+		assert.ErrorIs(t, (&Config{Rules: []*Rule{rule}}).Validate(true), ErrInvalidRule, name)
+	}
+
+	config := &Config{Rules: []*Rule{nil, {Action: "PAUSE", Ratio: 1}}}
+	require.NoError(t, config.Validate(false))
+	assert.Equal(t, "rule 2", config.Rules[1].Name, "unnamed rules are numbered")
+	assert.Equal(t, ActionPause, config.Rules[1].Action, "actions are lowercased")
+}
+
+func TestMatchRule(t *testing.T) {
+	t.Parallel()
+
+	qbit := &client{name: "qbit", instance: 1}
+	week := cnfg.Duration{Duration: 7 * 24 * time.Hour}
+	c := &cmd{seeding: &Config{Rules: []*Rule{
+		{Name: "private", Action: ActionPause, Trackers: []string{"private.example"}, Ratio: 5},
+		{Name: "movies", Action: ActionRemove, Clients: []string{"qbit1"}, Categories: []string{"radarr"}, SeedTime: week},
+		{Name: "space", Action: ActionDelete, FreeSpace: 100},
+	}}}
+
+	tests := map[string]struct {
+		torrent *torrent
+		free    int64
+		rule    string // empty means no rule matches.
+	}{
+		"tracker ratio": {
+			torrent: &torrent{tracker: "https://private.example/announce", ratio: 5.5},
+			free:    -1, rule: "private",
+		},
+		"tracker below ratio falls through to category": {
+			torrent: &torrent{tracker: "https://private.example/announce", ratio: 1, categories: []string{" Radarr "},
+				seedTime: week.Duration},
+			free: -1, rule: "movies",
+		},
+		"already paused is skipped by a pause rule": {
+			torrent: &torrent{tracker: "private.example", ratio: 9, paused: true},
+			free:    -1,
+		},
+		"category seed time not reached": {
+			torrent: &torrent{categories: []string{"radarr"}, seedTime: time.Hour},
+			free:    -1,
+		},
+		"low space deletes anything": {
+			torrent: &torrent{size: gigabyte},
+			free:    50 * gigabyte, rule: "space",
+		},
+		"enough space": {
+			torrent: &torrent{size: gigabyte},
+			free:    100 * gigabyte,
+		},
+		"unknown space never deletes": {
+			torrent: &torrent{size: gigabyte},
+			free:    -1,
+		},
+	}
+
+	for name, test := range tests {
+		rule, reason := c.matchRule(qbit, test.torrent, test.free)
+		if test.rule == "" {
+			assert.Nil(t, rule, name)
+			continue
+		}
+
+		if assert.NotNil(t, rule, name) {
+			assert.Equal(t, test.rule, rule.Name, name)
+			assert.NotEmpty(t, reason, name)
+		}
+	}
+}
+
+// TestApplyClientRulesFreeSpace checks that a free_space delete rule removes the oldest
+// torrents only until the client has enough space, and never touches queued torrents.
+func TestApplyClientRulesFreeSpace(t *testing.T) {
+	t.Parallel()
+
+	deleted := []string{}
+	torrents := []*torrent{
+		{hash: "new", seedTime: time.Hour, size: 30 * gigabyte},
+		{hash: "oldest", seedTime: 72 * time.Hour, size: 30 * gigabyte},
+		{hash: "queued", seedTime: 96 * time.Hour, size: 30 * gigabyte},
+		{hash: "older", seedTime: 48 * time.Hour, size: 30 * gigabyte},
+	}
+	qbit := &client{
+		name:     "qbit",
+		instance: 1,
+		list:     func(context.Context) ([]*torrent, error) { return torrents, nil },
+		free:     func(context.Context) (int64, error) { return 50 * gigabyte, nil },
+		remove: func(_ context.Context, deleteData bool, hash string) error {
+			assert.True(t, deleteData, "a delete rule must delete data")
+			deleted = append(deleted, hash)
+
+			return nil
+		},
+	}
+	c := &cmd{
+		Config:  &common.Config{Logger: logs.New()},
+		seeding: &Config{Rules: []*Rule{{Name: "space", Action: ActionDelete, FreeSpace: 100}}},
+	}
+	report := &Report{}
+
+	c.applyClientRules(context.Background(), qbit, map[string]bool{"QUEUED": true}, report)
+
+	// 50GB free: "oldest" brings it to 80GB, "older" to 110GB, then "new" is left alone.
+	assert.Equal(t, []string{"oldest", "older"}, deleted)
+	assert.Equal(t, 4, report.Checked)
+	assert.Equal(t, 1, report.Skipped)
+	assert.Len(t, report.Actions, 2)
+	assert.Empty(t, report.Errors)
+}
+
+func TestApplyClientRulesDryRun(t *testing.T) {
+	t.Parallel()
+
+	qbit := &client{
+		name: "qbit",
+		list: func(context.Context) ([]*torrent, error) {
+			return []*torrent{{hash: "a", ratio: 3}, {hash: "b", ratio: 1}}, nil
+		},
+		free: func(context.Context) (int64, error) { return 0, errors.New("free space is not needed") },
+		pause: func(context.Context, string) error {
+			t.Error("dry run must not pause torrents")
+			return nil
+		},
+	}
+	c := &cmd{
+		Config:  &common.Config{Logger: logs.New()},
+		seeding: &Config{DryRun: true, Rules: []*Rule{{Name: "ratio", Action: ActionPause, Ratio: 2}}},
+	}
+	report := &Report{}
+
+	c.applyClientRules(context.Background(), qbit, nil, report)
+
+	require.Len(t, report.Actions, 1)
+	assert.Equal(t, "a", report.Actions[0].Hash)
+	assert.True(t, report.Actions[0].DryRun)
+}
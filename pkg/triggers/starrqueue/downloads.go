@@ -35,19 +35,20 @@ func (c *cmd) sendDownloadingQueues(ctx context.Context, input *common.ActionInp
 		c.empty = false
 	}
 
+	payload := &QueuesPaylod{Lidarr: lidarr, Radarr: radarr, Readarr: readarr, Sonarr: sonarr}
+	if c.incremental(string(website.DownloadRoute), payload); payload.unchanged() {
+		c.Debugf("[%s requested] No downloading items changed.", input.Type)
+		return
+	}
+
 	c.SendData(&website.Request{
 		Route:      website.DownloadRoute,
 		Event:      input.Type,
 		LogPayload: true,
 		ErrorsOnly: true,
-		LogMsg: fmt.Sprintf("Downloading Items; Lidarr: %d, Radarr: %d, Readarr: %d, Sonarr: %d",
-			lidarr.Len(), radarr.Len(), readarr.Len(), sonarr.Len()),
-		Payload: &QueuesPaylod{
-			Lidarr:  lidarr,
-			Radarr:  radarr,
-			Readarr: readarr,
-			Sonarr:  sonarr,
-		},
+		LogMsg: fmt.Sprintf("Downloading Items; Lidarr: %d, Radarr: %d, Readarr: %d, Sonarr: %d, incremental: %v",
+			lidarr.Len(), radarr.Len(), readarr.Len(), sonarr.Len(), payload.Incremental),
+		Payload: payload,
 	})
 }
 
@@ -9,6 +9,7 @@ import (
 	"github.com/Notifiarr/notifiarr/pkg/triggers/data"
 	"github.com/Notifiarr/notifiarr/pkg/website"
 	"github.com/Notifiarr/notifiarr/pkg/website/clientinfo"
+	"golift.io/starr"
 )
 
 const TrigLidarrQueue common.TriggerName = "Storing Lidarr instance %d queue."
@@ -29,19 +30,25 @@ type lidarrApp struct {
 
 // storeQueue runs at an interval and saves the queue for an app internally.
 func (app *lidarrApp) storeQueue(ctx context.Context, input *common.ActionInput) {
-	queue, err := app.app.GetQueueContext(ctx, queueItemsMax, 1)
+	records, perPage := app.cmd.queueConfig.queueSize(starr.Lidarr, app.idx+1)
+
+	queue, err := app.app.GetQueueContext(ctx, records, perPage)
 	if err != nil {
 		app.cmd.Errorf("[%s requested] Getting Lidarr Queue (instance %d): %v", input.Type, app.idx+1, err)
 		return
 	}
 
-	for _, record := range queue.Records {
+	downloadIDs := make([]string, len(queue.Records))
+
+	for idx, record := range queue.Records {
+		downloadIDs[idx] = record.DownloadID
 		record.Quality = nil
 	}
 
 	app.cmd.Debugf("[%s requested] Stored Lidarr Queue (%d items), instance %d %s",
 		input.Type, len(queue.Records), app.idx+1, app.app.Name)
 	data.SaveWithID("lidarr", app.idx, queue)
+	app.cmd.ages.update(starr.Lidarr, app.idx+1, downloadIDs)
 }
 
 func (c *cmd) setupLidarr() bool {
@@ -57,9 +64,9 @@ func (c *cmd) setupLidarr() bool {
 
 		instance := idx + 1
 		if ci.Actions.Apps.Lidarr.Finished(instance) {
-			ticker = time.NewTicker(finishedDuration)
+			ticker = time.NewTicker(c.queueConfig.pollInterval(starr.Lidarr, instance, true))
 		} else if ci.Actions.Apps.Lidarr.Stuck(instance) {
-			ticker = time.NewTicker(stuckDuration)
+			ticker = time.NewTicker(c.queueConfig.pollInterval(starr.Lidarr, instance, false))
 		}
 
 		if ticker != nil {
@@ -0,0 +1,299 @@
+package starrqueue
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Notifiarr/notifiarr/pkg/triggers/data"
+	"golift.io/starr"
+	"golift.io/starr/lidarr"
+	"golift.io/starr/radarr"
+	"golift.io/starr/readarr"
+	"golift.io/starr/sonarr"
+)
+
+/* This file contains the procedures to display the cached download queues locally; API and GUI. */
+
+// QueueItem is a cached Starr app queue record, formatted the same for every app.
+type QueueItem struct {
+	App            starr.App `json:"app"`
+	Instance       int       `json:"instance"`
+	Name           string    `json:"name"` // instance name.
+	ID             int64     `json:"id"`
+	DownloadID     string    `json:"downloadId"`
+	Title          string    `json:"title"`
+	Status         string    `json:"status"`
+	TrackedStatus  string    `json:"trackedStatus"`
+	Protocol       string    `json:"protocol"`
+	DownloadClient string    `json:"downloadClient"`
+	Indexer        string    `json:"indexer"`
+	Size           float64   `json:"size"`
+	SizeLeft       float64   `json:"sizeLeft"`
+	TimeLeft       string    `json:"timeLeft"`
+	FirstSeen      time.Time `json:"firstSeen"` // the first time this download was found in the cached queue.
+	Age            string    `json:"age"`
+	Updated        time.Time `json:"updated"` // when the queue was cached.
+	Stuck          bool      `json:"stuck"`
+	Messages       []string  `json:"messages"`
+}
+
+// queueAges tracks the first time every download was seen in a cached queue.
+// The Starr apps do not provide the time an item was added to the queue.
+type queueAges struct {
+	seen map[string]time.Time
+	mu   sync.Mutex
+}
+
+// Progress returns the downloaded percentage.
+func (q *QueueItem) Progress() float64 {
+	if q.Size <= 0 {
+		return 0
+	}
+
+	return (q.Size - q.SizeLeft) / q.Size * 100 //nolint:gomnd // percent.
+}
+
+// update replaces the download IDs for an app instance and keeps the first seen times for existing IDs.
+func (q *queueAges) update(app starr.App, instance int, downloadIDs []string) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	prefix := string(app) + strconv.Itoa(instance) + ":"
+	current := make(map[string]time.Time)
+
+	for _, id := range downloadIDs {
+		key := prefix + id
+		if seen, ok := q.seen[key]; ok {
+			current[key] = seen
+		} else {
+			current[key] = time.Now()
+		}
+	}
+
+	for key, seen := range q.seen {
+		if !strings.HasPrefix(key, prefix) {
+			current[key] = seen
+		}
+	}
+
+	q.seen = current
+}
+
+func (q *queueAges) get(app starr.App, instance int, downloadID string) time.Time {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return q.seen[string(app)+strconv.Itoa(instance)+":"+downloadID]
+}
+
+// QueueItems returns the cached queue items for all Starr app instances. Does not fetch fresh data.
+func (a *Action) QueueItems() []*QueueItem {
+	return a.cmd.queueItems()
+}
+
+// HandleQueue returns the cached queue items for all Starr app instances.
+// @Summary      Retrieve cached Starr queue items.
+// @Description  Returns the download queue items cached for stuck and downloading items on the website.
+// @Description  Does not make requests to the Starr apps; instances without a cached queue are not included.
+// @Tags         Triggers
+// @Produce      json
+// @Param        app      query string false "only include this app: lidarr, radarr, readarr or sonarr"
+// @Param        instance query int    false "only include this instance number, requires app"
+// @Param        status   query string false "only include items with this status or tracked status, comma separated"
+// @Param        stuck    query bool   false "only include stuck items"
+// @Param        search   query string false "only include items with this text in the title"
+// @Param        sort     query string false "sort by app, title, status, protocol, size, progress or age; default app"
+// @Param        desc     query bool   false "sort in descending order"
+// @Success      200  {object} apps.Respond.apiResponse{message=[]starrqueue.QueueItem} "queue items"
+// @Failure      404  {object} string "bad token or api key"
+// @Router       /api/queue [get]
+// @Security     ApiKeyAuth
+func (a *Action) HandleQueue(r *http.Request) (int, interface{}) {
+	query := r.URL.Query()
+	items := filterQueue(a.cmd.queueItems(), query.Get("app"), query.Get("instance"),
+		query.Get("status"), query.Get("search"), query.Get("stuck") == "true")
+	sortQueue(items, query.Get("sort"), query.Get("desc") == "true")
+
+	return http.StatusOK, items
+}
+
+func filterQueue(items []*QueueItem, app, instance, status, search string, stuck bool) []*QueueItem {
+	var (
+		statuses = []string{}
+		idx, _   = strconv.Atoi(instance)
+		output   = []*QueueItem{}
+	)
+
+	for _, s := range strings.Split(status, ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			statuses = append(statuses, strings.ToLower(s))
+		}
+	}
+
+	for _, item := range items {
+		if (app != "" && !strings.EqualFold(string(item.App), app)) ||
+			(idx > 0 && item.Instance != idx) ||
+			(stuck && !item.Stuck) ||
+			(search != "" && !strings.Contains(strings.ToLower(item.Title), strings.ToLower(search))) ||
+			(len(statuses) > 0 && !matchStatus(item, statuses)) {
+			continue
+		}
+
+		output = append(output, item)
+	}
+
+	return output
+}
+
+func matchStatus(item *QueueItem, statuses []string) bool {
+	for _, status := range statuses {
+		if strings.EqualFold(item.Status, status) || strings.EqualFold(item.TrackedStatus, status) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func sortQueue(items []*QueueItem, field string, desc bool) {
+	less := func(i, j int) bool {
+		switch strings.ToLower(field) {
+		case "title":
+			return strings.ToLower(items[i].Title) < strings.ToLower(items[j].Title)
+		case "status":
+			return items[i].Status < items[j].Status
+		case "protocol":
+			return items[i].Protocol < items[j].Protocol
+		case "size":
+			return items[i].Size < items[j].Size
+		case "progress":
+			return items[i].Progress() < items[j].Progress()
+		case "age": // older items have an earlier first seen time.
+			return items[i].FirstSeen.After(items[j].FirstSeen)
+		default:
+			if items[i].App != items[j].App {
+				return items[i].App < items[j].App
+			}
+
+			return items[i].Instance < items[j].Instance
+		}
+	}
+
+	sort.SliceStable(items, func(i, j int) bool {
+		if desc {
+			return less(j, i)
+		}
+
+		return less(i, j)
+	})
+}
+
+// queueItems collects the cached queues for every app. The queues are only cached
+// for instances with stuck or downloading items enabled on the website.
+func (c *cmd) queueItems() []*QueueItem {
+	items := []*QueueItem{}
+
+	for idx, app := range c.Apps.Lidarr {
+		if cache := data.GetWithID("lidarr", idx); cache != nil {
+			queue, ok := cache.Data.(*lidarr.Queue)
+			if !ok || queue == nil {
+				continue
+			}
+
+			for _, rec := range queue.Records {
+				items = append(items, c.newQueueItem(starr.Lidarr, idx+1, app.Name, cache.Time, &QueueItem{
+					ID: rec.ID, DownloadID: rec.DownloadID, Title: rec.Title, Status: rec.Status,
+					TrackedStatus: rec.TrackedDownloadStatus, Protocol: rec.Protocol,
+					DownloadClient: rec.DownloadClient, Indexer: rec.Indexer,
+					Size: rec.Size, SizeLeft: rec.Sizeleft, TimeLeft: rec.Timeleft,
+				}, rec.ErrorMessage, rec.StatusMessages))
+			}
+		}
+	}
+
+	for idx, app := range c.Apps.Radarr {
+		if cache := data.GetWithID("radarr", idx); cache != nil {
+			queue, ok := cache.Data.(*radarr.Queue)
+			if !ok || queue == nil {
+				continue
+			}
+
+			for _, rec := range queue.Records {
+				items = append(items, c.newQueueItem(starr.Radarr, idx+1, app.Name, cache.Time, &QueueItem{
+					ID: rec.ID, DownloadID: rec.DownloadID, Title: rec.Title, Status: rec.Status,
+					TrackedStatus: rec.TrackedDownloadStatus, Protocol: rec.Protocol,
+					DownloadClient: rec.DownloadClient, Indexer: rec.Indexer,
+					Size: rec.Size, SizeLeft: rec.Sizeleft, TimeLeft: rec.Timeleft,
+				}, rec.ErrorMessage, rec.StatusMessages))
+			}
+		}
+	}
+
+	for idx, app := range c.Apps.Readarr {
+		if cache := data.GetWithID("readarr", idx); cache != nil {
+			queue, ok := cache.Data.(*readarr.Queue)
+			if !ok || queue == nil {
+				continue
+			}
+
+			for _, rec := range queue.Records {
+				items = append(items, c.newQueueItem(starr.Readarr, idx+1, app.Name, cache.Time, &QueueItem{
+					ID: rec.ID, DownloadID: rec.DownloadID, Title: rec.Title, Status: rec.Status,
+					TrackedStatus: rec.TrackedDownloadStatus, Protocol: rec.Protocol,
+					DownloadClient: rec.DownloadClient, Indexer: rec.Indexer,
+					Size: rec.Size, SizeLeft: rec.Sizeleft, TimeLeft: rec.Timeleft,
+				}, rec.ErrorMessage, rec.StatusMessages))
+			}
+		}
+	}
+
+	for idx, app := range c.Apps.Sonarr {
+		if cache := data.GetWithID("sonarr", idx); cache != nil {
+			queue, ok := cache.Data.(*sonarr.Queue)
+			if !ok || queue == nil {
+				continue
+			}
+
+			for _, rec := range queue.Records {
+				items = append(items, c.newQueueItem(starr.Sonarr, idx+1, app.Name, cache.Time, &QueueItem{
+					ID: rec.ID, DownloadID: rec.DownloadID, Title: rec.Title, Status: rec.Status,
+					TrackedStatus: rec.TrackedDownloadStatus, Protocol: rec.Protocol,
+					DownloadClient: rec.DownloadClient, Indexer: rec.Indexer,
+					Size: rec.Size, SizeLeft: rec.Sizeleft, TimeLeft: rec.Timeleft,
+				}, rec.ErrorMessage, rec.StatusMessages))
+			}
+		}
+	}
+
+	return items
+}
+
+func (c *cmd) newQueueItem(
+	app starr.App,
+	instance int,
+	name string,
+	updated time.Time,
+	item *QueueItem,
+	errMsg string,
+	msgs []*starr.StatusMessage,
+) *QueueItem {
+	// Use the same logic the stuck queue remediation uses.
+	stuck := newQueueItem(item.ID, item.DownloadID, item.Title, item.Status, item.TrackedStatus, errMsg, msgs)
+
+	item.App = app
+	item.Instance = instance
+	item.Name = name
+	item.Updated = updated
+	item.Stuck = stuck.stuck()
+	item.Messages = stuck.messages
+
+	if item.FirstSeen = c.ages.get(app, instance, item.DownloadID); !item.FirstSeen.IsZero() {
+		item.Age = time.Since(item.FirstSeen).Round(time.Second).String()
+	}
+
+	return item
+}
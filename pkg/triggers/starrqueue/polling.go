@@ -0,0 +1,249 @@
+package starrqueue
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golift.io/cnfg"
+	"golift.io/starr"
+	"golift.io/starr/lidarr"
+	"golift.io/starr/radarr"
+	"golift.io/starr/readarr"
+	"golift.io/starr/sonarr"
+)
+
+/* This file contains the procedures to configure queue polling, and to only send changed queue items. */
+
+const (
+	// minQueueInterval is the shortest allowed queue polling interval.
+	minQueueInterval = 10 * time.Second
+	// fullResend is how often a full queue is sent when incremental updates are enabled.
+	fullResend = time.Hour
+)
+
+// ErrInvalidQueue is returned when the starr queue config is not valid.
+var ErrInvalidQueue = fmt.Errorf("invalid starr queue config")
+
+// QueueConfig is the [starr_queue] config section. It controls how the Starr app queues are polled.
+type QueueConfig struct {
+	StuckInterval       cnfg.Duration    `json:"stuckInterval" toml:"stuck_interval" xml:"stuck_interval" yaml:"stuckInterval"`
+	DownloadingInterval cnfg.Duration    `json:"downloadingInterval" toml:"downloading_interval" xml:"downloading_interval" yaml:"downloadingInterval"` //nolint:lll
+	PageSize            int              `json:"pageSize" toml:"page_size" xml:"page_size" yaml:"pageSize"`
+	MaxItems            int              `json:"maxItems" toml:"max_items" xml:"max_items" yaml:"maxItems"` // -1 is all items.
+	Incremental         bool             `json:"incremental" toml:"incremental" xml:"incremental" yaml:"incremental"`
+	Instances           []*QueueInstance `json:"instances" toml:"instance" xml:"instance" yaml:"instances"`
+}
+
+// QueueInstance overrides the queue polling settings for a single Starr app instance.
+type QueueInstance struct {
+	App      string        `json:"app" toml:"app" xml:"app" yaml:"app"` // lidarr, radarr, readarr or sonarr.
+	Instance int           `json:"instance" toml:"instance" xml:"instance" yaml:"instance"`
+	Interval cnfg.Duration `json:"interval" toml:"interval" xml:"interval" yaml:"interval"`
+	PageSize int           `json:"pageSize" toml:"page_size" xml:"page_size" yaml:"pageSize"`
+	MaxItems int           `json:"maxItems" toml:"max_items" xml:"max_items" yaml:"maxItems"`
+}
+
+// queueDiff keeps track of the queue items sent to the website, so only changes are sent.
+type queueDiff struct {
+	sent map[string]map[int64][32]byte // route+app+instance -> queue record ID -> checksum.
+	full map[string]time.Time          // route -> last full send.
+	mu   sync.Mutex
+}
+
+// Validate checks the starr queue config for errors.
+func (q *QueueConfig) Validate() error {
+	for _, dur := range []*cnfg.Duration{&q.StuckInterval, &q.DownloadingInterval} {
+		if dur.Duration != 0 && dur.Duration < minQueueInterval {
+			dur.Duration = minQueueInterval
+		}
+	}
+
+	for idx, inst := range q.Instances {
+		if inst == nil {
+			continue
+		}
+
+		switch inst.App = strings.ToLower(inst.App); inst.App {
+		case "lidarr", "radarr", "readarr", "sonarr":
+		default:
+			return fmt.Errorf("%w: instance %d: app must be one of lidarr, radarr, readarr or sonarr", ErrInvalidQueue, idx+1)
+		}
+
+		if inst.Instance < 1 {
+			return fmt.Errorf("%w: instance %d: instance number must be 1 or more", ErrInvalidQueue, idx+1)
+		}
+
+		if inst.Interval.Duration != 0 && inst.Interval.Duration < minQueueInterval {
+			inst.Interval.Duration = minQueueInterval
+		}
+	}
+
+	return nil
+}
+
+func (q *QueueConfig) instance(app starr.App, instance int) *QueueInstance {
+	if q == nil {
+		return nil
+	}
+
+	for _, inst := range q.Instances {
+		if inst != nil && inst.Instance == instance && strings.EqualFold(inst.App, string(app)) {
+			return inst
+		}
+	}
+
+	return nil
+}
+
+// stuckInterval is how often stuck items are sent, and queues are polled for stuck items.
+func (q *QueueConfig) stuckInterval() time.Duration {
+	if q == nil || q.StuckInterval.Duration == 0 {
+		return stuckDuration
+	}
+
+	return q.StuckInterval.Duration
+}
+
+// downloadingInterval is how often downloading items are sent, and queues are polled for downloading items.
+func (q *QueueConfig) downloadingInterval() time.Duration {
+	if q == nil || q.DownloadingInterval.Duration == 0 {
+		return finishedDuration
+	}
+
+	return q.DownloadingInterval.Duration
+}
+
+// pollInterval returns the queue polling interval for an app instance.
+func (q *QueueConfig) pollInterval(app starr.App, instance int, finished bool) time.Duration {
+	if inst := q.instance(app, instance); inst != nil && inst.Interval.Duration != 0 {
+		return inst.Interval.Duration
+	}
+
+	if finished {
+		return q.downloadingInterval()
+	}
+
+	return q.stuckInterval()
+}
+
+// queueSize returns the max number of records and the page size used to get a queue for an app instance.
+// Records of 0 gets every record in the queue.
+func (q *QueueConfig) queueSize(app starr.App, instance int) (int, int) {
+	records, perPage := queueItemsMax, queueItemsMax
+
+	if q != nil {
+		if q.MaxItems != 0 {
+			records = q.MaxItems
+		}
+
+		if q.PageSize > 0 {
+			perPage = q.PageSize
+		}
+	}
+
+	if inst := q.instance(app, instance); inst != nil {
+		if inst.MaxItems != 0 {
+			records = inst.MaxItems
+		}
+
+		if inst.PageSize > 0 {
+			perPage = inst.PageSize
+		}
+	}
+
+	if records < 0 {
+		records = 0
+	}
+
+	return records, perPage
+}
+
+// incremental removes the queue items that have not changed since the last time they were sent.
+// Every instance in the list gets the removed queue record IDs attached. A full list is sent once an hour.
+func (c *cmd) incremental(route string, payload *QueuesPaylod) {
+	if c.queueConfig == nil || !c.queueConfig.Incremental {
+		return
+	}
+
+	c.diff.mu.Lock()
+	defer c.diff.mu.Unlock()
+
+	full := time.Since(c.diff.full[route]) >= fullResend
+	if full {
+		c.diff.full[route] = time.Now()
+	}
+
+	payload.Incremental = !full
+
+	for app, list := range map[starr.App]itemList{
+		starr.Lidarr: payload.Lidarr, starr.Radarr: payload.Radarr,
+		starr.Readarr: payload.Readarr, starr.Sonarr: payload.Sonarr,
+	} {
+		for instance, item := range list {
+			list[instance] = c.diff.changes(route+string(app)+strconv.Itoa(instance), item, full)
+		}
+	}
+}
+
+func (d *queueDiff) changes(key string, list listItem, full bool) listItem {
+	current := make(map[int64][32]byte)
+	output := listItem{Name: list.Name}
+	previous := d.sent[key]
+
+	for _, record := range list.Queue {
+		body, _ := json.Marshal(record)
+		id, sum := queueRecordID(record), sha256.Sum256(body)
+		current[id] = sum
+
+		if old, ok := previous[id]; full || !ok || old != sum {
+			output.Queue = append(output.Queue, record)
+		}
+	}
+
+	if !full {
+		for id := range previous {
+			if _, ok := current[id]; !ok {
+				output.Removed = append(output.Removed, id)
+			}
+		}
+	}
+
+	d.sent[key] = current
+
+	return output
+}
+
+// unchanged returns true if an incremental payload has no changes.
+func (q *QueuesPaylod) unchanged() bool {
+	for _, list := range []itemList{q.Lidarr, q.Radarr, q.Readarr, q.Sonarr} {
+		for _, item := range list {
+			if len(item.Queue) > 0 || len(item.Removed) > 0 {
+				return false
+			}
+		}
+	}
+
+	return q.Incremental
+}
+
+// queueRecordID returns the queue record ID. Download IDs are not unique; a season pack has a record
+// for every episode with the same download ID.
+func queueRecordID(record interface{}) int64 {
+	switch rec := record.(type) {
+	case *lidarr.QueueRecord:
+		return rec.ID
+	case *radarr.QueueRecord:
+		return rec.ID
+	case *readarr.QueueRecord:
+		return rec.ID
+	case *sonarr.QueueRecord:
+		return rec.ID
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,55 @@
+package starrqueue
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golift.io/starr/sonarr"
+)
+
+func TestQueueDiffChanges(t *testing.T) {
+	t.Parallel()
+
+	assert := assert.New(t)
+	diff := &queueDiff{sent: make(map[string]map[int64][32]byte)}
+	send := func(full bool, records ...*sonarr.QueueRecord) (listItem, []int64) {
+		queue := make([]interface{}, len(records))
+		for idx, record := range records {
+			queue[idx] = record
+		}
+
+		output := diff.changes("key", listItem{Name: "name", Queue: queue}, full)
+		ids := []int64{}
+
+		for _, record := range output.Queue {
+			ids = append(ids, queueRecordID(record))
+		}
+
+		return output, ids
+	}
+	record := func(id int64, status string) *sonarr.QueueRecord {
+		return &sonarr.QueueRecord{ID: id, DownloadID: "pack", Status: status}
+	}
+
+	output, ids := send(false, record(1, "downloading"), record(2, "downloading"))
+	assert.Equal("name", output.Name)
+	assert.ElementsMatch([]int64{1, 2}, ids, "the first send has every record")
+	assert.Empty(output.Removed)
+
+	output, ids = send(false, record(1, "downloading"), record(2, "downloading"))
+	assert.Empty(ids, "nothing changed")
+	assert.Empty(output.Removed)
+
+	_, ids = send(false, record(1, "downloading"), record(2, "warning"))
+	assert.Equal([]int64{2}, ids, "only the changed record is sent")
+
+	output, ids = send(false, record(1, "downloading"))
+	assert.Empty(ids)
+	assert.Equal([]int64{2}, output.Removed, "a season pack episode was removed")
+
+	send(false, record(1, "downloading"), record(2, "downloading"))
+
+	output, ids = send(true, record(1, "downloading"))
+	assert.Equal([]int64{1}, ids, "a full send has every record")
+	assert.Empty(output.Removed, "a full send has no removals")
+}
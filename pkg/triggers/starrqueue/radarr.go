@@ -9,6 +9,7 @@ import (
 	"github.com/Notifiarr/notifiarr/pkg/triggers/data"
 	"github.com/Notifiarr/notifiarr/pkg/website"
 	"github.com/Notifiarr/notifiarr/pkg/website/clientinfo"
+	"golift.io/starr"
 )
 
 const TrigRadarrQueue common.TriggerName = "Storing Radarr instance %d queue."
@@ -29,13 +30,18 @@ type radarrApp struct {
 
 // storeQueue runs at an interval and saves the queue for an app internally.
 func (app *radarrApp) storeQueue(ctx context.Context, input *common.ActionInput) {
-	queue, err := app.app.GetQueueContext(ctx, queueItemsMax, 1)
+	records, perPage := app.cmd.queueConfig.queueSize(starr.Radarr, app.idx+1)
+
+	queue, err := app.app.GetQueueContext(ctx, records, perPage)
 	if err != nil {
 		app.cmd.Errorf("[%s requested] Getting Radarr Queue (instance %d): %v", input.Type, app.idx+1, err)
 		return
 	}
 
-	for _, item := range queue.Records {
+	downloadIDs := make([]string, len(queue.Records))
+
+	for idx, item := range queue.Records {
+		downloadIDs[idx] = item.DownloadID
 		item.Quality = nil
 		item.CustomFormats = nil
 		item.Languages = nil
@@ -44,6 +50,7 @@ func (app *radarrApp) storeQueue(ctx context.Context, input *common.ActionInput)
 	app.cmd.Debugf("[%s requested] Stored Radarr Queue (%d items), instance %d %s",
 		input.Type, len(queue.Records), app.idx+1, app.app.Name)
 	data.SaveWithID("radarr", app.idx, queue)
+	app.cmd.ages.update(starr.Radarr, app.idx+1, downloadIDs)
 }
 
 func (c *cmd) setupRadarr() bool {
@@ -59,9 +66,9 @@ func (c *cmd) setupRadarr() bool {
 
 		instance := idx + 1
 		if ci.Actions.Apps.Radarr.Finished(instance) {
-			ticker = time.NewTicker(finishedDuration)
+			ticker = time.NewTicker(c.queueConfig.pollInterval(starr.Radarr, instance, true))
 		} else if ci.Actions.Apps.Radarr.Stuck(instance) {
-			ticker = time.NewTicker(stuckDuration)
+			ticker = time.NewTicker(c.queueConfig.pollInterval(starr.Radarr, instance, false))
 		}
 
 		if ticker != nil {
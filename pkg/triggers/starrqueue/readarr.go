@@ -9,6 +9,7 @@ import (
 	"github.com/Notifiarr/notifiarr/pkg/triggers/data"
 	"github.com/Notifiarr/notifiarr/pkg/website"
 	"github.com/Notifiarr/notifiarr/pkg/website/clientinfo"
+	"golift.io/starr"
 )
 
 const TrigReadarrQueue common.TriggerName = "Storing Readarr instance %d queue."
@@ -29,19 +30,25 @@ func (a *Action) StoreReadarr(event website.EventType, instance int) {
 
 // storeQueue runs at an interval and saves the queue for an app internally.
 func (app *readarrApp) storeQueue(ctx context.Context, input *common.ActionInput) {
-	queue, err := app.app.GetQueueContext(ctx, queueItemsMax, 1)
+	records, perPage := app.cmd.queueConfig.queueSize(starr.Readarr, app.idx+1)
+
+	queue, err := app.app.GetQueueContext(ctx, records, perPage)
 	if err != nil {
 		app.cmd.Errorf("[%s requested] Getting Readarr Queue (instance %d): %v", input.Type, app.idx+1, err)
 		return
 	}
 
-	for _, record := range queue.Records {
+	downloadIDs := make([]string, len(queue.Records))
+
+	for idx, record := range queue.Records {
+		downloadIDs[idx] = record.DownloadID
 		record.Quality = nil
 	}
 
 	app.cmd.Debugf("[%s requested] Stored Readarr Queue (%d items), instance %d %s",
 		input.Type, len(queue.Records), app.idx+1, app.app.Name)
 	data.SaveWithID("readarr", app.idx, queue)
+	app.cmd.ages.update(starr.Readarr, app.idx+1, downloadIDs)
 }
 
 func (c *cmd) setupReadarr() bool {
@@ -60,10 +67,10 @@ func (c *cmd) setupReadarr() bool {
 		switch {
 		case ci.Actions.Apps.Readarr.Finished(instance):
 			enable = true
-			ticker = time.NewTicker(finishedDuration)
+			ticker = time.NewTicker(c.queueConfig.pollInterval(starr.Readarr, instance, true))
 		case ci.Actions.Apps.Readarr.Stuck(instance):
 			enable = true
-			ticker = time.NewTicker(stuckDuration)
+			ticker = time.NewTicker(c.queueConfig.pollInterval(starr.Readarr, instance, false))
 		default:
 			continue
 		}
@@ -0,0 +1,400 @@
+package starrqueue
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Notifiarr/notifiarr/pkg/triggers/common"
+	"github.com/Notifiarr/notifiarr/pkg/triggers/data"
+	"github.com/Notifiarr/notifiarr/pkg/website"
+	"golift.io/cnfg"
+	"golift.io/starr"
+)
+
+/* This file contains the procedures to fix stuck download queue items automatically. */
+
+const TrigRemedyItems common.TriggerName = "Fixing stuck queue items."
+
+const (
+	remedyFile = "stuck_queue.json"
+	// This is the max number of actions kept in the remediation audit log.
+	remedyLogMax = 250
+	// This is the max number of queue records to inspect per app instance when fixing stuck items.
+	remedyItemsMax = 1000
+	// This is how long to wait before acting on an item again, when it's still in the queue.
+	remedyRetry = 6 * time.Hour
+)
+
+// Remediation actions.
+const (
+	RemedyBlocklist = "blocklist" // remove from the queue and the download client, and blocklist the release.
+	RemedyRemove    = "remove"    // remove from the queue and the download client.
+	RemedySearch    = "search"    // search for the item again; nothing is removed.
+)
+
+// ErrInvalidRemedy is returned when a stuck queue rule is not valid.
+var ErrInvalidRemedy = fmt.Errorf("invalid stuck queue rule")
+
+// RemedyConfig is the [stuck_queue] config section.
+type RemedyConfig struct {
+	Interval cnfg.Duration `json:"interval" toml:"interval" xml:"interval" yaml:"interval"`
+	Retry    cnfg.Duration `json:"retry" toml:"retry" xml:"retry" yaml:"retry"`
+	DryRun   bool          `json:"dryRun" toml:"dry_run" xml:"dry_run" yaml:"dryRun"`
+	Rules    []*RemedyRule `json:"rules" toml:"rule" xml:"rule" yaml:"rules"`
+}
+
+// RemedyRule matches stuck queue items and fixes them once they've been stuck for Age.
+// Apps, Statuses and Messages narrow the matched items; empty matches everything.
+// The first matching rule wins.
+type RemedyRule struct {
+	Name     string        `json:"name" toml:"name" xml:"name" yaml:"name"`
+	Action   string        `json:"action" toml:"action" xml:"action" yaml:"action"`
+	Apps     []string      `json:"apps" toml:"apps" xml:"apps" yaml:"apps"` // radarr, sonarr, lidarr, readarr or radarr1.
+	Statuses []string      `json:"statuses" toml:"statuses" xml:"statuses" yaml:"statuses"`
+	Messages []string      `json:"messages" toml:"messages" xml:"messages" yaml:"messages"`
+	Age      cnfg.Duration `json:"age" toml:"age" xml:"age" yaml:"age"`
+	Search   bool          `json:"search" toml:"search" xml:"search" yaml:"search"` // search again after removing.
+	// KeepInClient leaves the download in the download client when it's removed from the queue.
+	KeepInClient bool `json:"keepInClient" toml:"keep_in_client" xml:"keep_in_client" yaml:"keepInClient"`
+}
+
+// Remedy is an action taken (or that would be taken, in dry run mode) on a stuck queue item.
+type Remedy struct {
+	Time       time.Time `json:"time"`
+	App        starr.App `json:"app"`
+	Instance   int       `json:"instance"`
+	Title      string    `json:"title"`
+	DownloadID string    `json:"downloadId"`
+	Rule       string    `json:"rule"`
+	Action     string    `json:"action"`
+	Search     bool      `json:"search"`
+	Reason     string    `json:"reason"`
+	DryRun     bool      `json:"dryRun"`
+	Error      string    `json:"error,omitempty"`
+}
+
+// remedy keeps track of how long items have been stuck, when they were last acted on, and the audit log.
+type remedy struct {
+	Since map[string]time.Time `json:"since"` // keyed by app, instance and download ID.
+	Acted map[string]time.Time `json:"acted"` // only items still in the queue (searched, dry run or failed).
+	Log   []*Remedy            `json:"log"`   // oldest first.
+	mu    sync.RWMutex
+}
+
+// Validate checks the stuck queue rules for errors.
+func (r *RemedyConfig) Validate() error {
+	if r.Interval.Duration <= 0 {
+		r.Interval.Duration = stuckDuration
+	}
+
+	if r.Retry.Duration <= 0 {
+		r.Retry.Duration = remedyRetry
+	}
+
+	for idx, rule := range r.Rules {
+		if rule == nil {
+			continue
+		}
+
+		if rule.Name == "" {
+			rule.Name = "rule " + strconv.Itoa(idx+1)
+		}
+
+		switch rule.Action = strings.ToLower(rule.Action); rule.Action {
+		case RemedyBlocklist, RemedyRemove, RemedySearch:
+		default:
+			return fmt.Errorf("%w: %s: action must be one of %s, %s or %s",
+				ErrInvalidRemedy, rule.Name, RemedyBlocklist, RemedyRemove, RemedySearch)
+		}
+
+		if rule.Age.Duration == 0 && len(rule.Statuses) == 0 && len(rule.Messages) == 0 {
+			return fmt.Errorf("%w: %s: a %s rule needs an age, statuses or messages",
+				ErrInvalidRemedy, rule.Name, rule.Action)
+		}
+	}
+
+	return nil
+}
+
+func (c *cmd) setupRemedy() {
+	if c.remedyConfig == nil || len(c.remedyConfig.Rules) == 0 {
+		return
+	}
+
+	c.remedy = &remedy{Since: make(map[string]time.Time), Acted: make(map[string]time.Time)}
+	if err := c.ReadDataFile(remedyFile, c.remedy); err != nil {
+		c.Errorf("Loading stuck queue remediation data: %v", err)
+	}
+
+	c.Printf("==> Stuck Queue Remediation timer started, interval:%s retry:%s rules:%d dry_run:%v",
+		c.remedyConfig.Interval, c.remedyConfig.Retry, len(c.remedyConfig.Rules), c.remedyConfig.DryRun)
+	c.Add(&common.Action{
+		Hide: true,
+		Name: TrigRemedyItems,
+		Fn:   c.fixStuckItems,
+		C:    make(chan *common.ActionInput, 1),
+		T:    time.NewTicker(c.remedyConfig.Interval.Duration),
+	})
+}
+
+// FixStuckItems checks all Starr queues for stuck items and fixes them now.
+func (a *Action) FixStuckItems(event website.EventType) bool {
+	return a.cmd.Exec(&common.ActionInput{Type: event}, TrigRemedyItems)
+}
+
+func (c *cmd) fixStuckItems(ctx context.Context, input *common.ActionInput) {
+	c.fixQueues(ctx, input, c.remedyQueues())
+}
+
+func (c *cmd) fixQueues(ctx context.Context, input *common.ActionInput, queues []*remedyQueue) {
+	var (
+		now     = time.Now()
+		current = make(map[string]time.Time)
+		acted   = make(map[string]time.Time)
+		taken   []*Remedy
+	)
+
+	for _, queue := range queues {
+		items, err := queue.list(ctx)
+		if err != nil {
+			c.Errorf("[%s requested] Stuck Queue: getting %s %d queue: %v", input.Type, queue.App, queue.Instance, err)
+			// Keep the stuck times for this app, so one bad request doesn't reset them.
+			c.remedy.keep(current, acted, queue)
+
+			continue
+		}
+
+		for _, item := range stuckItems(items) {
+			key := queue.key(item)
+			current[key] = c.remedy.since(key, now)
+
+			if last, ok := c.remedy.acted(key); ok {
+				if acted[key] = last; now.Sub(last) < c.remedyConfig.Retry.Duration {
+					continue // acted on it recently, and it's still in the queue.
+				}
+			}
+
+			rule, reason := c.matchRemedy(queue, item, now.Sub(current[key]))
+			if rule == nil {
+				continue
+			}
+
+			fixed := c.applyRemedy(ctx, queue, item, rule, reason)
+			taken = append(taken, fixed)
+
+			if !fixed.DryRun && fixed.Error == "" && rule.Action != RemedySearch {
+				delete(current, key) // it's gone now.
+				delete(acted, key)
+			} else {
+				acted[key] = now // still in the queue; wait for the retry period.
+			}
+		}
+	}
+
+	c.remedy.save(current, acted, taken)
+
+	if err := c.remedy.write(c.Config); err != nil {
+		c.Errorf("Saving stuck queue remediation data: %v", err)
+	}
+
+	if len(taken) > 0 {
+		c.Printf("[%s requested] Stuck Queue: took %d actions on stuck items, dry run: %v",
+			input.Type, len(taken), c.remedyConfig.DryRun)
+	}
+}
+
+// matchRemedy returns the first rule that matches a stuck item, and the reason it matched.
+func (c *cmd) matchRemedy(queue *remedyQueue, item *queueItem, age time.Duration) (*RemedyRule, string) {
+	for _, rule := range c.remedyConfig.Rules {
+		if rule == nil || age < rule.Age.Duration || !queue.Match(rule.Apps) || !rule.matchStatus(item) {
+			continue
+		}
+
+		message, ok := rule.matchMessage(item)
+		if !ok {
+			continue
+		}
+
+		reason := fmt.Sprintf("status %s stuck for %v", item.status, age.Round(time.Second))
+		if message != "" {
+			reason += ", message: " + message
+		}
+
+		return rule, reason
+	}
+
+	return nil, ""
+}
+
+func (c *cmd) applyRemedy(ctx context.Context, queue *remedyQueue, item *queueItem, rule *RemedyRule, reason string) *Remedy {
+	taken := &Remedy{
+		Time:       time.Now(),
+		App:        queue.App,
+		Instance:   queue.Instance,
+		Title:      item.title,
+		DownloadID: item.downloadID,
+		Rule:       rule.Name,
+		Action:     rule.Action,
+		Search:     rule.Search || rule.Action == RemedySearch,
+		Reason:     reason,
+		DryRun:     c.remedyConfig.DryRun,
+	}
+
+	if taken.DryRun {
+		c.Printf("Stuck Queue (dry run): would %s %s %d item '%s' (search: %v): %s",
+			rule.Action, queue.App, queue.Instance, item.title, taken.Search, reason)
+		return taken
+	}
+
+	var err error
+
+	if rule.Action != RemedySearch {
+		err = queue.remove(ctx, item.id, !rule.KeepInClient, rule.Action == RemedyBlocklist)
+	}
+
+	if err == nil && taken.Search && len(item.searchIDs) > 0 {
+		err = queue.search(ctx, item.searchIDs)
+	}
+
+	if err != nil {
+		taken.Error = fmt.Sprintf("%s %s %d item '%s': %v", rule.Action, queue.App, queue.Instance, item.title, err)
+		c.Errorf("Stuck Queue: %s", taken.Error)
+	} else {
+		c.Printf("Stuck Queue: %s %s %d item '%s' (search: %v): %s",
+			rule.Action, queue.App, queue.Instance, item.title, taken.Search, reason)
+	}
+
+	return taken
+}
+
+func (r *RemedyRule) matchStatus(item *queueItem) bool {
+	if len(r.Statuses) == 0 {
+		return true
+	}
+
+	for _, status := range r.Statuses {
+		if strings.EqualFold(status, item.status) || strings.EqualFold(status, item.tracked) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// matchMessage returns the matched message text, and true if the rule matches.
+func (r *RemedyRule) matchMessage(item *queueItem) (string, bool) {
+	if len(r.Messages) == 0 {
+		return "", true
+	}
+
+	for _, message := range item.messages {
+		for _, text := range r.Messages {
+			if strings.Contains(strings.ToLower(message), strings.ToLower(text)) {
+				return message, true
+			}
+		}
+	}
+
+	return "", false
+}
+
+// since returns the first time an item was seen stuck.
+func (r *remedy) since(key string, now time.Time) time.Time {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if since, ok := r.Since[key]; ok {
+		return since
+	}
+
+	return now
+}
+
+// acted returns the last time an item was acted on, if it was.
+func (r *remedy) acted(key string) (time.Time, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	last, ok := r.Acted[key]
+
+	return last, ok
+}
+
+// keep copies the stuck and acted times for one app instance into current and acted.
+func (r *remedy) keep(current, acted map[string]time.Time, queue *remedyQueue) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	prefix := queue.Key() + ":"
+
+	for key, since := range r.Since {
+		if strings.HasPrefix(key, prefix) {
+			current[key] = since
+		}
+	}
+
+	for key, last := range r.Acted {
+		if strings.HasPrefix(key, prefix) {
+			acted[key] = last
+		}
+	}
+}
+
+// save replaces the stuck and acted times (items no longer stuck are forgotten)
+// and adds actions to the audit log.
+func (r *remedy) save(current, acted map[string]time.Time, taken []*Remedy) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.Since = current
+	r.Acted = acted
+
+	if r.Log = append(r.Log, taken...); len(r.Log) > remedyLogMax {
+		r.Log = r.Log[len(r.Log)-remedyLogMax:]
+	}
+
+	data.Save("stuckQueueLog", r.list())
+}
+
+func (r *remedy) write(config *common.Config) error {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return config.WriteDataFile(remedyFile, r) //nolint:wrapcheck
+}
+
+// list returns the audit log, newest first. Must be locked.
+func (r *remedy) list() []*Remedy {
+	list := make([]*Remedy, 0, len(r.Log))
+	for idx := len(r.Log) - 1; idx >= 0; idx-- {
+		list = append(list, r.Log[idx])
+	}
+
+	return list
+}
+
+// HandleRemedyLog returns the audit log of actions taken on stuck queue items.
+// @Summary      Retrieve stuck queue audit log.
+// @Description  Returns the actions taken on stuck Starr queue items by the stuck queue rules, newest first.
+// @Tags         Triggers
+// @Produce      json
+// @Success      200  {object} apps.Respond.apiResponse{message=[]starrqueue.Remedy} "audit log"
+// @Failure      501  {object} apps.Respond.apiResponse{message=string} "no stuck queue rules"
+// @Failure      404  {object} string "bad token or api key"
+// @Router       /api/stuckqueue/log [get]
+// @Security     ApiKeyAuth
+func (a *Action) HandleRemedyLog(_ *http.Request) (int, interface{}) {
+	if a.cmd.remedy == nil {
+		return http.StatusNotImplemented, "No stuck queue rules configured."
+	}
+
+	a.cmd.remedy.mu.RLock()
+	defer a.cmd.remedy.mu.RUnlock()
+
+	return http.StatusOK, a.cmd.remedy.list()
+}
@@ -0,0 +1,194 @@
+package starrqueue
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Notifiarr/notifiarr/pkg/logs"
+	"github.com/Notifiarr/notifiarr/pkg/triggers/common"
+	"github.com/Notifiarr/notifiarr/pkg/website"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golift.io/cnfg"
+	"golift.io/starr"
+)
+
+// fakeQueue is a Starr queue that records the removals and searches made by the stuck queue rules.
+type fakeQueue struct {
+	items    []*queueItem
+	removed  []int64
+	searched [][]int64
+}
+
+func (f *fakeQueue) queue() *remedyQueue {
+	return &remedyQueue{
+		StarrApp: common.StarrApp{App: starr.Sonarr, Instance: 1},
+		list: func(context.Context) ([]*queueItem, error) {
+			// Return copies, because stuckItems merges season pack records.
+			items := make([]*queueItem, len(f.items))
+			for idx, item := range f.items {
+				dup := *item
+				items[idx] = &dup
+			}
+
+			return items, nil
+		},
+		remove: func(_ context.Context, id int64, _, _ bool) error {
+			f.removed = append(f.removed, id)
+			return nil
+		},
+		search: func(_ context.Context, ids []int64) error {
+			f.searched = append(f.searched, ids)
+			return nil
+		},
+	}
+}
+
+func testRemedyCmd(config *RemedyConfig) *cmd {
+	if err := config.Validate(); err != nil {
+		panic(err)
+	}
+
+	return &cmd{
+		Config:       &common.Config{Logger: logs.New()},
+		remedyConfig: config,
+		remedy:       &remedy{Since: make(map[string]time.Time), Acted: make(map[string]time.Time)},
+	}
+}
+
+func seasonPack() []*queueItem {
+	return []*queueItem{
+		{id: 1, downloadID: "pack", status: "warning", searchIDs: []int64{101}},
+		{id: 2, downloadID: "pack", status: "warning", searchIDs: []int64{102}},
+		{id: 3, downloadID: "pack", status: "warning", searchIDs: []int64{103}},
+	}
+}
+
+func TestRemedyValidate(t *testing.T) {
+	t.Parallel()
+
+	config := &RemedyConfig{Rules: []*RemedyRule{nil, {Action: "Search", Statuses: []string{"warning"}}}}
+	require.NoError(t, config.Validate())
+	assert.Equal(t, stuckDuration, config.Interval.Duration)
+	assert.Equal(t, remedyRetry, config.Retry.Duration)
+	assert.Equal(t, "rule 2", config.Rules[1].Name)
+	assert.Equal(t, RemedySearch, config.Rules[1].Action)
+
+	for _, action := range []string{RemedyBlocklist, RemedyRemove, RemedySearch} {
+		config := &RemedyConfig{Rules: []*RemedyRule{{Action: action}}}
+		assert.ErrorIs(t, config.Validate(), ErrInvalidRemedy, "an unfiltered %s rule must be rejected", action)
+	}
+
+	config = &RemedyConfig{Rules: []*RemedyRule{{Action: "fix", Age: cnfg.Duration{Duration: time.Hour}}}}
+	assert.ErrorIs(t, config.Validate(), ErrInvalidRemedy)
+}
+
+func TestQueueItemStuck(t *testing.T) {
+	t.Parallel()
+
+	assert := assert.New(t)
+
+	assert.True((&queueItem{status: "Warning"}).stuck())
+	assert.True((&queueItem{status: "failed"}).stuck())
+	assert.True((&queueItem{status: "completed", tracked: "warning"}).stuck())
+	assert.False((&queueItem{status: "completed"}).stuck(), "completed items wait to be imported")
+	assert.True((&queueItem{status: "downloading", messages: []string{"No files found"}}).stuck())
+	assert.False((&queueItem{status: "downloading"}).stuck())
+	assert.False((&queueItem{status: "queued"}).stuck())
+}
+
+func TestMatchRemedy(t *testing.T) {
+	t.Parallel()
+
+	c := testRemedyCmd(&RemedyConfig{Rules: []*RemedyRule{
+		{Name: "radarr only", Action: RemedyRemove, Apps: []string{"radarr"}, Age: cnfg.Duration{Duration: time.Minute}},
+		{Name: "samples", Action: RemedyBlocklist, Messages: []string{"SAMPLE"}},
+		{Name: "old warnings", Action: RemedySearch, Statuses: []string{"warning"}, Age: cnfg.Duration{Duration: time.Hour}},
+	}})
+	queue := &remedyQueue{StarrApp: common.StarrApp{App: starr.Sonarr, Instance: 1}}
+
+	rule, reason := c.matchRemedy(queue, &queueItem{status: "warning", messages: []string{"Found a sample file"}}, 0)
+	require.NotNil(t, rule, "the sonarr queue skips the radarr rule and matches the message")
+	assert.Equal(t, "samples", rule.Name)
+	assert.Contains(t, reason, "Found a sample file")
+
+	rule, _ = c.matchRemedy(queue, &queueItem{status: "completed", tracked: "Warning"}, 2*time.Hour)
+	require.NotNil(t, rule, "the tracked status matches too")
+	assert.Equal(t, "old warnings", rule.Name)
+
+	rule, _ = c.matchRemedy(queue, &queueItem{status: "warning"}, 30*time.Minute)
+	assert.Nil(t, rule, "not stuck long enough")
+
+	rule, _ = c.matchRemedy(&remedyQueue{StarrApp: common.StarrApp{App: starr.Radarr, Instance: 2}}, &queueItem{status: "queued"}, time.Hour)
+	require.NotNil(t, rule, "apps match without the instance number")
+	assert.Equal(t, "radarr only", rule.Name)
+}
+
+func TestStuckItems(t *testing.T) {
+	t.Parallel()
+
+	items := append(seasonPack(),
+		&queueItem{id: 4, downloadID: "pack", status: "warning", searchIDs: []int64{101}},
+		&queueItem{id: 5, status: "failed"},
+		&queueItem{id: 6, status: "failed"},
+		&queueItem{id: 7, downloadID: "fine", status: "downloading"},
+	)
+	stuck := stuckItems(items)
+	queue := (&fakeQueue{}).queue()
+
+	require.Len(t, stuck, 3)
+	assert.Equal(t, []int64{101, 102, 103}, stuck[0].searchIDs, "every episode in the pack is searched once")
+	assert.Equal(t, "Sonarr1:pack", queue.key(stuck[0]))
+	assert.Equal(t, "Sonarr1:id5", queue.key(stuck[1]), "items without a download ID are keyed by record ID")
+	assert.Equal(t, "Sonarr1:id6", queue.key(stuck[2]))
+}
+
+func TestFixQueuesSearchRetry(t *testing.T) {
+	t.Parallel()
+
+	fake := &fakeQueue{items: seasonPack()}
+	c := testRemedyCmd(&RemedyConfig{Rules: []*RemedyRule{{Action: RemedySearch, Statuses: []string{"warning"}}}})
+	input := &common.ActionInput{Type: website.EventUser}
+
+	c.fixQueues(context.Background(), input, []*remedyQueue{fake.queue()})
+	c.fixQueues(context.Background(), input, []*remedyQueue{fake.queue()})
+
+	assert.Equal(t, [][]int64{{101, 102, 103}}, fake.searched, "the second run is inside the retry period")
+	assert.Empty(t, fake.removed)
+	assert.Len(t, c.remedy.Log, 1)
+	assert.Contains(t, c.remedy.Since, "Sonarr1:pack", "a searched item is still stuck")
+
+	// Pretend the retry period passed.
+	c.remedy.Acted["Sonarr1:pack"] = time.Now().Add(-remedyRetry)
+	c.fixQueues(context.Background(), input, []*remedyQueue{fake.queue()})
+	assert.Len(t, fake.searched, 2)
+
+	// Once it's no longer stuck, it's forgotten.
+	fake.items = nil
+	c.fixQueues(context.Background(), input, []*remedyQueue{fake.queue()})
+	assert.Empty(t, c.remedy.Since)
+	assert.Empty(t, c.remedy.Acted)
+}
+
+func TestFixQueuesRemove(t *testing.T) {
+	t.Parallel()
+
+	fake := &fakeQueue{items: seasonPack()}
+	rules := []*RemedyRule{{Action: RemedyBlocklist, Statuses: []string{"warning"}, Search: true}}
+	input := &common.ActionInput{Type: website.EventUser}
+
+	dryRun := testRemedyCmd(&RemedyConfig{DryRun: true, Rules: rules})
+	dryRun.fixQueues(context.Background(), input, []*remedyQueue{fake.queue()})
+	dryRun.fixQueues(context.Background(), input, []*remedyQueue{fake.queue()})
+	assert.Empty(t, fake.removed, "dry run removes nothing")
+	assert.Empty(t, fake.searched, "dry run searches nothing")
+	assert.Len(t, dryRun.remedy.Log, 1, "dry run logs the item once per retry period")
+
+	c := testRemedyCmd(&RemedyConfig{Rules: rules})
+	c.fixQueues(context.Background(), input, []*remedyQueue{fake.queue()})
+	assert.Equal(t, []int64{1}, fake.removed, "removing one record removes the whole download")
+	assert.Equal(t, [][]int64{{101, 102, 103}}, fake.searched)
+	assert.NotContains(t, c.remedy.Since, "Sonarr1:pack", "a removed item is forgotten")
+	assert.NotContains(t, c.remedy.Acted, "Sonarr1:pack")
+}
@@ -0,0 +1,274 @@
+package starrqueue
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/Notifiarr/notifiarr/pkg/apps"
+	"github.com/Notifiarr/notifiarr/pkg/mnd"
+	"github.com/Notifiarr/notifiarr/pkg/triggers/common"
+	"golift.io/starr"
+	"golift.io/starr/lidarr"
+	"golift.io/starr/radarr"
+	"golift.io/starr/readarr"
+	"golift.io/starr/sonarr"
+)
+
+// queueItem is the data the stuck queue rules need from any Starr app queue record.
+type queueItem struct {
+	id         int64
+	downloadID string
+	title      string
+	status     string
+	tracked    string // tracked download status.
+	messages   []string
+	searchIDs  []int64 // movie, episode, album or book IDs for searches.
+}
+
+// remedyQueue wraps the different Starr apps, so the stuck queue rules can be applied to them the same way.
+type remedyQueue struct {
+	common.StarrApp
+	list   func(ctx context.Context) ([]*queueItem, error)
+	remove func(ctx context.Context, id int64, fromClient, blocklist bool) error
+	search func(ctx context.Context, ids []int64) error
+}
+
+// stuck is like the stuck items sent to the website, but a completed item is only stuck
+// if it has a warning or error. Completed items normally sit in the queue until they're imported.
+func (i *queueItem) stuck() bool {
+	switch s, t := strings.ToLower(i.status), strings.ToLower(i.tracked); {
+	case s == warning || s == failed || s == errorstr || len(i.messages) > 0:
+		return true
+	case s == completed:
+		return t == warning || t == errorstr
+	default:
+		return false
+	}
+}
+
+// key identifies a stuck download. Records without a download ID are keyed by their queue record ID.
+func (q *remedyQueue) key(item *queueItem) string {
+	if item.downloadID == "" {
+		return q.Key() + ":id" + strconv.FormatInt(item.id, mnd.Base10)
+	}
+
+	return q.Key() + ":" + item.downloadID
+}
+
+// stuckItems returns one item for each stuck download. Season packs have a record for every
+// episode; their search IDs are merged into the first record, so every episode is searched.
+func stuckItems(items []*queueItem) []*queueItem {
+	stuck := []*queueItem{}
+	downloads := make(map[string]*queueItem)
+
+	for _, item := range items {
+		if !item.stuck() {
+			continue
+		}
+
+		first, ok := downloads[item.downloadID]
+		if !ok || item.downloadID == "" {
+			downloads[item.downloadID] = item
+			stuck = append(stuck, item)
+
+			continue
+		}
+
+		for _, id := range item.searchIDs {
+			if !containsID(first.searchIDs, id) {
+				first.searchIDs = append(first.searchIDs, id)
+			}
+		}
+	}
+
+	return stuck
+}
+
+func containsID(ids []int64, find int64) bool {
+	for _, id := range ids {
+		if id == find {
+			return true
+		}
+	}
+
+	return false
+}
+
+func newQueueItem(id int64, downloadID, title, status, tracked, errMsg string, msgs []*starr.StatusMessage) *queueItem {
+	item := &queueItem{id: id, downloadID: downloadID, title: title, status: status, tracked: tracked}
+
+	if errMsg != "" {
+		item.messages = append(item.messages, errMsg)
+	}
+
+	for _, msg := range msgs {
+		if msg == nil {
+			continue
+		}
+
+		if len(msg.Messages) == 0 {
+			item.messages = append(item.messages, msg.Title)
+		}
+
+		for _, text := range msg.Messages {
+			item.messages = append(item.messages, msg.Title+": "+text)
+		}
+	}
+
+	return item
+}
+
+// removeQueueItem deletes a record from a Starr app queue. The starr library does not provide this yet.
+func removeQueueItem(ctx context.Context, api starr.APIer, apiVer string, id int64, fromClient, blocklist bool) error {
+	err := api.DeleteAny(ctx, starr.Request{
+		URI: path.Join(apiVer, "queue", strconv.FormatInt(id, mnd.Base10)),
+		Query: url.Values{
+			"removeFromClient": {strconv.FormatBool(fromClient)},
+			"blocklist":        {strconv.FormatBool(blocklist)},
+			"blacklist":        {strconv.FormatBool(blocklist)}, // older versions.
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("removing queue item %d: %w", id, err)
+	}
+
+	return nil
+}
+
+// remedyQueues returns all the enabled Starr apps.
+func (c *cmd) remedyQueues() []*remedyQueue {
+	queues := []*remedyQueue{}
+
+	(&common.StarrApps{
+		Lidarr:  func(instance int, app *apps.LidarrConfig) { queues = append(queues, lidarrRemedy(instance, app)) },
+		Radarr:  func(instance int, app *apps.RadarrConfig) { queues = append(queues, radarrRemedy(instance, app)) },
+		Readarr: func(instance int, app *apps.ReadarrConfig) { queues = append(queues, readarrRemedy(instance, app)) },
+		Sonarr:  func(instance int, app *apps.SonarrConfig) { queues = append(queues, sonarrRemedy(instance, app)) },
+	}).Each(c.Apps)
+
+	return queues
+}
+
+func lidarrRemedy(instance int, app *apps.LidarrConfig) *remedyQueue {
+	return &remedyQueue{
+		StarrApp: common.StarrApp{App: starr.Lidarr, Instance: instance},
+		remove: func(ctx context.Context, id int64, fromClient, blocklist bool) error {
+			return removeQueueItem(ctx, app.APIer, lidarr.APIver, id, fromClient, blocklist)
+		},
+		search: func(ctx context.Context, ids []int64) error {
+			_, err := app.SendCommandContext(ctx, &lidarr.CommandRequest{Name: "AlbumSearch", AlbumIDs: ids})
+			return err //nolint:wrapcheck
+		},
+		list: func(ctx context.Context) ([]*queueItem, error) {
+			queue, err := app.GetQueueContext(ctx, remedyItemsMax, remedyItemsMax)
+			if err != nil {
+				return nil, err //nolint:wrapcheck
+			}
+
+			items := make([]*queueItem, len(queue.Records))
+			for idx, rec := range queue.Records {
+				items[idx] = newQueueItem(rec.ID, rec.DownloadID, rec.Title, rec.Status,
+					rec.TrackedDownloadStatus, rec.ErrorMessage, rec.StatusMessages)
+				if rec.AlbumID != 0 {
+					items[idx].searchIDs = []int64{rec.AlbumID}
+				}
+			}
+
+			return items, nil
+		},
+	}
+}
+
+func radarrRemedy(instance int, app *apps.RadarrConfig) *remedyQueue {
+	return &remedyQueue{
+		StarrApp: common.StarrApp{App: starr.Radarr, Instance: instance},
+		remove: func(ctx context.Context, id int64, fromClient, blocklist bool) error {
+			return removeQueueItem(ctx, app.APIer, radarr.APIver, id, fromClient, blocklist)
+		},
+		search: func(ctx context.Context, ids []int64) error {
+			_, err := app.SendCommandContext(ctx, &radarr.CommandRequest{Name: "MoviesSearch", MovieIDs: ids})
+			return err //nolint:wrapcheck
+		},
+		list: func(ctx context.Context) ([]*queueItem, error) {
+			queue, err := app.GetQueueContext(ctx, remedyItemsMax, remedyItemsMax)
+			if err != nil {
+				return nil, err //nolint:wrapcheck
+			}
+
+			items := make([]*queueItem, len(queue.Records))
+			for idx, rec := range queue.Records {
+				items[idx] = newQueueItem(rec.ID, rec.DownloadID, rec.Title, rec.Status,
+					rec.TrackedDownloadStatus, rec.ErrorMessage, rec.StatusMessages)
+				if rec.MovieID != 0 {
+					items[idx].searchIDs = []int64{rec.MovieID}
+				}
+			}
+
+			return items, nil
+		},
+	}
+}
+
+func readarrRemedy(instance int, app *apps.ReadarrConfig) *remedyQueue {
+	return &remedyQueue{
+		StarrApp: common.StarrApp{App: starr.Readarr, Instance: instance},
+		remove: func(ctx context.Context, id int64, fromClient, blocklist bool) error {
+			return removeQueueItem(ctx, app.APIer, readarr.APIver, id, fromClient, blocklist)
+		},
+		search: func(ctx context.Context, ids []int64) error {
+			_, err := app.SendCommandContext(ctx, &readarr.CommandRequest{Name: "BookSearch", BookIDs: ids})
+			return err //nolint:wrapcheck
+		},
+		list: func(ctx context.Context) ([]*queueItem, error) {
+			queue, err := app.GetQueueContext(ctx, remedyItemsMax, remedyItemsMax)
+			if err != nil {
+				return nil, err //nolint:wrapcheck
+			}
+
+			items := make([]*queueItem, len(queue.Records))
+			for idx, rec := range queue.Records {
+				items[idx] = newQueueItem(rec.ID, rec.DownloadID, rec.Title, rec.Status,
+					rec.TrackedDownloadStatus, rec.ErrorMessage, rec.StatusMessages)
+				if rec.BookID != 0 {
+					items[idx].searchIDs = []int64{rec.BookID}
+				}
+			}
+
+			return items, nil
+		},
+	}
+}
+
+func sonarrRemedy(instance int, app *apps.SonarrConfig) *remedyQueue {
+	return &remedyQueue{
+		StarrApp: common.StarrApp{App: starr.Sonarr, Instance: instance},
+		remove: func(ctx context.Context, id int64, fromClient, blocklist bool) error {
+			return removeQueueItem(ctx, app.APIer, sonarr.APIver, id, fromClient, blocklist)
+		},
+		search: func(ctx context.Context, ids []int64) error {
+			_, err := app.SendCommandContext(ctx, &sonarr.CommandRequest{Name: "EpisodeSearch", EpisodeIDs: ids})
+			return err //nolint:wrapcheck
+		},
+		list: func(ctx context.Context) ([]*queueItem, error) {
+			queue, err := app.GetQueueContext(ctx, remedyItemsMax, remedyItemsMax)
+			if err != nil {
+				return nil, err //nolint:wrapcheck
+			}
+
+			items := make([]*queueItem, len(queue.Records))
+			for idx, rec := range queue.Records {
+				items[idx] = newQueueItem(rec.ID, rec.DownloadID, rec.Title, rec.Status,
+					rec.TrackedDownloadStatus, rec.ErrorMessage, rec.StatusMessages)
+				if rec.EpisodeID != 0 {
+					items[idx].searchIDs = []int64{rec.EpisodeID}
+				}
+			}
+
+			return items, nil
+		},
+	}
+}
@@ -16,7 +16,12 @@ type Action struct {
 type cmd struct {
 	*common.Config
 	// We set empty to true after we send 1 "empty downloads" payload.
-	empty bool
+	empty        bool
+	remedyConfig *RemedyConfig
+	remedy       *remedy
+	ages         *queueAges
+	queueConfig  *QueueConfig
+	diff         *queueDiff
 }
 
 const (
@@ -24,7 +29,7 @@ const (
 	stuckDuration = 5 * time.Minute
 	// How often to check starr apps for queue list when finished items is enabled.
 	finishedDuration = time.Minute
-	// This is the max number of queued items to inspect/send.
+	// This is the default max number of queued items to inspect/send.
 	queueItemsMax = 100
 )
 
@@ -48,11 +53,22 @@ type QueuesPaylod struct {
 	Radarr  itemList `json:"radarr"`
 	Readarr itemList `json:"readarr"`
 	Sonarr  itemList `json:"sonarr"`
+	// Incremental is true when the lists only contain changed items.
+	Incremental bool `json:"incremental,omitempty"`
 }
 
 // New configures the library.
-func New(config *common.Config) *Action {
-	return &Action{cmd: &cmd{Config: config}}
+func New(config *common.Config, queue *QueueConfig, remedy *RemedyConfig) *Action {
+	return &Action{cmd: &cmd{
+		Config:       config,
+		remedyConfig: remedy,
+		ages:         &queueAges{seen: make(map[string]time.Time)},
+		queueConfig:  queue,
+		diff: &queueDiff{
+			sent: make(map[string]map[int64][32]byte),
+			full: make(map[string]time.Time),
+		},
+	}}
 }
 
 // Run initializes the library.
@@ -67,7 +83,7 @@ func (a *Action) Create() {
 			Name: TrigStuckItems,
 			Fn:   a.cmd.sendStuckQueues,
 			C:    make(chan *common.ActionInput, 1),
-			T:    time.NewTicker(stuckDuration),
+			T:    time.NewTicker(a.cmd.queueConfig.stuckInterval()),
 		})
 
 		a.cmd.Add(&common.Action{
@@ -75,15 +91,19 @@ func (a *Action) Create() {
 			Name: TrigDownloadingItems,
 			Fn:   a.cmd.sendDownloadingQueues,
 			C:    make(chan *common.ActionInput, 1),
-			T:    time.NewTicker(finishedDuration),
+			T:    time.NewTicker(a.cmd.queueConfig.downloadingInterval()),
 		})
 	}
+
+	a.cmd.setupRemedy()
 }
 
 // listItem is data formatted for sending a json payload to the website.
 type listItem struct {
 	Name  string        `json:"name"`
 	Queue []interface{} `json:"queue"`
+	// Removed is the queue record IDs removed from the queue since the last incremental payload.
+	Removed []int64 `json:"removed,omitempty"`
 }
 
 // itemList stores an instance->queue map.
@@ -9,6 +9,7 @@ import (
 	"github.com/Notifiarr/notifiarr/pkg/triggers/data"
 	"github.com/Notifiarr/notifiarr/pkg/website"
 	"github.com/Notifiarr/notifiarr/pkg/website/clientinfo"
+	"golift.io/starr"
 )
 
 const TrigSonarrQueue common.TriggerName = "Storing Sonarr instance %d queue."
@@ -30,13 +31,18 @@ type sonarrApp struct {
 
 // storeQueue runs at an interval and saves the queue for an app internally.
 func (app *sonarrApp) storeQueue(ctx context.Context, input *common.ActionInput) {
-	queue, err := app.app.GetQueueContext(ctx, queueItemsMax, 1)
+	records, perPage := app.cmd.queueConfig.queueSize(starr.Sonarr, app.idx+1)
+
+	queue, err := app.app.GetQueueContext(ctx, records, perPage)
 	if err != nil {
 		app.cmd.Errorf("[%s requested] Getting Sonarr Queue (instance %d): %v", input.Type, app.idx+1, err)
 		return
 	}
 
-	for _, record := range queue.Records {
+	downloadIDs := make([]string, len(queue.Records))
+
+	for idx, record := range queue.Records {
+		downloadIDs[idx] = record.DownloadID
 		record.Quality = nil
 		record.Language = nil
 	}
@@ -44,6 +50,7 @@ func (app *sonarrApp) storeQueue(ctx context.Context, input *common.ActionInput)
 	app.cmd.Debugf("[%s requested] Stored Sonarr Queue (%d items), instance %d %s",
 		input.Type, len(queue.Records), app.idx+1, app.app.Name)
 	data.SaveWithID("sonarr", app.idx, queue)
+	app.cmd.ages.update(starr.Sonarr, app.idx+1, downloadIDs)
 }
 
 func (c *cmd) setupSonarr() bool {
@@ -60,10 +67,10 @@ func (c *cmd) setupSonarr() bool {
 		instance := idx + 1
 		if ci.Actions.Apps.Sonarr.Finished(instance) {
 			enable = true
-			ticker = time.NewTicker(finishedDuration)
+			ticker = time.NewTicker(c.queueConfig.pollInterval(starr.Sonarr, instance, true))
 		} else if ci.Actions.Apps.Sonarr.Stuck(instance) {
 			enable = true
-			ticker = time.NewTicker(stuckDuration)
+			ticker = time.NewTicker(c.queueConfig.pollInterval(starr.Sonarr, instance, false))
 		}
 
 		if ticker != nil {
@@ -33,18 +33,19 @@ func (c *cmd) sendStuckQueues(ctx context.Context, input *common.ActionInput) {
 		return
 	}
 
+	payload := &QueuesPaylod{Lidarr: lidarr, Radarr: radarr, Readarr: readarr, Sonarr: sonarr}
+	if c.incremental(string(website.StuckRoute), payload); payload.unchanged() {
+		c.Debugf("[%s requested] No stuck items changed.", input.Type)
+		return
+	}
+
 	c.SendData(&website.Request{
 		Route:      website.StuckRoute,
 		Event:      input.Type,
 		LogPayload: true,
-		LogMsg: fmt.Sprintf("Stuck Items; Lidarr: %d, Radarr: %d, Readarr: %d, Sonarr: %d",
-			lidarr.Len(), radarr.Len(), readarr.Len(), sonarr.Len()),
-		Payload: &QueuesPaylod{
-			Lidarr:  lidarr,
-			Radarr:  radarr,
-			Readarr: readarr,
-			Sonarr:  sonarr,
-		},
+		LogMsg: fmt.Sprintf("Stuck Items; Lidarr: %d, Radarr: %d, Readarr: %d, Sonarr: %d, incremental: %v",
+			lidarr.Len(), radarr.Len(), readarr.Len(), sonarr.Len(), payload.Incremental),
+		Payload: payload,
 	})
 }
 
@@ -0,0 +1,328 @@
+package starrsync
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/Notifiarr/notifiarr/pkg/apps"
+	"github.com/Notifiarr/notifiarr/pkg/triggers/common"
+	"golift.io/starr"
+	"golift.io/starr/radarr"
+	"golift.io/starr/sonarr"
+)
+
+// item is a movie or series, keyed by TMDB or TVDB ID.
+type item struct {
+	id        int64 // TMDB ID for radarr, TVDB ID for sonarr.
+	starrID   int64 // the movie or series ID in the instance.
+	title     string
+	monitored bool
+	root      string
+	profile   string
+	tags      []string
+	data      interface{} // *radarr.Movie or *sonarr.Series.
+}
+
+// mapping holds the root folders, quality profiles and tags in a target instance.
+type mapping struct {
+	roots    []string
+	profiles map[string]int64 // lowercase name -> ID.
+	tags     map[string]int   // lowercase label -> ID.
+}
+
+// instance wraps Radarr and Sonarr, so they can be synced the same way.
+type instance struct {
+	common.StarrApp
+	list    func(ctx context.Context) (map[int64]*item, error)
+	add     func(ctx context.Context, src *item, root string, profile int64, tags []int, search bool) error
+	remove  func(ctx context.Context, dst *item, deleteFiles bool) error
+	monitor func(ctx context.Context, dst *item, monitored bool) error
+	mapping func(ctx context.Context) (*mapping, error)
+	addTag  func(ctx context.Context, label string) (int, error)
+}
+
+// instance returns the app wrapper for an instance number, or nil if it's not configured or not enabled.
+func (c *cmd) instance(app string, number int) *instance {
+	var found *instance
+
+	(&common.StarrApps{
+		Radarr: func(idx int, config *apps.RadarrConfig) {
+			if app == "radarr" && idx == number {
+				found = radarrInstance(idx, config)
+			}
+		},
+		Sonarr: func(idx int, config *apps.SonarrConfig) {
+			if app == "sonarr" && idx == number {
+				found = sonarrInstance(idx, config)
+			}
+		},
+	}).Each(c.Apps)
+
+	return found
+}
+
+func (m *mapping) hasRoot(root string) bool {
+	for _, have := range m.roots {
+		if strings.EqualFold(strings.TrimRight(have, `/\`), strings.TrimRight(root, `/\`)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// tagIDs returns the tag IDs for a list of tag labels, and creates the tags that do not exist.
+func (i *instance) tagIDs(ctx context.Context, mapping *mapping, labels []string) ([]int, error) {
+	ids := []int{}
+
+	for _, label := range labels {
+		id, ok := mapping.tags[strings.ToLower(label)]
+		if !ok {
+			var err error
+			if id, err = i.addTag(ctx, label); err != nil {
+				return nil, fmt.Errorf("adding tag %s: %w", label, err)
+			}
+
+			mapping.tags[strings.ToLower(label)] = id
+		}
+
+		ids = append(ids, id)
+	}
+
+	return ids, nil
+}
+
+// rootFolder returns the root folder an item is in.
+func rootFolder(roots []string, itemPath string) string {
+	root := ""
+
+	for _, have := range roots {
+		have = strings.TrimRight(have, `/\`)
+		if (strings.HasPrefix(itemPath, have+"/") || strings.HasPrefix(itemPath, have+`\`)) && len(have) > len(root) {
+			root = have
+		}
+	}
+
+	if root == "" {
+		if idx := strings.LastIndexAny(itemPath, `/\`); idx > 0 {
+			return itemPath[:idx]
+		}
+	}
+
+	return root
+}
+
+// editor updates the monitored state with the movie or series editor; only the monitored state is changed.
+func editor(ctx context.Context, api starr.APIer, uri, idsKey string, id int64, monitored bool) error {
+	body, _ := json.Marshal(map[string]interface{}{idsKey: []int64{id}, "monitored": monitored})
+
+	var output interface{}
+
+	err := api.PutInto(ctx, starr.Request{URI: uri, Body: bytes.NewReader(body)}, &output)
+	if err != nil {
+		return fmt.Errorf("api.Put(%s): %w", uri, err)
+	}
+
+	return nil
+}
+
+// getNames collects the tags, quality profile names and root folders from an instance.
+func getNames(ctx context.Context, app common.StarrAPI, apiVer string) (*common.StarrNames, []string, error) {
+	names, err := common.GetStarrNames(ctx, app, apiVer)
+	if err != nil {
+		return nil, nil, err //nolint:wrapcheck
+	}
+
+	roots, err := common.GetStarrRootFolders(ctx, app, apiVer)
+	if err != nil {
+		return nil, nil, err //nolint:wrapcheck
+	}
+
+	return names, roots, nil
+}
+
+// newMapping indexes an instance's names, so items can be mapped to them.
+func newMapping(names *common.StarrNames, roots []string, err error) (*mapping, error) {
+	if err != nil {
+		return nil, err
+	}
+
+	output := &mapping{roots: roots, profiles: make(map[string]int64), tags: make(map[string]int)}
+
+	for _, tag := range names.Tags {
+		output.tags[strings.ToLower(tag.Label)] = tag.ID
+	}
+
+	for id, name := range names.Profiles {
+		output.profiles[strings.ToLower(name)] = id
+	}
+
+	return output, nil
+}
+
+func radarrInstance(number int, app *apps.RadarrConfig) *instance { //nolint:funlen
+	return &instance{
+		StarrApp: common.StarrApp{App: starr.Radarr, Instance: number},
+		list: func(ctx context.Context) (map[int64]*item, error) {
+			names, roots, err := getNames(ctx, app, radarr.APIver)
+			if err != nil {
+				return nil, err
+			}
+
+			movies, err := app.GetMovieContext(ctx, 0)
+			if err != nil {
+				return nil, fmt.Errorf("getting movies: %w", err)
+			}
+
+			items := make(map[int64]*item)
+
+			for _, movie := range movies {
+				if movie.TmdbID == 0 {
+					continue
+				}
+
+				items[movie.TmdbID] = &item{
+					id:        movie.TmdbID,
+					starrID:   movie.ID,
+					title:     movie.Title,
+					monitored: movie.Monitored,
+					root:      rootFolder(roots, movie.Path),
+					profile:   names.Profiles[movie.QualityProfileID],
+					tags:      names.TagNames(movie.Tags),
+					data:      movie,
+				}
+			}
+
+			return items, nil
+		},
+		mapping: func(ctx context.Context) (*mapping, error) {
+			return newMapping(getNames(ctx, app, radarr.APIver))
+		},
+		add: func(ctx context.Context, src *item, root string, profile int64, tags []int, search bool) error {
+			movie, _ := src.data.(*radarr.Movie)
+			_, err := app.AddMovieContext(ctx, &radarr.AddMovieInput{
+				Title:               movie.Title,
+				TitleSlug:           movie.TitleSlug,
+				MinimumAvailability: movie.MinimumAvailability,
+				RootFolderPath:      root,
+				TmdbID:              movie.TmdbID,
+				QualityProfileID:    profile,
+				Year:                movie.Year,
+				Images:              movie.Images,
+				AddOptions:          &radarr.AddMovieOptions{SearchForMovie: search},
+				Tags:                tags,
+				Monitored:           movie.Monitored,
+			})
+
+			return err //nolint:wrapcheck
+		},
+		remove: func(ctx context.Context, dst *item, deleteFiles bool) error {
+			return app.DeleteMovieContext(ctx, dst.starrID, deleteFiles, false) //nolint:wrapcheck
+		},
+		monitor: func(ctx context.Context, dst *item, monitored bool) error {
+			return editor(ctx, app.APIer, path.Join(radarr.APIver, "movie", "editor"), "movieIds", dst.starrID, monitored)
+		},
+		addTag: func(ctx context.Context, label string) (int, error) {
+			tag, err := app.AddTagContext(ctx, &starr.Tag{Label: label})
+			if err != nil {
+				return 0, err //nolint:wrapcheck
+			}
+
+			return tag.ID, nil
+		},
+	}
+}
+
+func sonarrInstance(number int, app *apps.SonarrConfig) *instance { //nolint:funlen
+	return &instance{
+		StarrApp: common.StarrApp{App: starr.Sonarr, Instance: number},
+		list: func(ctx context.Context) (map[int64]*item, error) {
+			names, roots, err := getNames(ctx, app, sonarr.APIver)
+			if err != nil {
+				return nil, err
+			}
+
+			series, err := app.GetAllSeriesContext(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("getting series: %w", err)
+			}
+
+			items := make(map[int64]*item)
+
+			for _, show := range series {
+				if show.TvdbID == 0 {
+					continue
+				}
+
+				root := strings.TrimRight(show.RootFolderPath, `/\`)
+				if root == "" {
+					root = rootFolder(roots, show.Path)
+				}
+
+				items[show.TvdbID] = &item{
+					id:        show.TvdbID,
+					starrID:   show.ID,
+					title:     show.Title,
+					monitored: show.Monitored,
+					root:      root,
+					profile:   names.Profiles[show.QualityProfileID],
+					tags:      names.TagNames(show.Tags),
+					data:      show,
+				}
+			}
+
+			return items, nil
+		},
+		mapping: func(ctx context.Context) (*mapping, error) {
+			return newMapping(getNames(ctx, app, sonarr.APIver))
+		},
+		add: func(ctx context.Context, src *item, root string, profile int64, tags []int, search bool) error {
+			show, _ := src.data.(*sonarr.Series)
+			input := &sonarr.AddSeriesInput{
+				Monitored:         show.Monitored,
+				SeasonFolder:      show.SeasonFolder,
+				UseSceneNumbering: show.UseSceneNumbering,
+				QualityProfileID:  profile,
+				TvdbID:            show.TvdbID,
+				SeriesType:        show.SeriesType,
+				Title:             show.Title,
+				TitleSlug:         show.TitleSlug,
+				RootFolderPath:    root,
+				Tags:              tags,
+				Images:            show.Images,
+				AddOptions:        &sonarr.AddSeriesOptions{SearchForMissingEpisodes: search},
+			}
+
+			for _, season := range show.Seasons {
+				input.Seasons = append(input.Seasons, &sonarr.Season{Monitored: season.Monitored, SeasonNumber: season.SeasonNumber})
+			}
+
+			// Sonarr v3 requires a language profile; use the first one in the target.
+			if langs, err := app.GetLanguageProfilesContext(ctx); err == nil && len(langs) > 0 {
+				input.LanguageProfileID = langs[0].ID
+			}
+
+			_, err := app.AddSeriesContext(ctx, input)
+
+			return err //nolint:wrapcheck
+		},
+		remove: func(ctx context.Context, dst *item, deleteFiles bool) error {
+			return app.DeleteSeriesContext(ctx, int(dst.starrID), deleteFiles, false) //nolint:wrapcheck
+		},
+		monitor: func(ctx context.Context, dst *item, monitored bool) error {
+			return editor(ctx, app.APIer, path.Join(sonarr.APIver, "series", "editor"), "seriesIds", dst.starrID, monitored)
+		},
+		addTag: func(ctx context.Context, label string) (int, error) {
+			tag, err := app.AddTagContext(ctx, &starr.Tag{Label: label})
+			if err != nil {
+				return 0, err //nolint:wrapcheck
+			}
+
+			return tag.ID, nil
+		},
+	}
+}
@@ -0,0 +1,342 @@
+package starrsync
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Notifiarr/notifiarr/pkg/triggers/common"
+	"github.com/Notifiarr/notifiarr/pkg/triggers/data"
+	"github.com/Notifiarr/notifiarr/pkg/website"
+	"golift.io/cnfg"
+	"golift.io/starr"
+)
+
+/* Starr sync mirrors new items, monitored state and deletions from one Radarr or Sonarr instance to another. */
+
+const TrigStarrSync common.TriggerName = "Syncing Starr App Instances."
+
+const (
+	stateFile = "starr_sync.json"
+	// This is the max number of changes kept in the audit log.
+	changesMax = 500
+	// These limit the deletes in one run of a rule, so an empty or truncated source library does not
+	// empty the target. More than one delete may not be more than this percent of the previous items.
+	deleteMaxItems   = 25
+	deleteMaxPercent = 10
+)
+
+// ErrInvalidSync is returned when a sync rule is not valid.
+var ErrInvalidSync = fmt.Errorf("invalid starr sync rule")
+
+// Changes made to a target instance.
+const (
+	ChangeAdd     = "add"
+	ChangeDelete  = "delete"
+	ChangeMonitor = "monitor"
+)
+
+// Config is the [starr_sync] config section.
+type Config struct {
+	Interval cnfg.Duration `json:"interval" toml:"interval" xml:"interval" yaml:"interval"` // 0 disables the timer.
+	DryRun   bool          `json:"dryRun" toml:"dry_run" xml:"dry_run" yaml:"dryRun"`
+	Rules    []*Rule       `json:"rules" toml:"rule" xml:"rule" yaml:"rules"`
+}
+
+// Rule mirrors changes from a source instance to a target instance of the same app.
+// The root folder, quality profile and tag lists map source values to target values with "source=target".
+// An entry without an equal sign is the default for root folders and quality profiles, and is always added for tags.
+// Unmapped root folders and quality profiles use the same value in the target. Unmapped tags are not copied.
+type Rule struct {
+	Name        string   `json:"name" toml:"name" xml:"name" yaml:"name"`
+	App         string   `json:"app" toml:"app" xml:"app" yaml:"app"` // radarr or sonarr.
+	Source      int      `json:"source" toml:"source" xml:"source" yaml:"source"`
+	Target      int      `json:"target" toml:"target" xml:"target" yaml:"target"`
+	Add         bool     `json:"add" toml:"add" xml:"add" yaml:"add"`
+	Delete      bool     `json:"delete" toml:"delete" xml:"delete" yaml:"delete"`
+	DeleteFiles bool     `json:"deleteFiles" toml:"delete_files" xml:"delete_files" yaml:"deleteFiles"`
+	Monitored   bool     `json:"monitored" toml:"monitored" xml:"monitored" yaml:"monitored"`
+	Search      bool     `json:"search" toml:"search" xml:"search" yaml:"search"` // search for added items.
+	RootFolders []string `json:"rootFolders" toml:"root_folders" xml:"root_folders" yaml:"rootFolders"`
+	Profiles    []string `json:"qualityProfiles" toml:"quality_profiles" xml:"quality_profiles" yaml:"qualityProfiles"`
+	Tags        []string `json:"tags" toml:"tags" xml:"tags" yaml:"tags"`
+}
+
+// Change is a change made (or that would be made, in dry run mode) to a target instance.
+type Change struct {
+	Time   time.Time `json:"time"`
+	Rule   string    `json:"rule"`
+	App    starr.App `json:"app"`
+	Target int       `json:"target"`
+	Action string    `json:"action"`
+	ID     int64     `json:"id"` // TMDB ID for radarr, TVDB ID for sonarr.
+	Title  string    `json:"title"`
+	Detail string    `json:"detail"`
+	DryRun bool      `json:"dryRun"`
+	Error  string    `json:"error,omitempty"`
+}
+
+// Conflict is an item that was not synced because the instances disagree.
+type Conflict struct {
+	Rule   string `json:"rule"`
+	ID     int64  `json:"id"`
+	Title  string `json:"title"`
+	Reason string `json:"reason"`
+}
+
+// Report is the result of running the sync rules once.
+type Report struct {
+	Start     time.Time     `json:"start"`
+	Elapsed   cnfg.Duration `json:"elapsed"`
+	DryRun    bool          `json:"dryRun"`
+	Changes   []*Change     `json:"changes"`
+	Conflicts []*Conflict   `json:"conflicts"`
+	Errors    []string      `json:"errors,omitempty"`
+}
+
+// seen is the state of a source item the last time it was synced.
+type seen struct {
+	Monitored bool `json:"monitored"`
+	InTarget  bool `json:"inTarget"` // in the target, or was and got removed from it.
+}
+
+// state is saved to a file, so deletions and monitored changes can be found between restarts.
+type state struct {
+	Rules map[string]map[int64]*seen `json:"rules"` // rule key -> source item ID -> state.
+	Log   []*Change                  `json:"log"`   // oldest first.
+}
+
+// Action contains the exported methods for this package.
+type Action struct {
+	cmd *cmd
+}
+
+type cmd struct {
+	*common.Config
+	sync  *Config
+	state *state
+	last  *Report
+	mu    sync.RWMutex
+}
+
+// New configures the library.
+func New(config *common.Config, sync *Config) *Action {
+	return &Action{cmd: &cmd{Config: config, sync: sync}}
+}
+
+// Create initializes the library.
+func (a *Action) Create() {
+	a.cmd.create()
+}
+
+func (c *cmd) create() {
+	if c.sync == nil || len(c.sync.Rules) == 0 {
+		return
+	}
+
+	c.state = &state{Rules: make(map[string]map[int64]*seen)}
+	if err := c.ReadDataFile(stateFile, c.state); err != nil {
+		c.Errorf("Loading starr sync data: %v", err)
+	}
+
+	var ticker *time.Ticker
+
+	if c.sync.Interval.Duration > 0 {
+		ticker = time.NewTicker(c.sync.Interval.Duration)
+		c.Printf("==> Starr Sync timer started, interval:%s rules:%d dry_run:%v",
+			c.sync.Interval, len(c.sync.Rules), c.sync.DryRun)
+	}
+
+	c.Add(&common.Action{
+		Name: TrigStarrSync,
+		Fn:   c.runRules,
+		C:    make(chan *common.ActionInput, 1),
+		T:    ticker,
+	})
+}
+
+// Validate checks the sync rules for errors.
+func (c *Config) Validate() error {
+	for idx, rule := range c.Rules {
+		if rule == nil {
+			continue
+		}
+
+		switch rule.App = strings.ToLower(rule.App); rule.App {
+		case "radarr", "sonarr":
+		default:
+			return fmt.Errorf("%w: rule %d: app must be radarr or sonarr", ErrInvalidSync, idx+1)
+		}
+
+		if rule.Source < 1 || rule.Target < 1 || rule.Source == rule.Target {
+			return fmt.Errorf("%w: rule %d: source and target must be different instance numbers", ErrInvalidSync, idx+1)
+		}
+
+		if rule.Name == "" {
+			rule.Name = fmt.Sprintf("%s %d to %d", rule.App, rule.Source, rule.Target)
+		}
+	}
+
+	return nil
+}
+
+// key identifies a rule in the state file. The name may change, so it is not used.
+func (r *Rule) key() string {
+	return r.App + strconv.Itoa(r.Source) + ">" + strconv.Itoa(r.Target)
+}
+
+// mapValue returns the target value for a source value, using a source=target list.
+// Returns the default (an entry without an equal sign) or the source value if there is no mapping.
+func mapValue(list []string, value string) string {
+	output := value
+
+	for _, entry := range list {
+		if from, to, ok := strings.Cut(entry, "="); !ok {
+			output = strings.TrimSpace(entry)
+		} else if strings.EqualFold(strings.TrimSpace(from), value) {
+			return strings.TrimSpace(to)
+		}
+	}
+
+	return output
+}
+
+// mapTags returns the target tag labels for a list of source tag labels.
+func mapTags(list []string, labels []string) []string {
+	output := []string{}
+
+	for _, entry := range list {
+		from, to, ok := strings.Cut(entry, "=")
+		if !ok {
+			output = append(output, strings.TrimSpace(entry))
+			continue
+		}
+
+		for _, label := range labels {
+			if strings.EqualFold(strings.TrimSpace(from), label) {
+				output = append(output, strings.TrimSpace(to))
+			}
+		}
+	}
+
+	return output
+}
+
+// Run runs the sync rules now. Returns false if there are no sync rules.
+func (a *Action) Run(event website.EventType) bool {
+	if a.cmd.state == nil {
+		return false
+	}
+
+	return a.cmd.Exec(&common.ActionInput{Type: event}, TrigStarrSync)
+}
+
+// Changed runs the sync rules if an instance is the source in a rule.
+// This is called after a movie or series is added or updated with the API.
+func (a *Action) Changed(app starr.App, instance int) {
+	if a.cmd.state == nil {
+		return
+	}
+
+	for _, rule := range a.cmd.sync.Rules {
+		if rule == nil || rule.Source != instance || !strings.EqualFold(rule.App, string(app)) {
+			continue
+		}
+
+		if trig := a.cmd.Get(TrigStarrSync); trig != nil {
+			select {
+			case trig.C <- &common.ActionInput{Type: website.EventAPI}:
+			default: // a sync is already queued; do not block the API request.
+			}
+		}
+
+		return
+	}
+}
+
+func (c *cmd) runRules(ctx context.Context, input *common.ActionInput) {
+	report := &Report{Start: time.Now(), DryRun: c.sync.DryRun, Changes: []*Change{}, Conflicts: []*Conflict{}}
+
+	for _, rule := range c.sync.Rules {
+		if rule == nil {
+			continue
+		}
+
+		if err := c.syncRule(ctx, rule, report); err != nil {
+			c.Errorf("[%s requested] Starr Sync: %s: %v", input.Type, rule.Name, err)
+			report.Errors = append(report.Errors, fmt.Sprintf("%s: %v", rule.Name, err))
+		}
+	}
+
+	report.Elapsed.Duration = time.Since(report.Start).Round(time.Millisecond)
+	c.saveReport(report)
+
+	if len(report.Changes) == 0 && len(report.Conflicts) == 0 && len(report.Errors) == 0 {
+		return
+	}
+
+	c.Printf("[%s requested] Starr Sync: %d changes, %d conflicts, %d errors, dry run: %v, elapsed: %v",
+		input.Type, len(report.Changes), len(report.Conflicts), len(report.Errors), report.DryRun, report.Elapsed)
+
+	c.SendData(&website.Request{
+		Route:      website.StarrSyncRoute,
+		Event:      input.Type,
+		LogPayload: true,
+		LogMsg: fmt.Sprintf("Starr Sync (%d changes, %d conflicts)",
+			len(report.Changes), len(report.Conflicts)),
+		Payload: report,
+	})
+}
+
+// saveReport stores the report for the API and GUI, and writes the state file.
+func (c *cmd) saveReport(report *Report) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.last = report
+	data.Save("starrSyncReport", report)
+
+	if c.state.Log = append(c.state.Log, report.Changes...); len(c.state.Log) > changesMax {
+		c.state.Log = c.state.Log[len(c.state.Log)-changesMax:]
+	}
+
+	if err := c.WriteDataFile(stateFile, c.state); err != nil {
+		c.Errorf("Saving starr sync data: %v", err)
+	}
+}
+
+// HandleReport returns the last sync report and the audit log of changes.
+// @Summary      Retrieve starr sync report.
+// @Description  Returns the report from the last time the sync rules ran, including conflicts,
+// @Description  and the audit log of recent changes made to target instances (newest first).
+// @Tags         Triggers
+// @Produce      json
+// @Success      200  {object} apps.Respond.apiResponse{message=starrsync.HandleReport.report} "sync report"
+// @Failure      501  {object} apps.Respond.apiResponse{message=string} "no sync rules"
+// @Failure      404  {object} string "bad token or api key"
+// @Router       /api/starrsync/report [get]
+// @Security     ApiKeyAuth
+func (a *Action) HandleReport(_ *http.Request) (int, interface{}) {
+	if a.cmd.state == nil {
+		return http.StatusNotImplemented, "No starr sync rules configured."
+	}
+
+	a.cmd.mu.RLock()
+	defer a.cmd.mu.RUnlock()
+
+	type report struct {
+		Last    *Report   `json:"last"`
+		Changes []*Change `json:"changes"`
+	}
+
+	changes := make([]*Change, 0, len(a.cmd.state.Log))
+	for idx := len(a.cmd.state.Log) - 1; idx >= 0; idx-- {
+		changes = append(changes, a.cmd.state.Log[idx])
+	}
+
+	return http.StatusOK, &report{Last: a.cmd.last, Changes: changes}
+}
@@ -0,0 +1,49 @@
+package starrsync
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMapValue(t *testing.T) {
+	t.Parallel()
+
+	assert := assert.New(t)
+
+	assert.Equal("/movies", mapValue(nil, "/movies"), "no list")
+	assert.Equal("/films", mapValue([]string{"/movies = /films"}, "/movies"))
+	assert.Equal("Any", mapValue([]string{"HD=Any"}, "hd"), "mapping ignores case")
+	assert.Equal("/movies", mapValue([]string{"/tv=/shows"}, "/movies"), "not mapped")
+	assert.Equal("/other", mapValue([]string{"/tv=/shows", " /other "}, "/movies"), "a value without = is the default")
+	assert.Equal("/films", mapValue([]string{"/other", "/movies=/films"}, "/movies"), "a mapping beats the default")
+}
+
+func TestMapTags(t *testing.T) {
+	t.Parallel()
+
+	assert := assert.New(t)
+
+	assert.Equal([]string{}, mapTags(nil, []string{"4k"}), "no list")
+	assert.Equal([]string{"synced"}, mapTags([]string{" synced "}, []string{"4k"}), "a tag without = is always added")
+	assert.Equal([]string{"uhd"}, mapTags([]string{"4K = uhd"}, []string{"4k", "hdr"}))
+	assert.Equal([]string{}, mapTags([]string{"anime=cartoon"}, []string{"4k"}), "not mapped")
+	assert.Equal([]string{"uhd", "synced"}, mapTags([]string{"4k=uhd", "synced"}, []string{"4k"}))
+}
+
+func TestRootFolder(t *testing.T) {
+	t.Parallel()
+
+	roots := []string{"/movies", "/movies4k/", `D:\Movies`}
+	paths := map[string]string{
+		"/movies/Alien (1979)":    "/movies",
+		"/movies4k/Alien (1979)":  "/movies4k",
+		`D:\Movies\Alien (1979)`:  `D:\Movies`,
+		"/moviesold/Alien (1979)": "/moviesold",
+		"/other/Alien (1979)":     "/other",
+	}
+
+	for path, want := range paths {
+		assert.Equal(t, want, rootFolder(roots, path), path)
+	}
+}
@@ -0,0 +1,206 @@
+package starrsync
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// syncRule mirrors the changes in a rule's source instance to its target instance.
+func (c *cmd) syncRule(ctx context.Context, rule *Rule, report *Report) error {
+	source, target := c.instance(rule.App, rule.Source), c.instance(rule.App, rule.Target)
+	if source == nil || target == nil {
+		return fmt.Errorf("%w: %s %d or %d is not configured or not enabled", ErrInvalidSync, rule.App, rule.Source, rule.Target)
+	}
+
+	srcItems, err := source.list(ctx)
+	if err != nil {
+		return fmt.Errorf("getting %s %d library: %w", rule.App, rule.Source, err)
+	}
+
+	dstItems, err := target.list(ctx)
+	if err != nil {
+		return fmt.Errorf("getting %s %d library: %w", rule.App, rule.Target, err)
+	}
+
+	c.mu.RLock()
+	previous := c.state.Rules[rule.key()]
+	c.mu.RUnlock()
+
+	run := &ruleRun{cmd: c, rule: rule, report: report, target: target, current: make(map[int64]*seen)}
+
+	for _, id := range sortedIDs(srcItems) {
+		run.syncItem(ctx, srcItems[id], dstItems[id], previous[id])
+	}
+
+	if rule.Delete && previous != nil {
+		run.deleteItems(ctx, srcItems, dstItems, previous)
+	}
+
+	if !c.sync.DryRun {
+		c.mu.Lock()
+		c.state.Rules[rule.key()] = run.current
+		c.mu.Unlock()
+	}
+
+	return nil
+}
+
+// ruleRun holds the data for one run of a sync rule.
+type ruleRun struct {
+	*cmd
+	rule    *Rule
+	report  *Report
+	target  *instance
+	current map[int64]*seen // the new state for the rule.
+	mapping *mapping        // target root folders, profiles and tags; fetched when needed.
+}
+
+// syncItem compares a source item with the target item and the previous state, and makes changes.
+func (r *ruleRun) syncItem(ctx context.Context, src, dst *item, prev *seen) {
+	// An item removed from the target stays InTarget, so it's not added again on a later run.
+	removed := dst == nil && prev != nil && prev.InTarget
+	r.current[src.id] = &seen{Monitored: src.monitored, InTarget: dst != nil || removed}
+
+	switch {
+	case removed:
+		r.conflict(src, "removed from target; not added again")
+	case dst == nil && r.rule.Add:
+		r.add(ctx, src)
+	case dst == nil:
+	case dst.monitored == src.monitored:
+	case r.rule.Monitored && prev != nil && prev.Monitored != src.monitored:
+		r.change(ChangeMonitor, dst, "monitored: "+strconv.FormatBool(src.monitored), func() error {
+			return r.target.monitor(ctx, dst, src.monitored)
+		})
+	case r.rule.Monitored:
+		r.conflict(src, fmt.Sprintf("monitored in source: %v, in target: %v", src.monitored, dst.monitored))
+	}
+}
+
+// deleteItems deletes the target items that were deleted from the source since the last run.
+// An empty or truncated source library looks like a lot of deletes, so those are skipped and reported.
+func (r *ruleRun) deleteItems(ctx context.Context, srcItems, dstItems map[int64]*item, previous map[int64]*seen) {
+	deletes := []int64{}
+
+	for _, id := range sortedIDs(dstItems) {
+		if _, ok := previous[id]; ok && srcItems[id] == nil {
+			deletes = append(deletes, id)
+		}
+	}
+
+	if len(deletes) == 0 {
+		return
+	}
+
+	if len(srcItems) == 0 || len(deletes) > deleteMaxItems ||
+		(len(deletes) > 1 && len(deletes)*100 > len(previous)*deleteMaxPercent) {
+		r.report.Conflicts = append(r.report.Conflicts, &Conflict{
+			Rule: r.rule.Name,
+			Reason: fmt.Sprintf("%d of %d items are missing from the source library; deletes skipped",
+				len(deletes), len(previous)),
+		})
+
+		for _, id := range deletes {
+			r.current[id] = previous[id] // still deleted next run, if the source library is not restored.
+		}
+
+		return
+	}
+
+	for _, id := range deletes {
+		dst := dstItems[id]
+		r.change(ChangeDelete, dst, "deleted from source", func() error {
+			return r.target.remove(ctx, dst, r.rule.DeleteFiles)
+		})
+	}
+}
+
+func (r *ruleRun) add(ctx context.Context, src *item) {
+	if r.mapping == nil {
+		var err error
+		if r.mapping, err = r.target.mapping(ctx); err != nil {
+			r.current[src.id].InTarget = false
+			r.conflict(src, "getting target root folders, profiles or tags: "+err.Error())
+
+			return
+		}
+	}
+
+	root := mapValue(r.rule.RootFolders, src.root)
+	if !r.mapping.hasRoot(root) {
+		r.conflict(src, "root folder not found in target: "+root)
+		return
+	}
+
+	profile := mapValue(r.rule.Profiles, src.profile)
+
+	profileID, ok := r.mapping.profiles[strings.ToLower(profile)]
+	if !ok {
+		r.conflict(src, "quality profile not found in target: "+profile)
+		return
+	}
+
+	tags := mapTags(r.rule.Tags, src.tags)
+	detail := fmt.Sprintf("root folder: %s, quality profile: %s, tags: %s", root, profile, strings.Join(tags, ", "))
+
+	r.current[src.id].InTarget = r.change(ChangeAdd, src, detail, func() error {
+		tagIDs, err := r.target.tagIDs(ctx, r.mapping, tags)
+		if err != nil {
+			return err
+		}
+
+		return r.target.add(ctx, src, root, profileID, tagIDs, r.rule.Search)
+	})
+}
+
+// change makes a change to the target instance, unless it's a dry run. Returns true if the change was made.
+func (r *ruleRun) change(action string, item *item, detail string, do func() error) bool {
+	change := &Change{
+		Time:   time.Now(),
+		Rule:   r.rule.Name,
+		App:    r.target.App,
+		Target: r.rule.Target,
+		Action: action,
+		ID:     item.id,
+		Title:  item.title,
+		Detail: detail,
+		DryRun: r.sync.DryRun,
+	}
+	r.report.Changes = append(r.report.Changes, change)
+
+	if r.sync.DryRun {
+		r.Printf("Starr Sync (dry run): %s: would %s '%s' in %s %d: %s",
+			r.rule.Name, action, item.title, r.target.App, r.rule.Target, detail)
+		return false
+	}
+
+	if err := do(); err != nil {
+		change.Error = err.Error()
+		r.Errorf("Starr Sync: %s: %s '%s' in %s %d: %v", r.rule.Name, action, item.title, r.target.App, r.rule.Target, err)
+
+		return false
+	}
+
+	r.Printf("Starr Sync: %s: %s '%s' in %s %d: %s", r.rule.Name, action, item.title, r.target.App, r.rule.Target, detail)
+
+	return true
+}
+
+func (r *ruleRun) conflict(item *item, reason string) {
+	r.report.Conflicts = append(r.report.Conflicts, &Conflict{Rule: r.rule.Name, ID: item.id, Title: item.title, Reason: reason})
+}
+
+func sortedIDs(items map[int64]*item) []int64 {
+	ids := make([]int64, 0, len(items))
+	for id := range items {
+		ids = append(ids, id)
+	}
+
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	return ids
+}
@@ -0,0 +1,180 @@
+package starrsync
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/Notifiarr/notifiarr/pkg/logs"
+	"github.com/Notifiarr/notifiarr/pkg/triggers/common"
+	"github.com/stretchr/testify/assert"
+)
+
+var errTest = fmt.Errorf("test error")
+
+func TestSyncItem(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		rule      Rule
+		dst       *item
+		prev      *seen
+		mapErr    error
+		changes   []string
+		conflicts int
+		inTarget  bool
+	}{
+		"new item not added": {
+			inTarget: false,
+		},
+		"new item added": {
+			rule:     Rule{Add: true},
+			changes:  []string{ChangeAdd},
+			inTarget: true,
+		},
+		"new item without target mapping": {
+			rule:      Rule{Add: true},
+			mapErr:    errTest,
+			conflicts: 1,
+			inTarget:  false,
+		},
+		"removed from target is not added again": {
+			rule:      Rule{Add: true},
+			prev:      &seen{Monitored: true, InTarget: true},
+			conflicts: 1,
+			inTarget:  true,
+		},
+		"never in target is added": {
+			rule:     Rule{Add: true},
+			prev:     &seen{Monitored: true},
+			changes:  []string{ChangeAdd},
+			inTarget: true,
+		},
+		"monitored in both": {
+			rule:     Rule{Monitored: true},
+			dst:      &item{id: 1, monitored: true},
+			prev:     &seen{Monitored: true, InTarget: true},
+			inTarget: true,
+		},
+		"monitored changed in source": {
+			rule:     Rule{Monitored: true},
+			dst:      &item{id: 1, monitored: false},
+			prev:     &seen{Monitored: false, InTarget: true},
+			changes:  []string{ChangeMonitor},
+			inTarget: true,
+		},
+		"monitored changed in target": {
+			rule:      Rule{Monitored: true},
+			dst:       &item{id: 1, monitored: false},
+			prev:      &seen{Monitored: true, InTarget: true},
+			conflicts: 1,
+			inTarget:  true,
+		},
+		"monitored not synced": {
+			dst:      &item{id: 1, monitored: false},
+			prev:     &seen{Monitored: false, InTarget: true},
+			inTarget: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			rule := test.rule
+			run := &ruleRun{
+				cmd:     &cmd{Config: &common.Config{Logger: logs.New()}, sync: &Config{}},
+				rule:    &rule,
+				report:  &Report{},
+				current: make(map[int64]*seen),
+				target: &instance{
+					add:     func(context.Context, *item, string, int64, []int, bool) error { return nil },
+					monitor: func(context.Context, *item, bool) error { return nil },
+					mapping: func(context.Context) (*mapping, error) {
+						return &mapping{roots: []string{"/movies"}, profiles: map[string]int64{"hd": 1}}, test.mapErr
+					},
+				},
+			}
+			src := &item{id: 1, title: "title", monitored: true, root: "/movies/", profile: "HD"}
+
+			run.syncItem(context.Background(), src, test.dst, test.prev)
+
+			changes := []string{}
+			for _, change := range run.report.Changes {
+				assert.Empty(t, change.Error)
+				changes = append(changes, change.Action)
+			}
+
+			assert.ElementsMatch(t, test.changes, changes)
+			assert.Len(t, run.report.Conflicts, test.conflicts)
+			assert.Equal(t, test.inTarget, run.current[src.id].InTarget)
+			assert.Equal(t, src.monitored, run.current[src.id].Monitored)
+		})
+	}
+}
+
+func TestDeleteItems(t *testing.T) {
+	t.Parallel()
+
+	assert := assert.New(t)
+	// library returns items with IDs 1 to count.
+	library := func(count int) map[int64]*item {
+		items := make(map[int64]*item)
+		for id := int64(1); id <= int64(count); id++ {
+			items[id] = &item{id: id, title: fmt.Sprint("item ", id)}
+		}
+
+		return items
+	}
+	// deleteItems runs a delete rule with items left in the source and items seen in the source and target last run.
+	deleteItems := func(source, previous int) (int, *ruleRun) {
+		removed := 0
+		seenItems := make(map[int64]*seen)
+
+		for id := range library(previous) {
+			seenItems[id] = &seen{InTarget: true}
+		}
+
+		run := &ruleRun{
+			cmd:     &cmd{Config: &common.Config{Logger: logs.New()}, sync: &Config{}},
+			rule:    &Rule{Name: "test", Delete: true},
+			report:  &Report{},
+			current: make(map[int64]*seen),
+			target: &instance{remove: func(context.Context, *item, bool) error {
+				removed++
+				return nil
+			}},
+		}
+		run.deleteItems(context.Background(), library(source), library(previous), seenItems)
+
+		return removed, run
+	}
+
+	removed, run := deleteItems(100, 100)
+	assert.Zero(removed)
+	assert.Empty(run.report.Conflicts)
+
+	removed, _ = deleteItems(99, 100)
+	assert.Equal(1, removed)
+
+	removed, _ = deleteItems(1, 2)
+	assert.Equal(1, removed, "a small library can lose one item")
+
+	removed, run = deleteItems(90, 100)
+	assert.Equal(10, removed, "ten percent can be deleted")
+	assert.Empty(run.report.Conflicts)
+
+	// Skipped deletes are reported, and kept in the state for the next run.
+	removed, run = deleteItems(89, 100)
+	assert.Zero(removed, "more than ten percent is not deleted")
+	assert.Len(run.report.Conflicts, 1)
+	assert.Len(run.current, 11)
+
+	removed, run = deleteItems(970, 1000)
+	assert.Zero(removed, "more than the limit is not deleted")
+	assert.Len(run.report.Conflicts, 1)
+	assert.Len(run.current, 30)
+
+	removed, run = deleteItems(0, 1)
+	assert.Zero(removed, "an empty source deletes nothing")
+	assert.Len(run.report.Conflicts, 1)
+	assert.Len(run.current, 1)
+}
@@ -10,18 +10,24 @@ import (
 	"github.com/Notifiarr/notifiarr/pkg/apps"
 	"github.com/Notifiarr/notifiarr/pkg/mnd"
 	"github.com/Notifiarr/notifiarr/pkg/snapshot"
+	"github.com/Notifiarr/notifiarr/pkg/triggers/backlog"
 	"github.com/Notifiarr/notifiarr/pkg/triggers/backups"
+	"github.com/Notifiarr/notifiarr/pkg/triggers/calendar"
 	"github.com/Notifiarr/notifiarr/pkg/triggers/cfsync"
 	"github.com/Notifiarr/notifiarr/pkg/triggers/commands"
 	"github.com/Notifiarr/notifiarr/pkg/triggers/common"
+	"github.com/Notifiarr/notifiarr/pkg/triggers/compare"
 	"github.com/Notifiarr/notifiarr/pkg/triggers/crontimer"
 	"github.com/Notifiarr/notifiarr/pkg/triggers/dashboard"
 	"github.com/Notifiarr/notifiarr/pkg/triggers/emptytrash"
 	"github.com/Notifiarr/notifiarr/pkg/triggers/filewatch"
 	"github.com/Notifiarr/notifiarr/pkg/triggers/gaps"
 	"github.com/Notifiarr/notifiarr/pkg/triggers/plexcron"
+	"github.com/Notifiarr/notifiarr/pkg/triggers/plexscan"
+	"github.com/Notifiarr/notifiarr/pkg/triggers/seeding"
 	"github.com/Notifiarr/notifiarr/pkg/triggers/snapcron"
 	"github.com/Notifiarr/notifiarr/pkg/triggers/starrqueue"
+	"github.com/Notifiarr/notifiarr/pkg/triggers/starrsync"
 	"github.com/Notifiarr/notifiarr/pkg/website"
 	"github.com/Notifiarr/notifiarr/pkg/website/clientinfo"
 )
@@ -34,6 +40,16 @@ type Config struct {
 	WatchFiles []*filewatch.WatchFile
 	Commands   []*commands.Command
 	CIC        *clientinfo.Config
+	DataDir    string // optional, for triggers that persist data.
+	Series     dashboard.SeriesConfig
+	Seeding    *seeding.Config
+	StarrQueue *starrqueue.QueueConfig
+	StuckQueue *starrqueue.RemedyConfig
+	Calendar   *calendar.Config
+	Backlog    *backlog.Config
+	Compare    *compare.Config
+	StarrSync  *starrsync.Config
+	TrashLocal *cfsync.LocalConfig
 	common.Services
 	mnd.Logger
 }
@@ -54,6 +70,12 @@ type Actions struct {
 	StarrQueue *starrqueue.Action
 	Commands   *commands.Action
 	EmptyTrash *emptytrash.Action
+	PlexScan   *plexscan.Action
+	Seeding    *seeding.Action
+	Calendar   *calendar.Action
+	Backlog    *backlog.Action
+	Compare    *compare.Action
+	StarrSync  *starrsync.Action
 }
 
 // New turns a populated Config into a pile of Actions.
@@ -65,21 +87,29 @@ func New(config *Config) *Actions {
 		Logger:   config.Logger,
 		CIC:      config.CIC,
 		Services: config.Services,
+		DataDir:  config.DataDir,
 	}
 	plex := plexcron.New(common, config.Apps.Plex)
+	cmds := commands.New(common, config.Commands)
 
 	return &Actions{
 		PlexCron:   plex,
 		Backups:    backups.New(common),
-		CFSync:     cfsync.New(common),
+		CFSync:     cfsync.New(common, config.TrashLocal),
 		CronTimer:  crontimer.New(common),
-		Dashboard:  dashboard.New(common, plex),
-		FileWatch:  filewatch.New(common, config.WatchFiles),
+		Dashboard:  dashboard.New(common, plex, config.Series),
+		FileWatch:  filewatch.New(common, config.WatchFiles, cmds),
 		Gaps:       gaps.New(common),
 		SnapCron:   snapcron.New(common),
-		StarrQueue: starrqueue.New(common),
-		Commands:   commands.New(common, config.Commands),
+		StarrQueue: starrqueue.New(common, config.StarrQueue, config.StuckQueue),
+		Commands:   cmds,
 		EmptyTrash: emptytrash.New(common),
+		PlexScan:   plexscan.New(common),
+		Seeding:    seeding.New(common, config.Seeding),
+		Calendar:   calendar.New(common, config.Calendar),
+		Backlog:    backlog.New(common, config.Backlog),
+		Compare:    compare.New(common, config.Compare),
+		StarrSync:  starrsync.New(common, config.StarrSync),
 		Timers:     common,
 	}
 }
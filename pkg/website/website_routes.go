@@ -6,6 +6,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/Notifiarr/notifiarr/pkg/apps"
 	"github.com/Notifiarr/notifiarr/pkg/apps/apppkg/plex"
 	"github.com/Notifiarr/notifiarr/pkg/snapshot"
 	"golift.io/cnfg"
@@ -36,6 +37,8 @@ type Payload struct {
 	Plex *plex.Sessions        `json:"plex,omitempty"`
 	Snap *snapshot.Snapshot    `json:"snapshot,omitempty"`
 	Load *plex.IncomingWebhook `json:"payload,omitempty"`
+	// Starr contains the library items matched to a Plex webhook, when enrichment is enabled.
+	Starr []*apps.StarrMatch `json:"starr,omitempty"`
 }
 
 // Request is used when sending data through a channel.
@@ -104,25 +107,29 @@ api/v1/user/trash?app=...
   sonarr
 */
 const (
-	BaseURL             = "https://notifiarr.com"
-	userRoute1    Route = "/api/v1/user"
-	userRoute2    Route = "/api/v2/user"
-	ClientRoute   Route = userRoute2 + "/client"
-	CFSyncRoute   Route = userRoute1 + "/trash"
-	GapsRoute     Route = userRoute1 + "/gaps"
-	notifiRoute   Route = "/api/v1/notification"
-	DashRoute     Route = notifiRoute + "/dashboard"
-	StuckRoute    Route = notifiRoute + "/stuck"
-	DownloadRoute Route = notifiRoute + "/downloads"
-	PlexRoute     Route = notifiRoute + "/plex"
-	SnapRoute     Route = notifiRoute + "/snapshot"
-	SvcRoute      Route = notifiRoute + "/services"
-	CorruptRoute  Route = notifiRoute + "/corruption"
-	BackupRoute   Route = notifiRoute + "/backup"
-	TestRoute     Route = notifiRoute + "/test"
-	PkgRoute      Route = notifiRoute + "/packageManager"
-	LogLineRoute  Route = notifiRoute + "/logWatcher"
-	CommandRoute  Route = notifiRoute + "/command"
+	BaseURL               = "https://notifiarr.com"
+	userRoute1      Route = "/api/v1/user"
+	userRoute2      Route = "/api/v2/user"
+	ClientRoute     Route = userRoute2 + "/client"
+	CFSyncRoute     Route = userRoute1 + "/trash"
+	GapsRoute       Route = userRoute1 + "/gaps"
+	notifiRoute     Route = "/api/v1/notification"
+	DashRoute       Route = notifiRoute + "/dashboard"
+	StuckRoute      Route = notifiRoute + "/stuck"
+	DownloadRoute   Route = notifiRoute + "/downloads"
+	PlexRoute       Route = notifiRoute + "/plex"
+	SnapRoute       Route = notifiRoute + "/snapshot"
+	SvcRoute        Route = notifiRoute + "/services"
+	CorruptRoute    Route = notifiRoute + "/corruption"
+	BackupRoute     Route = notifiRoute + "/backup"
+	TestRoute       Route = notifiRoute + "/test"
+	PkgRoute        Route = notifiRoute + "/packageManager"
+	LogLineRoute    Route = notifiRoute + "/logWatcher"
+	CommandRoute    Route = notifiRoute + "/command"
+	CalendarRoute   Route = notifiRoute + "/calendar"
+	CompareRoute    Route = notifiRoute + "/libraryCompare"
+	StarrSyncRoute  Route = notifiRoute + "/starrSync"
+	TrashLocalRoute Route = notifiRoute + "/trashLocal"
 )
 
 // Path adds parameters to a route path and turns it into a string.